@@ -122,6 +122,14 @@ func (m *ControllerManager) Start(ctx context.Context) error {
 			log.Error(err, "Register app controller error")
 			return err
 		}
+		if err := (mountctrl.NewEphemeralVolumeController(m.client)).SetupWithManager(m.mgr); err != nil {
+			log.Error(err, "Register ephemeral volume controller error")
+			return err
+		}
+		if err := (mountctrl.NewSidecarDriftController(m.client)).SetupWithManager(m.mgr); err != nil {
+			log.Error(err, "Register sidecar drift controller error")
+			return err
+		}
 	}
 
 	if m.enableMountManager {
@@ -135,15 +143,15 @@ func (m *ControllerManager) Start(ctx context.Context) error {
 		}
 	}
 
-	if config.CacheClientConf {
-		if err := (mountctrl.NewPVController(m.client)).SetupWithManager(m.mgr); err != nil {
-			log.Error(err, "Register pv controller error")
-			return err
-		}
-		if err := (mountctrl.NewSecretController(m.client)).SetupWithManager(m.mgr); err != nil {
-			log.Error(err, "Register secret controller error")
-			return err
-		}
+	// secrets of PVs are watched to cache client config of ee volumes if CacheClientConf is set,
+	// and to rotate credentials of ce volumes anyway
+	if err := (mountctrl.NewPVController(m.client)).SetupWithManager(m.mgr); err != nil {
+		log.Error(err, "Register pv controller error")
+		return err
+	}
+	if err := (mountctrl.NewSecretController(m.client)).SetupWithManager(m.mgr); err != nil {
+		log.Error(err, "Register secret controller error")
+		return err
 	}
 
 	if err := m.mgr.Start(ctx); err != nil {
@@ -0,0 +1,161 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+var (
+	revisionNamespace string
+	revisionAuthor    string
+)
+
+var configRevisionCmd = &cobra.Command{
+	Use:   "config-revision",
+	Short: "view, diff and roll back revisions of the CSI config map",
+	Long: `View, diff and roll back revisions of the CSI config map, which are recorded when the config map is
+updated by CSI Dashboard or rolled back by this command. It uses the kubeconfig or in-cluster config.`,
+}
+
+var configRevisionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list revisions of the CSI config map, the latest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newRevisionClient()
+		if err != nil {
+			return err
+		}
+		revisions, err := config.ListConfigRevisions(context.TODO(), client, revisionNamespace, config.GetGlobalConfigName())
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "REVISION\tAUTHOR\tTIME")
+		for _, r := range revisions {
+			author := r.Author
+			if author == "" {
+				author = "<unknown>"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", r.Revision, author, r.Time.Format(time.RFC3339))
+		}
+		return w.Flush()
+	},
+}
+
+var configRevisionShowCmd = &cobra.Command{
+	Use:   "show <revision>",
+	Short: "show the config and the diff from the previous revision",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		revision, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid revision %s", args[0])
+		}
+		client, err := newRevisionClient()
+		if err != nil {
+			return err
+		}
+		r, err := config.GetConfigRevision(context.TODO(), client, revisionNamespace, config.GetGlobalConfigName(), revision)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "# revision %d by %q at %s\n%s\n# diff\n%s", r.Revision, r.Author, r.Time.Format(time.RFC3339), r.Config, r.Diff)
+		return nil
+	},
+}
+
+var configRevisionDiffCmd = &cobra.Command{
+	Use:   "diff <revision> [<revision>]",
+	Short: "diff a revision with another one, or with the current config",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		revisions := make([]int, 2)
+		for i, arg := range args {
+			r, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("invalid revision %s", arg)
+			}
+			revisions[i] = r
+		}
+		client, err := newRevisionClient()
+		if err != nil {
+			return err
+		}
+		diff, err := config.DiffConfigRevisions(context.TODO(), client, revisionNamespace, config.GetGlobalConfigName(), revisions[0], revisions[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), diff)
+		return nil
+	},
+}
+
+var configRevisionRollbackCmd = &cobra.Command{
+	Use:   "rollback <revision>",
+	Short: "roll back the CSI config map to a revision, which is checked before applied",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		revision, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid revision %s", args[0])
+		}
+		client, err := newRevisionClient()
+		if err != nil {
+			return err
+		}
+		cm, err := config.RollbackConfigMap(context.TODO(), client, revisionNamespace, config.GetGlobalConfigName(), revision, revisionAuthor)
+		if err != nil && cm == nil {
+			return err
+		}
+		if err != nil {
+			log.Error(err, "config map is rolled back, but its revision is not recorded")
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "config map %s is rolled back to revision %d\n", cm.Name, revision)
+		return nil
+	},
+}
+
+func init() {
+	author := os.Getenv("USER")
+	if author == "" {
+		author = "cli"
+	}
+	configRevisionCmd.PersistentFlags().StringVarP(&revisionNamespace, "namespace", "n", config.GetGlobalConfigNamespace(), "namespace of the CSI config map")
+	configRevisionRollbackCmd.Flags().StringVar(&revisionAuthor, "author", author, "author recorded in the new revision")
+	configRevisionCmd.AddCommand(configRevisionListCmd, configRevisionShowCmd, configRevisionDiffCmd, configRevisionRollbackCmd)
+}
+
+func newRevisionClient() (*k8sclient.K8sClient, error) {
+	restConfig, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return k8sclient.NewClientWithConfig(*restConfig)
+}
@@ -25,12 +25,14 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 
 	"github.com/juicedata/juicefs-csi-driver/cmd/app"
 	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/controller"
 	"github.com/juicedata/juicefs-csi-driver/pkg/driver"
 	k8s "github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
 	"github.com/juicedata/juicefs-csi-driver/pkg/util"
@@ -42,6 +44,7 @@ var (
 
 func init() {
 	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(storagev1.AddToScheme(scheme))
 }
 func parseControllerConfig() {
 	config.ByProcess = process
@@ -188,6 +191,16 @@ func controllerRun(ctx context.Context) {
 		}
 	}()
 
+	// wrap data keys of encrypted secrets again after the key of secret encryption is rotated
+	go func() {
+		client, err := k8s.NewClient()
+		if err != nil {
+			log.Error(err, "Can't get k8s client for secret re-encryptor")
+			return
+		}
+		controller.StartSecretReEncryptor(ctx, client)
+	}()
+
 	if config.MountManager || config.Webhook {
 		mgr, err := app.NewControllerManager(
 			config.MountManager,
@@ -114,6 +114,7 @@ func main() {
 	cmd.PersistentFlags().StringVar(&leaderElectionNamespace, "leader-election-namespace", "", "Namespace where the leader election resource lives. Defaults to the pod namespace if not set.")
 	cmd.PersistentFlags().DurationVar(&leaderElectionLeaseDuration, "leader-election-lease-duration", 15*time.Second, "Duration, in seconds, that non-leader candidates will wait to force acquire leadership. Defaults to 15 seconds.")
 	cmd.PersistentFlags().BoolVar(&enableManager, "enable-manager", true, "enable manager for cache/index resource")
+	cmd.PersistentFlags().IntVar(&jfsConfig.ConfigRevisionHistoryLimit, "config-revision-limit", 10, "max number of revisions kept for the CSI config map, history is disabled if it is not positive")
 
 	goFlag := goflag.CommandLine
 	klog.InitFlags(goFlag)
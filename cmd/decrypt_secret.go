@@ -0,0 +1,50 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+)
+
+var (
+	encryptedSecretDir = ""
+	secretKeyFiles     []string
+)
+
+// decryptSecretCmd runs in mount containers whose secret is encrypted, the script printed is evaluated by the shell
+var decryptSecretCmd = &cobra.Command{
+	Use:   "decrypt-secret",
+	Short: "print the script which exports credentials of the encrypted secret of mount pod",
+	Run: func(cmd *cobra.Command, args []string) {
+		script, err := config.DecryptSecretDir(encryptedSecretDir, secretKeyFiles)
+		if err != nil {
+			log.Error(err, "failed to decrypt secret", "dir", encryptedSecretDir)
+			os.Exit(1)
+		}
+		fmt.Print(script)
+	},
+}
+
+func init() {
+	decryptSecretCmd.Flags().StringVar(&encryptedSecretDir, "secret-dir", "", "directory where the encrypted secret is mounted")
+	decryptSecretCmd.Flags().StringArrayVar(&secretKeyFiles, "key-file", nil, "key files of secretEncryption, can be set multiple times")
+}
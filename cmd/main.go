@@ -117,6 +117,9 @@ func main() {
 	cmd.PersistentFlags().AddGoFlagSet(goFlag)
 
 	cmd.AddCommand(upgradeCmd)
+	cmd.AddCommand(renderCmd)
+	cmd.AddCommand(configRevisionCmd)
+	cmd.AddCommand(decryptSecretCmd)
 
 	if err := cmd.Execute(); err != nil {
 		os.Exit(1)
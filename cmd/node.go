@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -130,6 +131,8 @@ func parseNodeConfig() {
 		os.Exit(1)
 	}
 	config.CSIPod = *pod
+	checkNonPrivilegedMountPod(k8sclient)
+	go watchNonPrivilegedMountPod(context.TODO(), k8sclient)
 
 	config.DisableGraceUpgrade = strings.ToLower(os.Getenv("DISABLE_GRACE_UPGRADE")) == "true"
 	if !config.DisableGraceUpgrade {
@@ -143,6 +146,51 @@ func parseNodeConfig() {
 	}
 }
 
+// checkNonPrivilegedMountPod checks the node supports non-privileged mount pods if they are configured,
+// mount pods of the volumes which require them are not created in the node if not
+func checkNonPrivilegedMountPod(client *k8s.K8sClient) {
+	setting := config.GlobalConfig.NonPrivilegedMountPod
+	if setting == nil {
+		checkedNonPrivilegedMountPod = nil
+		return
+	}
+	node, err := client.GetNode(context.TODO(), config.NodeName)
+	if err != nil {
+		// the node is unknown, leave it unchecked so that it is checked again later
+		config.NonPrivilegedMountPodUnsupported = fmt.Errorf("can't get node %s: %v", config.NodeName, err)
+		log.Error(err, "Can't get node, non-privileged mount pods are not supported until it is checked again", "node", config.NodeName)
+		return
+	}
+	checkedNonPrivilegedMountPod = setting
+	_, err = os.Stat("/dev/fuse")
+	config.NonPrivilegedMountPodUnsupported = setting.CheckNode(node, err == nil)
+	if config.NonPrivilegedMountPodUnsupported != nil {
+		log.Error(config.NonPrivilegedMountPodUnsupported, "Node does not support non-privileged mount pods", "node", config.NodeName)
+		return
+	}
+	log.Info("Node supports non-privileged mount pods", "node", config.NodeName)
+}
+
+// checkedNonPrivilegedMountPod is the nonPrivilegedMountPod config the node is checked against
+var checkedNonPrivilegedMountPod *config.NonPrivilegedMountPod
+
+// watchNonPrivilegedMountPod checks the node again when nonPrivilegedMountPod is changed by config reload,
+// or when the last check failed to get the node
+func watchNonPrivilegedMountPod(ctx context.Context, client *k8s.K8sClient) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !reflect.DeepEqual(config.GlobalConfig.NonPrivilegedMountPod, checkedNonPrivilegedMountPod) {
+				checkNonPrivilegedMountPod(client)
+			}
+		}
+	}
+}
+
 func nodeRun(ctx context.Context) {
 	parseNodeConfig()
 	if nodeID == "" {
@@ -212,6 +260,18 @@ func nodeRun(ctx context.Context) {
 			}()
 		}
 		log.Info("Pod Reconciler Started")
+
+		if !config.DisableGraceUpgrade {
+			go func() {
+				client, err := k8s.NewClient()
+				if err != nil {
+					log.Error(err, "Can't get k8s client for drift reconciler")
+					return
+				}
+				go grace.StartCredentialRotator(ctx, client)
+				grace.StartDriftReconciler(ctx, client)
+			}()
+		}
 	}
 
 	registerer.MustRegister(collectors.NewGoCollector())
@@ -0,0 +1,234 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/juicefs"
+)
+
+var (
+	renderFiles     []string
+	renderPV        string
+	renderPVC       string
+	renderNode      string
+	renderUUID      string
+	renderNamespace string
+	renderOutput    string
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "render the mount pod of a volume in a node offline",
+	Long: `Render the mount pod which a PVC gets in a node, without a cluster.
+It reads the CSI config file (--config) and manifests of PV, PVC, StorageClass, Secret, Node and Namespace,
+and prints the mount pod, its hash and the matched items of mountPodPatch. If PV is not given,
+it is provisioned for PVC with its StorageClass. A Pod in manifests is used as the CSI Node pod.`,
+	Example: `  juicefs-csi render --config config.yaml -f pvc.yaml -f sc.yaml -f secret.yaml -f node.yaml --uuid <volume-uuid>`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := render(cmd.OutOrStdout()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	renderCmd.Flags().StringArrayVarP(&renderFiles, "filename", "f", nil, "manifest files of PV, PVC, StorageClass, Secret, Node, Namespace and CSI Node pod, - for stdin")
+	renderCmd.Flags().StringVar(&renderPV, "pv", "", "name of the PV to render, required if there are several PVs in manifests")
+	renderCmd.Flags().StringVar(&renderPVC, "pvc", "", "<namespace>/<name> of the PVC to render, required if there are several PVCs in manifests")
+	renderCmd.Flags().StringVar(&renderNode, "node", "", "name of the node, required if there are several nodes in manifests")
+	renderCmd.Flags().StringVar(&renderUUID, "uuid", "", "uuid of the volume, got by `juicefs status` for community edition if empty")
+	renderCmd.Flags().StringVar(&renderNamespace, "mount-namespace", "kube-system", "namespace of mount pods")
+	renderCmd.Flags().StringVarP(&renderOutput, "output", "o", "yaml", "output format, yaml or json")
+}
+
+type renderObjects struct {
+	pvs        []corev1.PersistentVolume
+	pvcs       []corev1.PersistentVolumeClaim
+	scs        []storagev1.StorageClass
+	secrets    []corev1.Secret
+	nodes      []corev1.Node
+	namespaces []corev1.Namespace
+	pods       []corev1.Pod
+}
+
+func render(out io.Writer) error {
+	if len(renderFiles) == 0 {
+		return errors.New("manifests are required, set them by -f")
+	}
+	if configPath != "" {
+		if err := config.LoadConfig(configPath); err != nil {
+			return fmt.Errorf("load config %s error: %v", configPath, err)
+		}
+		if err := config.GlobalConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid config %s: %v", configPath, err)
+		}
+	}
+
+	objs := &renderObjects{}
+	for _, f := range renderFiles {
+		if err := objs.load(f); err != nil {
+			return err
+		}
+	}
+	in := juicefs.RenderInput{Secrets: objs.secrets, UUID: renderUUID}
+	var err error
+	if in.PV, err = selectObject(objs.pvs, "PV", renderPV, func(pv corev1.PersistentVolume) string { return pv.Name }); err != nil {
+		return err
+	}
+	if in.PVC, err = selectObject(objs.pvcs, "PVC", renderPVC, func(pvc corev1.PersistentVolumeClaim) string { return pvc.Namespace + "/" + pvc.Name }); err != nil {
+		return err
+	}
+	if in.PV == nil && in.PVC == nil {
+		return errors.New("neither PV nor PVC is found in manifests")
+	}
+	scName := ""
+	if in.PV != nil {
+		scName = in.PV.Spec.StorageClassName
+	} else if in.PVC.Spec.StorageClassName != nil {
+		scName = *in.PVC.Spec.StorageClassName
+	}
+	for i := range objs.scs {
+		if objs.scs[i].Name == scName {
+			in.SC = &objs.scs[i]
+		}
+	}
+	if in.Node, err = selectObject(objs.nodes, "Node", renderNode, func(node corev1.Node) string { return node.Name }); err != nil {
+		return err
+	}
+	if in.PVC != nil {
+		for i := range objs.namespaces {
+			if objs.namespaces[i].Name == in.PVC.Namespace {
+				in.Namespace = &objs.namespaces[i]
+			}
+		}
+	}
+	if len(objs.pods) > 0 {
+		config.CSIPod = objs.pods[0]
+	}
+
+	config.Namespace = renderNamespace
+	config.NodeName = renderNode
+	if in.Node != nil {
+		config.NodeName = in.Node.Name
+	}
+	if config.NodeName == "" {
+		config.NodeName = "node"
+	}
+
+	result, err := juicefs.Render(context.TODO(), in)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch renderOutput {
+	case "json":
+		data, err = json.MarshalIndent(result, "", "  ")
+	case "yaml":
+		data, err = yaml.Marshal(result)
+	default:
+		return fmt.Errorf("unknown output format %s", renderOutput)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+// load decodes objects in the multi-document YAML or JSON file
+func (o *renderObjects) load(file string) error {
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("read %s error: %v", file, err)
+		}
+		if len(strings.TrimSpace(string(doc))) == 0 {
+			continue
+		}
+		obj, gvk, err := clientgoscheme.Codecs.UniversalDeserializer().Decode(doc, nil, nil)
+		if err != nil {
+			return fmt.Errorf("decode %s error: %v", file, err)
+		}
+		switch v := obj.(type) {
+		case *corev1.PersistentVolume:
+			o.pvs = append(o.pvs, *v)
+		case *corev1.PersistentVolumeClaim:
+			o.pvcs = append(o.pvcs, *v)
+		case *storagev1.StorageClass:
+			o.scs = append(o.scs, *v)
+		case *corev1.Secret:
+			o.secrets = append(o.secrets, *v)
+		case *corev1.Node:
+			o.nodes = append(o.nodes, *v)
+		case *corev1.Namespace:
+			o.namespaces = append(o.namespaces, *v)
+		case *corev1.Pod:
+			o.pods = append(o.pods, *v)
+		default:
+			log.Info("ignore object of unsupported kind", "file", file, "kind", gvk.Kind)
+		}
+	}
+}
+
+// selectObject returns the object named name, or the only one if name is empty
+func selectObject[T any](objs []T, kind, name string, nameOf func(T) string) (*T, error) {
+	if name == "" {
+		if len(objs) > 1 {
+			return nil, fmt.Errorf("there are %d %ss in manifests, specify one by --%s", len(objs), kind, strings.ToLower(kind))
+		}
+		if len(objs) == 1 {
+			return &objs[0], nil
+		}
+		return nil, nil
+	}
+	for i := range objs {
+		if nameOf(objs[i]) == name {
+			return &objs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s %s not found in manifests", kind, name)
+}
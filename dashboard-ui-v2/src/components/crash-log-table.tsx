@@ -0,0 +1,73 @@
+/**
+ * Copyright 2026 Juicedata Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import React from 'react'
+import { ProCard } from '@ant-design/pro-components'
+import { Button, Table } from 'antd'
+import { FormattedMessage } from 'react-intl'
+
+import { useDownloadCrashLog, usePVCrashLogs } from '@/hooks/pv-api'
+
+const CrashLogTable: React.FC<{
+  name: string
+}> = ({ name }) => {
+  const { data } = usePVCrashLogs(name)
+  const [state, actions] = useDownloadCrashLog(name)
+
+  return (
+    <ProCard title={<FormattedMessage id="crashLogs" />}>
+      <Table
+        columns={[
+          {
+            title: <FormattedMessage id="createAt" />,
+            width: 160,
+            render: (_, record) => new Date(record.time).toLocaleString(),
+          },
+          {
+            title: <FormattedMessage id="node" />,
+            dataIndex: 'node',
+          },
+          {
+            title: 'Mount Pod',
+            dataIndex: 'podName',
+          },
+          {
+            title: <FormattedMessage id="size" />,
+            render: (_, record) => `${(record.size / 1024).toFixed(1)} KiB`,
+          },
+          {
+            title: '',
+            render: (_, record) => (
+              <Button
+                size="small"
+                loading={state.status === 'loading'}
+                onClick={() => actions.execute(record)}
+              >
+                <FormattedMessage id="download" />
+              </Button>
+            ),
+          },
+        ]}
+        dataSource={data}
+        size="small"
+        pagination={false}
+        rowKey={(c) => `${c.node}/${c.uniqueId}/${c.name}`}
+      />
+    </ProCard>
+  )
+}
+
+export default CrashLogTable
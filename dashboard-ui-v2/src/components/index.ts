@@ -15,6 +15,7 @@
  */
 
 import Containers from '@/components/containers'
+import CrashLogTable from '@/components/crash-log-table'
 import DebugModal from '@/components/debug-modal'
 import EventTable from '@/components/event-table'
 import Layout from '@/components/layout'
@@ -28,6 +29,7 @@ import YamlModal from '@/components/yaml-modal'
 
 export {
   Containers,
+  CrashLogTable,
   EventTable,
   Layout,
   PodBasic,
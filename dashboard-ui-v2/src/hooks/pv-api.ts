@@ -14,12 +14,20 @@
  * limitations under the License.
  */
 
+import { useAsync } from '@react-hookz/web'
 import { Event } from 'kubernetes-types/core/v1'
 import { StorageClass } from 'kubernetes-types/storage/v1'
 import useSWR from 'swr'
 
 import { PVCPagingListArgs, PVPagingListArgs, SCPagingListArgs } from '@/types'
-import { PV, PVC, PVCBasicInfo, PVCWithUniqueId } from '@/types/k8s.ts'
+import {
+  CrashLogArchive,
+  PV,
+  PVC,
+  PVCBasicInfo,
+  PVCWithUniqueId,
+} from '@/types/k8s.ts'
+import { apiFetchBlob } from '@/utils'
 
 export function useSCs(args: SCPagingListArgs) {
   const order = args.sort?.['time'] || 'ascend'
@@ -116,3 +124,21 @@ export function usePVEvents(pvName?: string) {
 export function usePVCEvents(pvName?: string) {
   return useSWR<Event[]>(`/api/v1/pvc/${pvName}/events`)
 }
+
+export function usePVCrashLogs(name?: string) {
+  return useSWR<CrashLogArchive[]>(`/api/v1/pv/${name}/crashlogs`)
+}
+
+export function useDownloadCrashLog(name?: string) {
+  return useAsync(async (archive: CrashLogArchive) => {
+    const blob = await apiFetchBlob(
+      `/api/v1/pv/${name}/crashlogs/${archive.node}/${archive.uniqueId}/${archive.name}`,
+    )
+    const url = window.URL.createObjectURL(blob)
+    const a = document.createElement('a')
+    a.href = url
+    a.download = `${archive.node}_${archive.name}`
+    a.click()
+    window.URL.revokeObjectURL(url)
+  })
+}
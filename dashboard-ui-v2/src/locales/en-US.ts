@@ -184,4 +184,7 @@ export default {
   cacheDir: 'Cache Directory',
   configNotFound: 'Config not found, please install it first.',
   smoothUpgradeDisabled: 'Smooth upgrade has been disabled',
+  crashLogs: 'Crash Logs',
+  size: 'Size',
+  download: 'Download',
 }
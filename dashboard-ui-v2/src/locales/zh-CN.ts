@@ -179,4 +179,7 @@ export default {
   cacheDir: '缓存路径',
   configNotFound: 'Config 不存在，请先安装。',
   smoothUpgradeDisabled: '平滑升级已被禁用。',
+  crashLogs: '崩溃日志',
+  size: '大小',
+  download: '下载',
 }
@@ -45,7 +45,8 @@ function validateConfigData(config: OriginConfig): string | null {
     for (const [k, v] of Object.entries(patch.labels ?? {})) {
       if (!isValidK8sKey(k))
         return `mountPodPatch[${i}].labels: invalid key "${k}"`
-      if (!isValidLabelValue(v))
+      // values with templates are checked by the backend after expanded
+      if (!v.includes('${') && !isValidLabelValue(v))
         return `mountPodPatch[${i}].labels: invalid value "${v}" for key "${k}"`
     }
 
@@ -15,7 +15,7 @@
  */
 import { PageContainer } from '@ant-design/pro-components'
 
-import { EventTable, PodsTable, PVBasic } from '@/components'
+import { CrashLogTable, EventTable, PodsTable, PVBasic } from '@/components'
 import { usePV } from '@/hooks/pv-api'
 
 const PVDetail: React.FC<{
@@ -38,6 +38,7 @@ const PVDetail: React.FC<{
       <PVBasic pv={data} />
       <PodsTable title="Mount Pods" source="pv" type="mountpods" name={name!} />
       <EventTable source="pv" name={name!} />
+      <CrashLogTable name={name!} />
     </PageContainer>
   )
 }
@@ -247,3 +247,12 @@ export type CacheGroup = {
     cacheGroup?: string
   }
 }
+
+export type CrashLogArchive = {
+  node: string
+  uniqueId: string
+  name: string
+  podName: string
+  size: number
+  time: string
+}
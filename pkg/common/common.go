@@ -50,6 +50,11 @@ const (
 	injectSidecar        = ".sidecar" + inject
 	InjectSidecarDone    = "done" + injectSidecar
 	InjectSidecarDisable = "disable" + injectSidecar
+	// set on app pod, "true" or "false" to override enableSharedSidecar in config
+	SharedSidecarKey = "juicefs/shared-sidecar"
+	// set on app pod to override sidecarExitPolicy in config
+	SidecarExitPolicyKey      = "juicefs/sidecar-exit-policy"
+	SidecarExitGracePeriodKey = "juicefs/sidecar-exit-grace-period"
 
 	// config in pv
 	MountPodCpuLimitKey    = "juicefs/mount-cpu-limit"
@@ -66,11 +71,35 @@ const (
 	CacheEmptyDir          = "juicefs/mount-cache-emptydir"
 	CacheInlineVolume      = "juicefs/mount-cache-inline-volume"
 	MountPodHostPath       = "juicefs/host-path"
+	// "false" to run mount pods without privileged, see nonPrivilegedMountPod in config
+	MountPodPrivilegedKey = "juicefs/mount-privileged"
+
+	// paths to warm up after mount, set in PVC or app pod annotations, or volume context
+	WarmupPathsKey   = "juicefs/warmup-paths"
+	WarmupTimeoutKey = "juicefs/warmup-timeout"
 
 	// DeleteDelayTimeKey mount pod annotation
 	DeleteDelayTimeKey = "juicefs-delete-delay"
 	DeleteDelayAtKey   = "juicefs-delete-at"
 
+	// recommended resources of mount pod, published by resource recommender
+	RecommendedCpuRequestKey = "juicefs-recommended-cpu-request"
+	RecommendedCpuLimitKey   = "juicefs-recommended-cpu-limit"
+	RecommendedMemRequestKey = "juicefs-recommended-memory-request"
+	RecommendedMemLimitKey   = "juicefs-recommended-memory-limit"
+
+	// memory limit of mount pod raised after OOMKilled
+	OOMMemoryLimitKey = "juicefs-oom-memory-limit"
+	// memory limit of mount pod which OOMKilled at the ceiling has been reported for
+	OOMCeilingReportedKey = "juicefs-oom-ceiling-reported"
+
+	// metrics port allocated to hostNetwork mount pod
+	MetricsPortKey = "juicefs-metrics-port"
+	// labels of the volume used by mount pod, used as target labels of metrics
+	MountPodPVLabelKey           = "juicefs-pv"
+	MountPodPVCLabelKey          = "juicefs-pvc"
+	MountPodPVCNamespaceLabelKey = "juicefs-pvc-namespace"
+
 	// pod immediate reconciler key
 	ImmediateReconcilerKey = "juicefs-immediate-reconciler"
 
@@ -83,6 +112,24 @@ const (
 	// secret labels
 	JuicefsSecretLabelKey = "juicefs/secret"
 
+	// annotation of PV set by the provisioner which created it
+	ProvisionedByAnnotationKey = "pv.kubernetes.io/provisioned-by"
+
+	// annotation of PVC of generic ephemeral volume created by webhook, the pod named by it becomes the owner once it is created
+	EphemeralPodAnnotationKey = "juicefs-ephemeral-pod"
+
+	// annotations of app pod injected with sidecars: hash of the sidecar setting and PVCs the sidecars are injected for
+	SidecarHashAnnotationKey = "juicefs-sidecar-hash"
+	SidecarPVCsAnnotationKey = "juicefs-sidecar-pvcs"
+	// label of app pod whose sidecars drift from the current setting
+	SidecarDriftedLabelKey = "juicefs-sidecar-drifted"
+
+	// config revision
+	ConfigRevisionOfLabelKey      = "juicefs-config-revision-of"
+	ConfigRevisionAnnotationKey   = "juicefs-config-revision"
+	ConfigAuthorAnnotationKey     = "juicefs-config-author"
+	ConfigUpdateTimeAnnotationKey = "juicefs-config-update-time"
+
 	// job labels
 	CanaryJobLabelKey = "juicefs-canary-job"
 
@@ -99,6 +146,17 @@ const (
 	JfsJobKind    = "juicefs-job-kind"
 	KindOfUpgrade = "juicefs-upgrade"
 
+	// pause drift reconciler, set on mount pod or node
+	DriftReconcilePausedKey = "juicefs/drift-reconcile-paused"
+
+	// credential rotation, set on mount pod
+	CredentialRotationHashKey   = "juicefs-credential-rotation-hash"
+	CredentialRotationStatusKey = "juicefs-credential-rotation-status"
+	CredentialRotationPending   = "Pending"
+	CredentialRotationRunning   = "Running"
+	CredentialRotationSucceeded = "Succeeded"
+	CredentialRotationFailed    = "Failed"
+
 	JfsUpgradeJobName = "juicefs-job-name"
 	JfsUpgradeConfig  = "juicefs-upgrade-config"
 
@@ -107,4 +165,11 @@ const (
 	// mount share mode
 	// only accept two value, storageClassShareMount or fsShareMount
 	JuicefsMountShareMode = "juicefs/mount-share-mode"
+
+	// set on mount pod running a read-only client, which is dedicated to read-only volumes
+	JuicefsReadOnlyKey = "juicefs/read-only"
+
+	// env of mount container whose secret is encrypted, the directory where the encrypted secret is mounted,
+	// which is decrypted by the mount container when it starts, see secretEncryption in config
+	JfsEncryptedSecretDirEnv = "JFS_ENCRYPTED_SECRET_DIR"
 )
@@ -22,6 +22,8 @@ import (
 	"fmt"
 	"hash/fnv"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -40,6 +42,7 @@ import (
 	"github.com/juicedata/juicefs-csi-driver/pkg/common"
 	k8s "github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
 	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/template"
 )
 
 var (
@@ -109,6 +112,7 @@ var CSISetEnvMap = map[string]interface{}{
 	"JUICEFS_CLIENT_SIDERCAR_CONTAINER": nil,
 	"JFS_NO_CHECK_OBJECT_STORAGE":       nil,
 	common.JfsStatePathEnv:              nil,
+	common.JfsEncryptedSecretDirEnv:     nil,
 }
 
 // opts auto set by the csi side
@@ -127,6 +131,10 @@ var interVolumesPrefix = []string{
 	"jfs-dir",
 	"update-db",
 	"cachedir-",
+	// volumes to decrypt the encrypted secret in mount containers
+	"juicefs-encrypted-secret",
+	"juicefs-csi-bin",
+	"juicefs-key-",
 }
 
 func IsInterVolume(name string) bool {
@@ -251,6 +259,12 @@ type MountPodPatch struct {
 	// used to specify the node selector to match nodes
 	// omit will patch for all nodes
 	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+	// used to specify the selector for the namespace of PVC, namespace name can be matched
+	// by label kubernetes.io/metadata.name. omit will patch for all namespaces
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// used to specify the selector for the application pod, only works in sidecar mode,
+	// as mount pod is shared by application pods. omit will patch for all pods
+	AppPodSelector *metav1.LabelSelector `json:"appPodSelector,omitempty"`
 
 	CEMountImage string               `json:"ceMountImage,omitempty"`
 	EEMountImage string               `json:"eeMountImage,omitempty"`
@@ -278,6 +292,11 @@ type MountPodPatch struct {
 	Env                           []corev1.EnvVar              `json:"env,omitempty"`
 	InitContainers                []corev1.Container           `json:"initContainers,omitempty"`
 	MountOptions                  []string                     `json:"mountOptions,omitempty"`
+
+	// generic patches applied to the generated mount pod
+	PodPatch `json:",inline"`
+	// patches of all matched MountPodPatch, in order
+	PodPatches []PodPatch `json:"-"`
 }
 
 func (mpp *MountPodPatch) isMatch(pvc *corev1.PersistentVolumeClaim, node *corev1.Node) bool {
@@ -290,6 +309,38 @@ func (mpp *MountPodPatch) isMatch(pvc *corev1.PersistentVolumeClaim, node *corev
 	return true
 }
 
+func (mpp *MountPodPatch) isMatchApp(namespace *corev1.Namespace, appPod *corev1.Pod) bool {
+	if mpp.NamespaceSelector != nil && !matchSelector(mpp.NamespaceSelector, namespace) {
+		return false
+	}
+	if mpp.AppPodSelector != nil && !matchSelector(mpp.AppPodSelector, appPod) {
+		return false
+	}
+	return true
+}
+
+func matchSelector[T *corev1.Namespace | *corev1.Pod](labelSelector *metav1.LabelSelector, obj T) bool {
+	if obj == nil {
+		return false
+	}
+	var objLabels map[string]string
+	switch o := any(obj).(type) {
+	case *corev1.Namespace:
+		// label kubernetes.io/metadata.name is set by apiserver, set it for namespace not got from apiserver
+		objLabels = map[string]string{corev1.LabelMetadataName: o.Name}
+		for k, v := range o.Labels {
+			objLabels[k] = v
+		}
+	case *corev1.Pod:
+		objLabels = o.Labels
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(objLabels))
+}
+
 func (mpp *MountPodPatch) matchPVC(pvc *corev1.PersistentVolumeClaim) bool {
 	if pvc == nil {
 		return false
@@ -320,6 +371,32 @@ func (mpp *MountPodPatch) matchNode(node *corev1.Node) bool {
 	}
 	return selector.Matches(labels.Set(node.Labels))
 }
+
+// UnmatchedMountPodPatches returns warnings of mountPodPatch whose selectors match none of the given
+// PVCs of JuiceFS, nodes or namespaces of PVCs of JuiceFS, which are usually caused by typos in labels or names
+func (c *Config) UnmatchedMountPodPatches(pvcs []corev1.PersistentVolumeClaim, nodes []corev1.Node, namespaces []corev1.Namespace) []string {
+	var warnings []string
+	for i := range c.MountPodPatch {
+		mpp := &c.MountPodPatch[i]
+		if mpp.PVCSelector != nil {
+			if !slices.ContainsFunc(pvcs, func(pvc corev1.PersistentVolumeClaim) bool { return mpp.matchPVC(&pvc) }) {
+				warnings = append(warnings, fmt.Sprintf("mountPodPatch[%d]: pvcSelector matches no PVC of JuiceFS", i))
+			}
+		}
+		if mpp.NodeSelector != nil {
+			if !slices.ContainsFunc(nodes, func(node corev1.Node) bool { return mpp.matchNode(&node) }) {
+				warnings = append(warnings, fmt.Sprintf("mountPodPatch[%d]: nodeSelector matches no node", i))
+			}
+		}
+		if mpp.NamespaceSelector != nil {
+			if !slices.ContainsFunc(namespaces, func(namespace corev1.Namespace) bool { return matchSelector(mpp.NamespaceSelector, &namespace) }) {
+				warnings = append(warnings, fmt.Sprintf("mountPodPatch[%d]: namespaceSelector matches no namespace with PVC of JuiceFS", i))
+			}
+		}
+	}
+	return warnings
+}
+
 func (mpp *MountPodPatch) deepCopy() MountPodPatch {
 	var copy MountPodPatch
 	data, _ := json.Marshal(mpp)
@@ -440,6 +517,9 @@ func (mpp *MountPodPatch) merge(mp MountPodPatch) {
 	if mp.HostnameKey != "" {
 		mpp.HostnameKey = mp.HostnameKey
 	}
+	if !mp.PodPatch.IsEmpty() {
+		mpp.PodPatches = append(mpp.PodPatches, mp.PodPatch)
+	}
 }
 
 // TODO: migrate more config for here
@@ -449,6 +529,9 @@ type Config struct {
 	// in sidecar mode, use k8s native sidecar instead of container
 	// If the k8s version is 1.29 and later, the default is true.
 	EnableNativeSidecar *bool `json:"enableNativeSidecar,omitempty"`
+	// in sidecar mode, PVCs of the same filesystem and mount settings in a pod share one sidecar,
+	// can be overridden by annotation juicefs/shared-sidecar of the pod
+	EnableSharedSidecar bool `json:"enableSharedSidecar,omitempty"`
 	// enable set quota according to capacity settings, the default is true.
 	EnableSetQuota *bool `json:"enableSetQuota,omitempty"`
 	// enable set quota in controller (CreateVolume/Provisioner)
@@ -464,14 +547,53 @@ type Config struct {
 	// use kubelet API to list mount pods on the node
 	// if enabled, the driver will try to use kubelet API to list mount pods on the node, and fall back to request api-server if kubelet API fails
 	// Kubelet synchronization of pods may have delays, which in high-concurrency scenarios could lead to mountpods not being reused.
-	EnableKubeletListMountPod bool            `json:"enableKubeletListMountPod,omitempty"`
-	MountPodPatch             []MountPodPatch `json:"mountPodPatch"`
+	EnableKubeletListMountPod bool `json:"enableKubeletListMountPod,omitempty"`
+	// record resource usage of mount pods and recommend requests/limits
+	ResourceRecommender *ResourceRecommender `json:"resourceRecommender,omitempty"`
+	// recreate OOMKilled mount pod with a higher memory limit
+	OOMRecovery *OOMRecovery `json:"oomRecovery,omitempty"`
+	// persist logs of failed or deleted mount pods in csi node
+	CrashLogArchive *CrashLogArchive `json:"crashLogArchive,omitempty"`
+	// smoothly upgrade mount pods whose setting drifts from the current config
+	DriftReconciler *DriftReconciler `json:"driftReconciler,omitempty"`
+	// report sidecars injected by webhook whose setting drifts from the current config, and restart their pods
+	SidecarDriftReconciler *SidecarDriftReconciler `json:"sidecarDriftReconciler,omitempty"`
+	// allocate metrics port for hostNetwork mount pods and expose metrics to prometheus
+	MountPodMetrics *MountPodMetrics `json:"mountPodMetrics,omitempty"`
+	// how mount pods of StorageClasses with juicefs/mount-privileged: "false" get /dev/fuse without privileged
+	NonPrivilegedMountPod *NonPrivilegedMountPod `json:"nonPrivilegedMountPod,omitempty"`
+	// encrypt secrets generated for mount pods with keys in files mounted into csi components
+	SecretEncryption *SecretEncryption `json:"secretEncryption,omitempty"`
+	// directories and URLs which credential providers set in volume secrets can read credentials from
+	CredentialProviders *CredentialProviders `json:"credentialProviders,omitempty"`
+	// when to terminate non-native sidecars after app containers exit, in pods whose restartPolicy is not Always
+	SidecarExitPolicy *SidecarExitPolicy `json:"sidecarExitPolicy,omitempty"`
+	// cap mount pod resources caused by PVCs per namespace, the first matched one applies
+	ResourceBudgets []ResourceBudget `json:"resourceBudgets,omitempty"`
+	// restrict juicefs/* annotations users can set on PVCs, checked by the PVC validating webhook
+	PVCAnnotationPolicies []PVCAnnotationPolicy `json:"pvcAnnotationPolicies,omitempty"`
+	// restrict file systems and their paths namespaces can use, checked in provisioning, mounting and sidecar injection
+	FSAccessPolicies []FSAccessPolicy `json:"fsAccessPolicies,omitempty"`
+	MountPodPatch    []MountPodPatch  `json:"mountPodPatch"`
 }
 
 func (c *Config) Unmarshal(data []byte) error {
 	return yaml.Unmarshal(data, c)
 }
 
+// validateTemplate checks templates in values of the patch, e.g. unknown variables or functions
+func (mpp *MountPodPatch) validateTemplate() error {
+	resolver := mountPodTemplateResolver(JfsSetting{})
+	data, err := json.Marshal(mpp)
+	if err != nil {
+		return err
+	}
+	if err := template.ValidateJSON(data, resolver); err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+	return nil
+}
+
 // Validate checks the config for invalid fields such as env var names,
 // label/annotation keys and label values that would cause pod creation to fail.
 //
@@ -479,16 +601,71 @@ func (c *Config) Unmarshal(data []byte) error {
 // dashboard-ui-v2/src/pages/config-detail.tsx. When changing validation rules
 // here, update the frontend validators and vice versa.
 func (c *Config) Validate() error {
+	if err := c.ResourceRecommender.validate(); err != nil {
+		return err
+	}
+	if err := c.OOMRecovery.validate(); err != nil {
+		return err
+	}
+	if err := c.CrashLogArchive.validate(); err != nil {
+		return err
+	}
+	if err := c.DriftReconciler.validate(); err != nil {
+		return err
+	}
+	if err := c.SidecarDriftReconciler.validate(); err != nil {
+		return err
+	}
+	if err := c.MountPodMetrics.validate(); err != nil {
+		return err
+	}
+	if err := c.NonPrivilegedMountPod.validate(); err != nil {
+		return err
+	}
+	if err := c.SecretEncryption.validate(); err != nil {
+		return err
+	}
+	if err := c.CredentialProviders.validate(); err != nil {
+		return err
+	}
+	if err := c.SidecarExitPolicy.validate(); err != nil {
+		return err
+	}
+	for i := range c.ResourceBudgets {
+		if err := c.ResourceBudgets[i].validate(); err != nil {
+			return fmt.Errorf("resourceBudgets[%d].%v", i, err)
+		}
+	}
+	for i := range c.PVCAnnotationPolicies {
+		if err := c.PVCAnnotationPolicies[i].validate(); err != nil {
+			return fmt.Errorf("pvcAnnotationPolicies[%d].%v", i, err)
+		}
+	}
+	for i := range c.FSAccessPolicies {
+		if err := c.FSAccessPolicies[i].validate(); err != nil {
+			return fmt.Errorf("fsAccessPolicies[%d].%v", i, err)
+		}
+	}
 	for i, patch := range c.MountPodPatch {
+		if err := patch.PodPatch.validate(); err != nil {
+			return fmt.Errorf("mountPodPatch[%d].%v", i, err)
+		}
 		for _, env := range patch.Env {
 			if errs := validation.IsRelaxedEnvVarName(env.Name); len(errs) > 0 {
 				return fmt.Errorf("mountPodPatch[%d].env: invalid environment variable name %q: %s", i, env.Name, strings.Join(errs, "; "))
 			}
 		}
+		if err := patch.validateTemplate(); err != nil {
+			return fmt.Errorf("mountPodPatch[%d]: %v", i, err)
+		}
 		for k, v := range patch.Labels {
 			if errs := validation.IsQualifiedName(k); len(errs) > 0 {
 				return fmt.Errorf("mountPodPatch[%d].labels: invalid key %q: %s", i, k, strings.Join(errs, "; "))
 			}
+			// values with templates are checked after expanded
+			if strings.Contains(v, "${") {
+				continue
+			}
 			if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
 				return fmt.Errorf("mountPodPatch[%d].labels: invalid value %q for key %q: %s", i, v, k, strings.Join(errs, "; "))
 			}
@@ -550,21 +727,85 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// PatchConflict is a field set to different values by more than one matched mountPodPatch
+type PatchConflict struct {
+	Field string `json:"field"`
+	// indexes of the matched mountPodPatch which set the field, the last one takes effect
+	Patches []int `json:"patches"`
+}
+
+func (c PatchConflict) String() string {
+	return fmt.Sprintf("%s: mountPodPatch%v", c.Field, c.Patches)
+}
+
+// fields of mountPodPatch which are not overridden by the latter patch
+var nonOverriddenPatchFields = map[string]bool{
+	"pvcSelector":         true,
+	"nodeSelector":        true,
+	"namespaceSelector":   true,
+	"appPodSelector":      true,
+	"volumes":             true,
+	"volumeMounts":        true,
+	"volumeDevices":       true,
+	"strategicMergePatch": true,
+	"jsonPatch":           true,
+}
+
+// MatchMountPodPatch returns indexes of mountPodPatch matching the setting and node in order,
+// and the fields set to different values by more than one of them
+func (c *Config) MatchMountPodPatch(setting JfsSetting, node *corev1.Node) ([]int, []PatchConflict) {
+	namespace := setting.GetNamespace()
+	matched := []int{}
+	fieldValues := map[string]map[string]bool{}
+	fieldPatches := map[string][]int{}
+	for i, mp := range c.MountPodPatch {
+		if !mp.isMatch(setting.PVC, node) || !mp.isMatchApp(namespace, setting.AppPod) {
+			continue
+		}
+		matched = append(matched, i)
+		data, _ := json.Marshal(mp)
+		fields := map[string]json.RawMessage{}
+		_ = json.Unmarshal(data, &fields)
+		for field, value := range fields {
+			if nonOverriddenPatchFields[field] {
+				continue
+			}
+			if fieldValues[field] == nil {
+				fieldValues[field] = map[string]bool{}
+			}
+			fieldValues[field][string(value)] = true
+			fieldPatches[field] = append(fieldPatches[field], i)
+		}
+	}
+	conflicts := []PatchConflict{}
+	for field, values := range fieldValues {
+		if len(values) > 1 {
+			conflicts = append(conflicts, PatchConflict{Field: field, Patches: fieldPatches[field]})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Field < conflicts[j].Field
+	})
+	return matched, conflicts
+}
+
 // GenMountPodPatch generate mount pod patch from jfsSetting
-// 1. match pvc selector and node selector
+// 1. match pvc selector, node selector, namespace selector and app pod selector
 // 2. parse template value
 // 3. return the merged mount pod patch
-func (c *Config) GenMountPodPatch(setting JfsSetting, replaceTemplate bool, node *corev1.Node) MountPodPatch {
+func (c *Config) GenMountPodPatch(setting JfsSetting, replaceTemplate bool, node *corev1.Node) (MountPodPatch, error) {
 	patch := &MountPodPatch{
 		Labels:      map[string]string{},
 		Annotations: map[string]string{},
 	}
 
-	// merge each patch
-	for _, mp := range c.MountPodPatch {
-		if mp.isMatch(setting.PVC, node) {
-			patch.merge(mp.deepCopy())
-		}
+	// merge each patch in order, the latter one overrides the former one
+	matched, conflicts := c.MatchMountPodPatch(setting, node)
+	for _, i := range matched {
+		patch.merge(c.MountPodPatch[i].deepCopy())
+	}
+	if replaceTemplate && len(conflicts) > 0 {
+		log.Info("fields are set by more than one matched mountPodPatch, the last one takes effect", "volumeId", setting.VolumeId, "conflicts", conflicts)
 	}
 	if setting.IsCe {
 		patch.Image = patch.CEMountImage
@@ -573,20 +814,50 @@ func (c *Config) GenMountPodPatch(setting JfsSetting, replaceTemplate bool, node
 	}
 
 	if replaceTemplate {
+		resolver := mountPodTemplateResolver(setting)
 		data, _ := json.Marshal(patch)
-		strData := ReplaceMountPodTemplate(string(data), setting)
-		_ = json.Unmarshal([]byte(strData), patch)
+		expanded, err := template.ExpandJSON(data, resolver)
+		if err != nil {
+			return MountPodPatch{}, fmt.Errorf("expand templates of mountPodPatch error: %v", err)
+		}
+		_ = json.Unmarshal(expanded, patch)
+		for i, p := range patch.PodPatches {
+			data, _ := json.Marshal(p)
+			expanded, err := template.ExpandJSON(data, resolver)
+			if err != nil {
+				return MountPodPatch{}, fmt.Errorf("expand templates of pod patch error: %v", err)
+			}
+			_ = json.Unmarshal(expanded, &patch.PodPatches[i])
+		}
 		log.V(1).Info("volume using patch", "volumeId", setting.VolumeId, "patch", patch)
 	}
-	return *patch
+	return *patch, nil
 }
 
-func ReplaceMountPodTemplate(value string, setting JfsSetting) string {
-	value = strings.ReplaceAll(value, "${MOUNT_POINT}", setting.MountPath)
-	value = strings.ReplaceAll(value, "${VOLUME_ID}", setting.VolumeId)
-	value = strings.ReplaceAll(value, "${VOLUME_NAME}", setting.Name)
-	value = strings.ReplaceAll(value, "${SUB_PATH}", setting.SubPath)
-	return value
+// mountPodTemplateResolver resolves variables in mount pod templates, including ${MOUNT_POINT}, ${VOLUME_ID},
+// ${VOLUME_NAME}, ${SUB_PATH}, and fields of pvc (${.PVC.xxx}), node (${.node.xxx}) and storageClass (${.SC.xxx}) of the setting
+func mountPodTemplateResolver(setting JfsSetting) template.Resolver {
+	pvc, sc := template.PVC(setting.PVC), template.StorageClass(setting.SC)
+	return template.Chain(
+		template.MapResolver(map[string]string{
+			"MOUNT_POINT": setting.MountPath,
+			"VOLUME_ID":   setting.VolumeId,
+			"VOLUME_NAME": setting.Name,
+			"SUB_PATH":    setting.SubPath,
+		}),
+		template.ObjectResolver(map[string]*template.Object{
+			"PVC":  pvc,
+			"pvc":  pvc,
+			"node": template.Node(setting.Node),
+			"SC":   sc,
+			"sc":   sc,
+		}),
+	)
+}
+
+// ExpandMountPodTemplate expands templates in value for the mount pod of setting
+func ExpandMountPodTemplate(value string, setting JfsSetting) (string, error) {
+	return template.Expand(value, mountPodTemplateResolver(setting))
 }
 
 // reset to default value
@@ -627,12 +898,7 @@ func LoadConfig(configPath string) error {
 }
 
 func LoadFromConfigMap(ctx context.Context, client *k8s.K8sClient) error {
-	cmName := GetGlobalConfigName()
-	sysNamespace := os.Getenv("SYS_NAMESPACE")
-	if sysNamespace == "" {
-		sysNamespace = "kube-system"
-	}
-	cm, err := client.GetConfigMap(ctx, cmName, sysNamespace)
+	cm, err := client.GetConfigMap(ctx, GetGlobalConfigName(), GetGlobalConfigNamespace())
 	if err != nil {
 		return err
 	}
@@ -724,3 +990,12 @@ func GetGlobalConfigName() string {
 	}
 	return cmName
 }
+
+// GetGlobalConfigNamespace returns the namespace of the CSI config map
+func GetGlobalConfigNamespace() string {
+	sysNamespace := os.Getenv("SYS_NAMESPACE")
+	if sysNamespace == "" {
+		sysNamespace = "kube-system"
+	}
+	return sysNamespace
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -541,7 +542,10 @@ func TestGenMountPodPatch(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			actualPatch := tc.baseConfig.GenMountPodPatch(tc.setting, true, tc.node)
+			actualPatch, err := tc.baseConfig.GenMountPodPatch(tc.setting, true, tc.node)
+			if err != nil {
+				t.Fatal(err)
+			}
 			assert.Equal(t, tc.expectedPatch, actualPatch)
 		})
 	}
@@ -595,7 +599,10 @@ func TestGenMountPodPatchParseTwice(t *testing.T) {
 		},
 	}
 
-	actualPatch := baseConfig.GenMountPodPatch(setting, true, nil)
+	actualPatch, err := baseConfig.GenMountPodPatch(setting, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, expectedPatch1, actualPatch)
 
 	expectedPatch2 := MountPodPatch{
@@ -609,7 +616,10 @@ func TestGenMountPodPatchParseTwice(t *testing.T) {
 	}
 	setting.MountPath = "/var/lib/juicefs/volume"
 	// Call the GenMountPodPatch function again
-	actualPatch = baseConfig.GenMountPodPatch(setting, true, nil)
+	actualPatch, err = baseConfig.GenMountPodPatch(setting, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, expectedPatch2, actualPatch)
 }
 
@@ -893,3 +903,164 @@ func TestMountPodPatch_isMatchWithNode(t *testing.T) {
 		})
 	}
 }
+
+func TestMountPodPatch_isMatchApp(t *testing.T) {
+	testCases := []struct {
+		name      string
+		patch     MountPodPatch
+		namespace *corev1.Namespace
+		appPod    *corev1.Pod
+		expected  bool
+	}{
+		{
+			name:      "no selectors",
+			patch:     MountPodPatch{},
+			namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			expected:  true,
+		},
+		{
+			name: "NamespaceSelector matches namespace labels",
+			patch: MountPodPatch{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a"}},
+			},
+			namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-a", Labels: map[string]string{"tenant": "a"}}},
+			expected:  true,
+		},
+		{
+			name: "NamespaceSelector matches namespace name",
+			patch: MountPodPatch{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: "ns-a"}},
+			},
+			namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}},
+			expected:  true,
+		},
+		{
+			name: "NamespaceSelector does not match",
+			patch: MountPodPatch{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a"}},
+			},
+			namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-b", Labels: map[string]string{"tenant": "b"}}},
+			expected:  false,
+		},
+		{
+			name: "NamespaceSelector with nil namespace",
+			patch: MountPodPatch{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a"}},
+			},
+			expected: false,
+		},
+		{
+			name: "AppPodSelector matches",
+			patch: MountPodPatch{
+				AppPodSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"spark", "flink"}},
+				}},
+			},
+			appPod:   &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "spark"}}},
+			expected: true,
+		},
+		{
+			name: "AppPodSelector without app pod",
+			patch: MountPodPatch{
+				AppPodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "spark"}},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.patch.isMatchApp(tc.namespace, tc.appPod)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestConfig_MatchMountPodPatch(t *testing.T) {
+	cfg := &Config{
+		MountPodPatch: []MountPodPatch{
+			{CEMountImage: "juicedata/mount:ce-v1.2.0", HostNetwork: toPtr(true)},
+			{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: "tenant-a"}},
+				CEMountImage:      "juicedata/mount:ce-v1.3.0",
+				Volumes:           []corev1.Volume{{Name: "a"}},
+			},
+			{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+				HostNetwork:       toPtr(true),
+				Volumes:           []corev1.Volume{{Name: "b"}},
+			},
+			{
+				AppPodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "spark"}},
+				MountOptions:   []string{"cache-size=0"},
+			},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "tenant-a"}}
+
+	// namespace got from apiserver
+	matched, conflicts := cfg.MatchMountPodPatch(JfsSetting{
+		PVC:       pvc,
+		Namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Labels: map[string]string{"tier": "gold"}}},
+	}, nil)
+	assert.Equal(t, []int{0, 1, 2}, matched)
+	assert.Equal(t, []PatchConflict{{Field: "ceMountImage", Patches: []int{0, 1}}}, conflicts)
+
+	// only namespace name of pvc is known
+	matched, _ = cfg.MatchMountPodPatch(JfsSetting{PVC: pvc}, nil)
+	assert.Equal(t, []int{0, 1}, matched)
+
+	// sidecar mode
+	appPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-b", Labels: map[string]string{"app": "spark"}}}
+	matched, conflicts = cfg.MatchMountPodPatch(JfsSetting{AppPod: appPod}, nil)
+	assert.Equal(t, []int{0, 3}, matched)
+	assert.Empty(t, conflicts)
+
+	patch, err := cfg.GenMountPodPatch(JfsSetting{IsCe: true, PVC: pvc}, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "juicedata/mount:ce-v1.3.0", patch.Image)
+}
+
+func TestMountPodPatch_Template(t *testing.T) {
+	cfg := &Config{MountPodPatch: []MountPodPatch{{
+		Labels:       map[string]string{"team": `${.PVC.labels.team | default "shared" | lower}`},
+		MountOptions: []string{"cache-dir=/var/jfsCache/${.PVC.namespace}/${VOLUME_ID | shortHash}"},
+	}}}
+	assert.NoError(t, cfg.Validate())
+
+	setting := JfsSetting{
+		VolumeId: "pvc-1234",
+		PVC:      &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default", Labels: map[string]string{"team": "Infra"}}},
+	}
+	patch, err := cfg.GenMountPodPatch(setting, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "infra", patch.Labels["team"])
+	assert.Equal(t, []string{"cache-dir=/var/jfsCache/default/1eb9de6b"}, patch.MountOptions)
+
+	setting.PVC.Labels = nil
+	patch, err = cfg.GenMountPodPatch(setting, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "shared", patch.Labels["team"])
+
+	cfg.MountPodPatch[0].Labels["tier"] = "${.SC.parameters.tier | default \"standard\"}"
+	setting.SC = &storagev1.StorageClass{Parameters: map[string]string{"tier": "fast"}}
+	patch, err = cfg.GenMountPodPatch(setting, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "fast", patch.Labels["tier"])
+
+	cfg.MountPodPatch[0].Labels["team"] = "${.PVC.labels.team | required}"
+	_, err = cfg.GenMountPodPatch(setting, true, nil)
+	assert.ErrorContains(t, err, "value is required")
+	cfg.MountPodPatch[0].Labels["team"] = "${.PVC.lables.team}"
+	assert.ErrorContains(t, cfg.Validate(), "unknown variable .PVC.lables.team")
+	cfg.MountPodPatch[0].Labels["team"] = "${.PVC.name | title}"
+	assert.ErrorContains(t, cfg.Validate(), "unknown function title")
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// CrashLogArchive saves stdout/stderr and .accesslog/.stats of failed or deleted mount pods
+// as archives in csi node, the directory can be a hostPath or PVC volume of csi node.
+type CrashLogArchive struct {
+	Enable bool `json:"enable,omitempty"`
+	// directory in csi node to save archives, the default is /var/lib/juicefs/crash-logs
+	Path string `json:"path,omitempty"`
+	// total size of archives, the oldest ones are removed when exceeded, the default is 1Gi
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+	// max size of each log captured in archive, the default is 10Mi
+	MaxLogSize *resource.Quantity `json:"maxLogSize,omitempty"`
+}
+
+const (
+	defaultCrashLogPath       = "/var/lib/juicefs/crash-logs"
+	defaultCrashLogMaxSize    = 1 << 30
+	defaultCrashLogMaxLogSize = 10 << 20
+)
+
+func (c *CrashLogArchive) IsEnabled() bool {
+	return c != nil && c.Enable
+}
+
+func (c *CrashLogArchive) GetPath() string {
+	if c == nil || c.Path == "" {
+		return defaultCrashLogPath
+	}
+	return c.Path
+}
+
+func (c *CrashLogArchive) GetMaxSize() int64 {
+	if c == nil || c.MaxSize == nil {
+		return defaultCrashLogMaxSize
+	}
+	return c.MaxSize.Value()
+}
+
+func (c *CrashLogArchive) GetMaxLogSize() int64 {
+	if c == nil || c.MaxLogSize == nil {
+		return defaultCrashLogMaxLogSize
+	}
+	return c.MaxLogSize.Value()
+}
+
+func (c *CrashLogArchive) validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Path != "" && !filepath.IsAbs(c.Path) {
+		return fmt.Errorf("crashLogArchive.path: must be an absolute path, got %q", c.Path)
+	}
+	if c.MaxSize != nil && c.MaxSize.Sign() <= 0 {
+		return fmt.Errorf("crashLogArchive.maxSize: must be positive, got %s", c.MaxSize.String())
+	}
+	if c.MaxLogSize != nil && c.MaxLogSize.Sign() <= 0 {
+		return fmt.Errorf("crashLogArchive.maxLogSize: must be positive, got %s", c.MaxLogSize.String())
+	}
+	return nil
+}
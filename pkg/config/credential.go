@@ -0,0 +1,114 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/credential"
+)
+
+// CredentialProviders restricts where credential providers set in volume secrets can read credentials from,
+// since volume secrets can be written by users who are not allowed to read files or call endpoints of CSI components.
+// Providers are disabled if it is not set.
+type CredentialProviders struct {
+	// directories which credential-path of the file provider can be in
+	AllowedDirs []string `json:"allowedDirs,omitempty"`
+	// URL prefixes which credential-endpoint of the http provider can start with, e.g. http://127.0.0.1:8200/v1/juicefs/
+	AllowedURLPrefixes []string `json:"allowedURLPrefixes,omitempty"`
+}
+
+func (c *CredentialProviders) validate() error {
+	if c == nil {
+		return nil
+	}
+	for _, dir := range c.AllowedDirs {
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("credentialProviders.allowedDirs: %s is not an absolute path", dir)
+		}
+	}
+	for _, prefix := range c.AllowedURLPrefixes {
+		if _, err := parseCredentialURL(prefix); err != nil {
+			return fmt.Errorf("credentialProviders.allowedURLPrefixes: %v", err)
+		}
+	}
+	return nil
+}
+
+// allows checks if credential-path and credential-endpoint of ref are in the allowed directories and URL prefixes
+func (c *CredentialProviders) allows(ref *credential.Ref) error {
+	if c == nil {
+		return fmt.Errorf("credential providers are not enabled, set credentialProviders in the config of CSI Driver")
+	}
+	if ref.Path != "" && !c.allowsPath(ref.Path) {
+		return fmt.Errorf("%s %s is not in credentialProviders.allowedDirs", credential.PathKey, ref.Path)
+	}
+	if ref.Endpoint != "" && !c.allowsEndpoint(ref.Endpoint) {
+		return fmt.Errorf("%s %s does not match credentialProviders.allowedURLPrefixes", credential.EndpointKey, ref.Endpoint)
+	}
+	return nil
+}
+
+func (c *CredentialProviders) allowsPath(p string) bool {
+	p = filepath.Clean(p)
+	for _, dir := range c.AllowedDirs {
+		if isSubPath(filepath.Clean(dir), p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CredentialProviders) allowsEndpoint(endpoint string) bool {
+	u, err := parseCredentialURL(endpoint)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range c.AllowedURLPrefixes {
+		p, err := parseCredentialURL(prefix)
+		if err != nil {
+			continue
+		}
+		// compare cleaned paths, so that neither ../ nor a prefix of the host name, e.g. http://127.0.0.1:8200.example.com,
+		// escapes the prefix
+		if u.Scheme == p.Scheme && u.Host == p.Host && isSubPath(path.Clean("/"+p.Path), path.Clean("/"+u.Path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCredentialURL parses the http(s) url without user info, query or fragment
+func parseCredentialURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("%s should be a http(s) url without user info, query or fragment", s)
+	}
+	return u, nil
+}
+
+// isSubPath returns true if p is dir or in dir, both are cleaned absolute paths
+func isSubPath(dir, p string) bool {
+	return dir == "/" || p == dir || strings.HasPrefix(p, dir+"/")
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DriftReconciler finds mount pods whose hash differs from the one generated with the current config and secret,
+// and upgrades them smoothly in csi node
+type DriftReconciler struct {
+	Enable bool `json:"enable,omitempty"`
+	// interval of checking drift, the default is 10m
+	Interval string `json:"interval,omitempty"`
+	// max mount pods upgraded at the same time in each node, the default is 1
+	MaxConcurrentPerNode int `json:"maxConcurrentPerNode,omitempty"`
+	// upgrade is only scheduled in these windows, no limit if empty
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+}
+
+// MaintenanceWindow is a daily time range, e.g. start: "02:00", end: "04:00".
+// The range crosses midnight if end is before start.
+type MaintenanceWindow struct {
+	// weekdays the window applies to, e.g. ["Sat", "Sun"], every day if empty
+	Weekdays []string `json:"weekdays,omitempty"`
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	// IANA time zone of start and end, the default is UTC
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+const (
+	defaultDriftReconcileInterval = 10 * time.Minute
+	maintenanceWindowTimeLayout   = "15:04"
+)
+
+func (d *DriftReconciler) IsEnabled() bool {
+	return d != nil && d.Enable
+}
+
+func (d *DriftReconciler) GetInterval() time.Duration {
+	if d == nil || d.Interval == "" {
+		return defaultDriftReconcileInterval
+	}
+	interval, err := time.ParseDuration(d.Interval)
+	if err != nil || interval <= 0 {
+		return defaultDriftReconcileInterval
+	}
+	return interval
+}
+
+func (d *DriftReconciler) GetMaxConcurrentPerNode() int {
+	if d == nil || d.MaxConcurrentPerNode <= 0 {
+		return 1
+	}
+	return d.MaxConcurrentPerNode
+}
+
+// InMaintenanceWindow checks if now is in any of the maintenance windows
+func (d *DriftReconciler) InMaintenanceWindow(now time.Time) bool {
+	return d == nil || inMaintenanceWindows(d.MaintenanceWindows, now)
+}
+
+// inMaintenanceWindows checks if now is in any of the windows, it's always true if there is no window
+func inMaintenanceWindows(windows []MaintenanceWindow, now time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if w.contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w MaintenanceWindow) contains(now time.Time) bool {
+	loc := time.UTC
+	if w.TimeZone != "" {
+		l, err := time.LoadLocation(w.TimeZone)
+		if err != nil {
+			return false
+		}
+		loc = l
+	}
+	start, err := time.Parse(maintenanceWindowTimeLayout, w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(maintenanceWindowTimeLayout, w.End)
+	if err != nil {
+		return false
+	}
+	now = now.In(loc)
+	minutes := now.Hour()*60 + now.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+	day := now.Weekday()
+	if startMinutes <= endMinutes {
+		return w.onWeekday(day) && minutes >= startMinutes && minutes < endMinutes
+	}
+	// crosses midnight, the part after midnight belongs to the window started yesterday
+	if minutes >= startMinutes {
+		return w.onWeekday(day)
+	}
+	return minutes < endMinutes && w.onWeekday((day+6)%7)
+}
+
+func (w MaintenanceWindow) onWeekday(day time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range w.Weekdays {
+		if weekdayMatches(d, day) {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdayMatches matches full or abbreviated weekday name, e.g. "Sunday" or "Sun"
+func weekdayMatches(name string, day time.Weekday) bool {
+	return strings.EqualFold(name, day.String()) || strings.EqualFold(name, day.String()[:3])
+}
+
+func (d *DriftReconciler) validate() error {
+	if d == nil {
+		return nil
+	}
+	if d.Interval != "" {
+		if interval, err := time.ParseDuration(d.Interval); err != nil || interval <= 0 {
+			return fmt.Errorf("driftReconciler.interval: invalid duration %q", d.Interval)
+		}
+	}
+	if d.MaxConcurrentPerNode < 0 {
+		return fmt.Errorf("driftReconciler.maxConcurrentPerNode: must not be negative, got %d", d.MaxConcurrentPerNode)
+	}
+	return validateMaintenanceWindows("driftReconciler", d.MaintenanceWindows)
+}
+
+func validateMaintenanceWindows(field string, windows []MaintenanceWindow) error {
+	for i, w := range windows {
+		if _, err := time.Parse(maintenanceWindowTimeLayout, w.Start); err != nil {
+			return fmt.Errorf("%s.maintenanceWindows[%d].start: invalid time %q, expect HH:MM", field, i, w.Start)
+		}
+		if _, err := time.Parse(maintenanceWindowTimeLayout, w.End); err != nil {
+			return fmt.Errorf("%s.maintenanceWindows[%d].end: invalid time %q, expect HH:MM", field, i, w.End)
+		}
+		if w.Start == w.End {
+			return fmt.Errorf("%s.maintenanceWindows[%d]: start and end must differ", field, i)
+		}
+		if w.TimeZone != "" {
+			if _, err := time.LoadLocation(w.TimeZone); err != nil {
+				return fmt.Errorf("%s.maintenanceWindows[%d].timeZone: %v", field, i, err)
+			}
+		}
+		for _, day := range w.Weekdays {
+			valid := false
+			for wd := time.Sunday; wd <= time.Saturday; wd++ {
+				if weekdayMatches(day, wd) {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return fmt.Errorf("%s.maintenanceWindows[%d].weekdays: invalid weekday %q", field, i, day)
+			}
+		}
+	}
+	return nil
+}
+
+// SidecarDriftReconciler finds pods whose sidecars injected by webhook differ from the ones injected with the current
+// config, secret and mount image, and reports them. Pods with native sidecars can be evicted to inject new sidecars.
+type SidecarDriftReconciler struct {
+	Enable bool `json:"enable,omitempty"`
+	// interval of checking drift of each pod, the default is 10m
+	Interval string `json:"interval,omitempty"`
+	// evict drifted pods with native sidecars, which are owned by ReplicaSets, StatefulSets or DaemonSets,
+	// one pod of a workload at a time and only when all pods of it are ready
+	Restart bool `json:"restart,omitempty"`
+	// eviction is only scheduled in these windows, no limit if empty
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+}
+
+func (d *SidecarDriftReconciler) IsEnabled() bool {
+	return d != nil && d.Enable
+}
+
+func (d *SidecarDriftReconciler) GetInterval() time.Duration {
+	if d == nil || d.Interval == "" {
+		return defaultDriftReconcileInterval
+	}
+	interval, err := time.ParseDuration(d.Interval)
+	if err != nil || interval <= 0 {
+		return defaultDriftReconcileInterval
+	}
+	return interval
+}
+
+// CanRestart checks if drifted pods can be evicted now
+func (d *SidecarDriftReconciler) CanRestart(now time.Time) bool {
+	return d.IsEnabled() && d.Restart && inMaintenanceWindows(d.MaintenanceWindows, now)
+}
+
+func (d *SidecarDriftReconciler) validate() error {
+	if d == nil {
+		return nil
+	}
+	if d.Interval != "" {
+		if interval, err := time.ParseDuration(d.Interval); err != nil || interval <= 0 {
+			return fmt.Errorf("sidecarDriftReconciler.interval: invalid duration %q", d.Interval)
+		}
+	}
+	return validateMaintenanceWindows("sidecarDriftReconciler", d.MaintenanceWindows)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDriftReconciler_InMaintenanceWindow(t *testing.T) {
+	// 2024-06-01 is Saturday
+	sat0300 := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+	sat1200 := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	sun0100 := time.Date(2024, 6, 2, 1, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		windows  []MaintenanceWindow
+		now      time.Time
+		expected bool
+	}{
+		{name: "no windows", now: sat1200, expected: true},
+		{name: "in daily window", windows: []MaintenanceWindow{{Start: "02:00", End: "04:00"}}, now: sat0300, expected: true},
+		{name: "out of daily window", windows: []MaintenanceWindow{{Start: "02:00", End: "04:00"}}, now: sat1200, expected: false},
+		{name: "weekday matched", windows: []MaintenanceWindow{{Weekdays: []string{"Sat"}, Start: "02:00", End: "04:00"}}, now: sat0300, expected: true},
+		{name: "weekday not matched", windows: []MaintenanceWindow{{Weekdays: []string{"sunday"}, Start: "02:00", End: "04:00"}}, now: sat0300, expected: false},
+		{name: "crosses midnight", windows: []MaintenanceWindow{{Weekdays: []string{"Sat"}, Start: "22:00", End: "02:00"}}, now: sun0100, expected: true},
+		{name: "crosses midnight of other day", windows: []MaintenanceWindow{{Weekdays: []string{"Sun"}, Start: "22:00", End: "02:00"}}, now: sun0100, expected: false},
+		{name: "time zone", windows: []MaintenanceWindow{{Start: "11:00", End: "12:00", TimeZone: "Asia/Shanghai"}}, now: sat0300, expected: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &DriftReconciler{Enable: true, MaintenanceWindows: tc.windows}
+			assert.Equal(t, tc.expected, d.InMaintenanceWindow(tc.now))
+		})
+	}
+}
+
+func TestDriftReconciler_validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		drift   *DriftReconciler
+		wantErr bool
+	}{
+		{name: "nil", drift: nil},
+		{name: "valid", drift: &DriftReconciler{Enable: true, Interval: "5m", MaxConcurrentPerNode: 2, MaintenanceWindows: []MaintenanceWindow{{Weekdays: []string{"Sat", "Sunday"}, Start: "22:00", End: "02:00"}}}},
+		{name: "invalid interval", drift: &DriftReconciler{Interval: "5"}, wantErr: true},
+		{name: "invalid start", drift: &DriftReconciler{MaintenanceWindows: []MaintenanceWindow{{Start: "2am", End: "04:00"}}}, wantErr: true},
+		{name: "empty window", drift: &DriftReconciler{MaintenanceWindows: []MaintenanceWindow{{Start: "02:00", End: "02:00"}}}, wantErr: true},
+		{name: "invalid weekday", drift: &DriftReconciler{MaintenanceWindows: []MaintenanceWindow{{Weekdays: []string{"Funday"}, Start: "02:00", End: "04:00"}}}, wantErr: true},
+		{name: "invalid time zone", drift: &DriftReconciler{MaintenanceWindows: []MaintenanceWindow{{Start: "02:00", End: "04:00", TimeZone: "Mars/Base"}}}, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.drift.validate()
+			assert.Equal(t, tc.wantErr, err != nil, "validate() error = %v", err)
+		})
+	}
+}
+
+func TestSidecarDriftReconciler_CanRestart(t *testing.T) {
+	sat0300 := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+	var d *SidecarDriftReconciler
+	assert.False(t, d.CanRestart(sat0300))
+	assert.Equal(t, defaultDriftReconcileInterval, d.GetInterval())
+	d = &SidecarDriftReconciler{Enable: true}
+	assert.False(t, d.CanRestart(sat0300), "restart is not enabled")
+	d.Restart = true
+	assert.True(t, d.CanRestart(sat0300))
+	d.MaintenanceWindows = []MaintenanceWindow{{Weekdays: []string{"Sun"}, Start: "02:00", End: "04:00"}}
+	assert.False(t, d.CanRestart(sat0300), "out of maintenance windows")
+
+	d.MaintenanceWindows = []MaintenanceWindow{{Start: "2am", End: "04:00"}}
+	assert.Error(t, d.validate())
+	d = &SidecarDriftReconciler{Interval: "0s"}
+	assert.Error(t, d.validate())
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FSAccessPolicy allows namespaces to use file systems at paths with prefixes. Once any policy is set,
+// a volume is rejected unless any policy matching its namespace allows both its file system and path.
+type FSAccessPolicy struct {
+	// names of namespaces the policy applies to
+	Namespaces []string `json:"namespaces,omitempty"`
+	// selector of namespaces the policy applies to, it applies to all namespaces if both are omitted
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// file systems allowed, any file system if empty
+	Filesystems []FSRef `json:"filesystems,omitempty"`
+	// prefixes of paths in the file system allowed, e.g. /tenant-a, any path if empty
+	SubdirPrefixes []string `json:"subdirPrefixes,omitempty"`
+}
+
+// FSRef refers to a file system by the secret of its volume credentials, or by its name
+type FSRef struct {
+	// <namespace>/<name> of the secret of volume credentials
+	Secret string `json:"secret,omitempty"`
+	// name of the file system, which is "name" in volume credentials
+	Name string `json:"name,omitempty"`
+}
+
+// FSAccess is the use of a file system by a volume, which is checked against FSAccessPolicies
+type FSAccess struct {
+	// namespace of the PVC or app pod
+	Namespace *corev1.Namespace
+	// secret of volume credentials
+	SecretNamespace string
+	SecretName      string
+	// name of the file system
+	FSName string
+	// absolute path in the file system, see PathInFS
+	Path string
+}
+
+func (a FSAccess) String() string {
+	return fmt.Sprintf("file system %q (secret %s/%s) at %s", a.FSName, a.SecretNamespace, a.SecretName, a.Path)
+}
+
+// CheckFSAccess returns an error if no policy allows the access. All accesses are allowed if no policy is set.
+func (c *Config) CheckFSAccess(access FSAccess) error {
+	if len(c.FSAccessPolicies) == 0 {
+		return nil
+	}
+	for i := range c.FSAccessPolicies {
+		policy := &c.FSAccessPolicies[i]
+		if policy.isMatch(access.Namespace) && policy.allowsFS(access) && policy.allowsPath(access.Path) {
+			return nil
+		}
+	}
+	name := ""
+	if access.Namespace != nil {
+		name = access.Namespace.Name
+	}
+	return fmt.Errorf("namespace %s is not allowed to use %s by fsAccessPolicies", name, access)
+}
+
+func (p *FSAccessPolicy) isMatch(namespace *corev1.Namespace) bool {
+	if namespace == nil {
+		return false
+	}
+	if len(p.Namespaces) == 0 && p.NamespaceSelector == nil {
+		return true
+	}
+	return slices.Contains(p.Namespaces, namespace.Name) || (p.NamespaceSelector != nil && matchSelector(p.NamespaceSelector, namespace))
+}
+
+func (p *FSAccessPolicy) allowsFS(access FSAccess) bool {
+	if len(p.Filesystems) == 0 {
+		return true
+	}
+	for _, fs := range p.Filesystems {
+		if fs.Secret != "" && fs.Secret == access.SecretNamespace+"/"+access.SecretName {
+			return true
+		}
+		if fs.Name != "" && fs.Name == access.FSName {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *FSAccessPolicy) allowsPath(fsPath string) bool {
+	if len(p.SubdirPrefixes) == 0 {
+		return true
+	}
+	fsPath = path.Join("/", fsPath)
+	for _, prefix := range p.SubdirPrefixes {
+		prefix = path.Join("/", prefix)
+		if prefix == "/" || fsPath == prefix || strings.HasPrefix(fsPath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *FSAccessPolicy) validate() error {
+	if p.NamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(p.NamespaceSelector); err != nil {
+			return fmt.Errorf("namespaceSelector: %v", err)
+		}
+	}
+	for i, fs := range p.Filesystems {
+		if (fs.Secret == "") == (fs.Name == "") {
+			return fmt.Errorf("filesystems[%d]: exactly one of secret and name must be set", i)
+		}
+		if fs.Secret != "" {
+			if ns, name, ok := strings.Cut(fs.Secret, "/"); !ok || ns == "" || name == "" || strings.Contains(name, "/") {
+				return fmt.Errorf("filesystems[%d].secret: invalid value %q, expect <namespace>/<name>", i, fs.Secret)
+			}
+		}
+	}
+	for _, prefix := range p.SubdirPrefixes {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("subdirPrefixes: %q must be an absolute path", prefix)
+		}
+	}
+	return nil
+}
+
+// PathInFS returns the absolute path of the volume in the file system, with subdir mount option and subPath
+func PathInFS(options []string, subPath string) string {
+	subdir := "/"
+	for _, option := range options {
+		if strings.HasPrefix(option, "subdir=") {
+			subdir = path.Join("/", strings.TrimPrefix(option, "subdir="))
+		}
+	}
+	return path.Join(subdir, subPath)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfig_CheckFSAccess(t *testing.T) {
+	cfg := &Config{}
+	tenantA := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a"}}
+	tenantB := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b", Labels: map[string]string{"tier": "gold"}}}
+	access := func(ns *corev1.Namespace, secret, fsName, fsPath string) FSAccess {
+		secretNamespace, secretName, _ := strings.Cut(secret, "/")
+		return FSAccess{Namespace: ns, SecretNamespace: secretNamespace, SecretName: secretName, FSName: fsName, Path: fsPath}
+	}
+	assert.NoError(t, cfg.CheckFSAccess(access(tenantA, "kube-system/juicefs-secret", "shared", "/")), "no policy")
+
+	cfg.FSAccessPolicies = []FSAccessPolicy{
+		{Namespaces: []string{"tenant-a"}, Filesystems: []FSRef{{Secret: "kube-system/juicefs-secret"}}, SubdirPrefixes: []string{"/tenant-a"}},
+		{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}}, Filesystems: []FSRef{{Name: "gold"}}},
+	}
+	assert.NoError(t, cfg.CheckFSAccess(access(tenantA, "kube-system/juicefs-secret", "shared", "/tenant-a")))
+	assert.NoError(t, cfg.CheckFSAccess(access(tenantA, "kube-system/juicefs-secret", "shared", "/tenant-a/pvc-1")))
+	assert.Error(t, cfg.CheckFSAccess(access(tenantA, "kube-system/juicefs-secret", "shared", "/tenant-ab")), "not a prefix of path components")
+	assert.Error(t, cfg.CheckFSAccess(access(tenantA, "kube-system/juicefs-secret", "shared", "/")))
+	assert.Error(t, cfg.CheckFSAccess(access(tenantA, "kube-system/other-secret", "shared", "/tenant-a")))
+	assert.Error(t, cfg.CheckFSAccess(access(tenantA, "kube-system/other-secret", "gold", "/tenant-a")), "file system of other namespace")
+	assert.NoError(t, cfg.CheckFSAccess(access(tenantB, "tenant-b/secret", "gold", "/any")))
+	assert.Error(t, cfg.CheckFSAccess(access(tenantB, "kube-system/juicefs-secret", "shared", "/tenant-a")))
+	assert.Error(t, cfg.CheckFSAccess(access(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}, "tenant-b/secret", "gold", "/")), "no policy matches namespace")
+
+	cfg.FSAccessPolicies = []FSAccessPolicy{{Filesystems: []FSRef{{Secret: "juicefs-secret"}}}}
+	assert.Error(t, cfg.Validate())
+	cfg.FSAccessPolicies = []FSAccessPolicy{{Filesystems: []FSRef{{Secret: "default/juicefs-secret", Name: "shared"}}}}
+	assert.Error(t, cfg.Validate())
+	cfg.FSAccessPolicies = []FSAccessPolicy{{SubdirPrefixes: []string{"tenant-a"}}}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestPathInFS(t *testing.T) {
+	assert.Equal(t, "/", PathInFS(nil, ""))
+	assert.Equal(t, "/pvc-1", PathInFS([]string{"cache-size=100"}, "pvc-1"))
+	assert.Equal(t, "/tenant-a/pvc-1", PathInFS([]string{"subdir=tenant-a"}, "pvc-1"))
+	assert.Equal(t, "/tenant-a", PathInFS([]string{"subdir=/tenant-a/"}, ""))
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MountPodMetrics allocates unique metrics port in each node for hostNetwork mount pods instead of a random one,
+// and adds labels of the volume and prometheus scrape annotations to mount pods
+type MountPodMetrics struct {
+	Enable bool `json:"enable,omitempty"`
+	// port range allocated to hostNetwork mount pods in each node, the default is 9600-9699
+	HostPortRange string `json:"hostPortRange,omitempty"`
+	// add prometheus.io/scrape, prometheus.io/port and prometheus.io/path annotations to mount pods
+	ScrapeAnnotations bool `json:"scrapeAnnotations,omitempty"`
+}
+
+const defaultMetricsHostPortRange = "9600-9699"
+
+func (m *MountPodMetrics) IsEnabled() bool {
+	return m != nil && m.Enable
+}
+
+// GetHostPortRange returns the first and the last port of the range
+func (m *MountPodMetrics) GetHostPortRange() (int32, int32) {
+	r := defaultMetricsHostPortRange
+	if m != nil && m.HostPortRange != "" {
+		r = m.HostPortRange
+	}
+	start, end, err := parsePortRange(r)
+	if err != nil {
+		start, end, _ = parsePortRange(defaultMetricsHostPortRange)
+	}
+	return start, end
+}
+
+func parsePortRange(r string) (int32, int32, error) {
+	pair := strings.SplitN(r, "-", 2)
+	if len(pair) != 2 {
+		return 0, 0, fmt.Errorf("invalid port range %q, expect <start>-<end>", r)
+	}
+	start, err := strconv.ParseInt(strings.TrimSpace(pair[0]), 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %q: %v", r, err)
+	}
+	end, err := strconv.ParseInt(strings.TrimSpace(pair[1]), 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %q: %v", r, err)
+	}
+	if start < 1 || end > 65535 || start > end {
+		return 0, 0, fmt.Errorf("invalid port range %q", r)
+	}
+	return int32(start), int32(end), nil
+}
+
+func (m *MountPodMetrics) validate() error {
+	if m == nil || m.HostPortRange == "" {
+		return nil
+	}
+	if _, _, err := parsePortRange(m.HostPortRange); err != nil {
+		return fmt.Errorf("mountPodMetrics.hostPortRange: %v", err)
+	}
+	return nil
+}
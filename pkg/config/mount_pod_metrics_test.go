@@ -0,0 +1,46 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountPodMetrics_GetHostPortRange(t *testing.T) {
+	testCases := []struct {
+		name      string
+		metrics   *MountPodMetrics
+		wantStart int32
+		wantEnd   int32
+		wantErr   bool
+	}{
+		{name: "nil", metrics: nil, wantStart: 9600, wantEnd: 9699},
+		{name: "custom", metrics: &MountPodMetrics{HostPortRange: "20000-20010"}, wantStart: 20000, wantEnd: 20010},
+		{name: "reversed", metrics: &MountPodMetrics{HostPortRange: "20010-20000"}, wantStart: 9600, wantEnd: 9699, wantErr: true},
+		{name: "invalid", metrics: &MountPodMetrics{HostPortRange: "9600"}, wantStart: 9600, wantEnd: 9699, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			start, end := tc.metrics.GetHostPortRange()
+			assert.Equal(t, tc.wantStart, start)
+			assert.Equal(t, tc.wantEnd, end)
+			assert.Equal(t, tc.wantErr, tc.metrics.validate() != nil)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NonPrivilegedMountPod runs mount pods without privileged, only with CAP_SYS_ADMIN, for StorageClasses with parameter
+// juicefs/mount-privileged: "false". Mount pods get /dev/fuse either from a device plugin or as a CDI device.
+type NonPrivilegedMountPod struct {
+	// extended resource of the device plugin which provides /dev/fuse, e.g. smarter-devices/fuse
+	FuseResource string `json:"fuseResource,omitempty"`
+	// fully qualified name of the CDI device of /dev/fuse, e.g. juicefs.com/fuse=fuse
+	CDIDevice string `json:"cdiDevice,omitempty"`
+}
+
+// minimal versions of container runtimes which inject CDI devices requested by pod annotations
+var cdiRuntimeVersions = map[string][2]int{
+	"containerd": {1, 7},
+	"cri-o":      {1, 23},
+}
+
+func (n *NonPrivilegedMountPod) validate() error {
+	if n == nil {
+		return nil
+	}
+	if (n.FuseResource == "") == (n.CDIDevice == "") {
+		return fmt.Errorf("nonPrivilegedMountPod: exactly one of fuseResource and cdiDevice should be set")
+	}
+	if n.FuseResource != "" {
+		if errs := validation.IsQualifiedName(n.FuseResource); len(errs) != 0 || !strings.Contains(n.FuseResource, "/") {
+			return fmt.Errorf("nonPrivilegedMountPod.fuseResource: %s is not an extended resource name", n.FuseResource)
+		}
+	}
+	if n.CDIDevice != "" {
+		kind, name, found := strings.Cut(n.CDIDevice, "=")
+		if !found || name == "" || !strings.Contains(kind, "/") {
+			return fmt.Errorf("nonPrivilegedMountPod.cdiDevice: %s is not in the form of vendor/class=name", n.CDIDevice)
+		}
+	}
+	return nil
+}
+
+// CheckNode checks the node supports non-privileged mount pods:
+// 1. /dev/fuse exists in the node
+// 2. the device plugin advertises fuseResource in allocatable of the node
+// 3. the container runtime of the node supports CDI devices
+func (n *NonPrivilegedMountPod) CheckNode(node *corev1.Node, fuseDeviceExists bool) error {
+	if n == nil {
+		return fmt.Errorf("nonPrivilegedMountPod is not configured")
+	}
+	if !fuseDeviceExists {
+		return fmt.Errorf("/dev/fuse does not exist in node")
+	}
+	if node == nil {
+		return fmt.Errorf("node is unknown")
+	}
+	if n.FuseResource != "" {
+		q, ok := node.Status.Allocatable[corev1.ResourceName(n.FuseResource)]
+		if !ok || q.IsZero() {
+			return fmt.Errorf("resource %s is not allocatable in node %s, is its device plugin running?", n.FuseResource, node.Name)
+		}
+	}
+	if n.CDIDevice != "" {
+		runtimeVersion := node.Status.NodeInfo.ContainerRuntimeVersion
+		runtime, version, _ := strings.Cut(runtimeVersion, "://")
+		minVersion, ok := cdiRuntimeVersions[runtime]
+		if !ok {
+			return fmt.Errorf("container runtime %s does not support CDI devices", runtimeVersion)
+		}
+		major, minor := parseMajorMinor(version)
+		if major < minVersion[0] || (major == minVersion[0] && minor < minVersion[1]) {
+			return fmt.Errorf("container runtime %s does not support CDI devices, %s %d.%d or later is required", runtimeVersion, runtime, minVersion[0], minVersion[1])
+		}
+	}
+	return nil
+}
+
+// NonPrivilegedMountPodUnsupported is why the node can't run non-privileged mount pods, set by the compatibility check of csi node,
+// which runs at start-up and again when nonPrivilegedMountPod is changed
+var NonPrivilegedMountPodUnsupported = errors.New("compatibility of the node with non-privileged mount pods is not checked yet")
+
+// CheckNonPrivilegedMountPod returns the reason if non-privileged mount pods can't be created in the node
+func CheckNonPrivilegedMountPod() error {
+	if GlobalConfig.NonPrivilegedMountPod == nil {
+		return fmt.Errorf("nonPrivilegedMountPod is not configured")
+	}
+	if DisableGraceUpgrade {
+		// FUSE is mounted by csi node and passed to the mount pod by the fd server of smooth upgrade
+		return fmt.Errorf("FUSE fd can't be passed to mount pods with DISABLE_GRACE_UPGRADE")
+	}
+	return NonPrivilegedMountPodUnsupported
+}
+
+func parseMajorMinor(version string) (int, int) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	major, _ := strconv.Atoi(parts[0])
+	minor := 0
+	if len(parts) > 1 {
+		minor, _ = strconv.Atoi(parts[1])
+	}
+	return major, minor
+}
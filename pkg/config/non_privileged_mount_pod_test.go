@@ -0,0 +1,85 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNonPrivilegedMountPod_validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		n       *NonPrivilegedMountPod
+		wantErr bool
+	}{
+		{name: "nil", n: nil},
+		{name: "resource", n: &NonPrivilegedMountPod{FuseResource: "smarter-devices/fuse"}},
+		{name: "cdi", n: &NonPrivilegedMountPod{CDIDevice: "juicefs.com/fuse=fuse"}},
+		{name: "none", n: &NonPrivilegedMountPod{}, wantErr: true},
+		{name: "both", n: &NonPrivilegedMountPod{FuseResource: "smarter-devices/fuse", CDIDevice: "juicefs.com/fuse=fuse"}, wantErr: true},
+		{name: "native resource", n: &NonPrivilegedMountPod{FuseResource: "cpu"}, wantErr: true},
+		{name: "unqualified cdi", n: &NonPrivilegedMountPod{CDIDevice: "fuse"}, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantErr, tc.n.validate() != nil)
+		})
+	}
+}
+
+func TestNonPrivilegedMountPod_CheckNode(t *testing.T) {
+	newNode := func(runtime string, allocatable corev1.ResourceList) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node"},
+			Status: corev1.NodeStatus{
+				Allocatable: allocatable,
+				NodeInfo:    corev1.NodeSystemInfo{ContainerRuntimeVersion: runtime},
+			},
+		}
+	}
+	fuse := corev1.ResourceList{"smarter-devices/fuse": resource.MustParse("20")}
+	byResource := &NonPrivilegedMountPod{FuseResource: "smarter-devices/fuse"}
+	byCDI := &NonPrivilegedMountPod{CDIDevice: "juicefs.com/fuse=fuse"}
+	testCases := []struct {
+		name       string
+		n          *NonPrivilegedMountPod
+		node       *corev1.Node
+		fuseExists bool
+		wantErr    bool
+	}{
+		{name: "not configured", n: nil, node: newNode("containerd://1.7.2", fuse), fuseExists: true, wantErr: true},
+		{name: "no /dev/fuse", n: byResource, node: newNode("containerd://1.7.2", fuse), wantErr: true},
+		{name: "resource allocatable", n: byResource, node: newNode("containerd://1.6.0", fuse), fuseExists: true},
+		{name: "resource not allocatable", n: byResource, node: newNode("containerd://1.7.2", nil), fuseExists: true, wantErr: true},
+		{name: "containerd supports cdi", n: byCDI, node: newNode("containerd://2.0.0", nil), fuseExists: true},
+		{name: "old containerd", n: byCDI, node: newNode("containerd://1.6.21", nil), fuseExists: true, wantErr: true},
+		{name: "cri-o supports cdi", n: byCDI, node: newNode("cri-o://1.28.1", nil), fuseExists: true},
+		{name: "docker", n: byCDI, node: newNode("docker://20.10.7", nil), fuseExists: true, wantErr: true},
+		{name: "unknown node", n: byCDI, node: nil, fuseExists: true, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.n.CheckNode(tc.node, tc.fuseExists)
+			assert.Equal(t, tc.wantErr, err != nil, "err: %v", err)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// OOMRecovery raises memory limit of mount pod step by step when it is OOMKilled
+type OOMRecovery struct {
+	Enable bool `json:"enable,omitempty"`
+	// percentage of memory limit raised each time, the default is 50
+	StepPercent int `json:"stepPercent,omitempty"`
+	// the ceiling of memory limit, the default is 16Gi
+	MaxMemoryLimit *resource.Quantity `json:"maxMemoryLimit,omitempty"`
+}
+
+const (
+	defaultOOMStepPercent    = 50
+	defaultOOMMaxMemoryLimit = "16Gi"
+)
+
+func (o *OOMRecovery) IsEnabled() bool {
+	return o != nil && o.Enable
+}
+
+func (o *OOMRecovery) GetMaxMemoryLimit() resource.Quantity {
+	if o == nil || o.MaxMemoryLimit == nil {
+		return resource.MustParse(defaultOOMMaxMemoryLimit)
+	}
+	return *o.MaxMemoryLimit
+}
+
+// NextMemoryLimit returns the raised memory limit, false if current limit has reached the ceiling
+func (o *OOMRecovery) NextMemoryLimit(current resource.Quantity) (resource.Quantity, bool) {
+	maxLimit := o.GetMaxMemoryLimit()
+	if current.Cmp(maxLimit) >= 0 {
+		return current, false
+	}
+	step := defaultOOMStepPercent
+	if o != nil && o.StepPercent > 0 {
+		step = o.StepPercent
+	}
+	next := resource.NewQuantity(current.Value()*int64(100+step)/100, resource.BinarySI)
+	if next.Cmp(maxLimit) > 0 {
+		return maxLimit, true
+	}
+	return *next, true
+}
+
+func (o *OOMRecovery) validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.StepPercent < 0 {
+		return fmt.Errorf("oomRecovery.stepPercent: must not be negative, got %d", o.StepPercent)
+	}
+	if o.MaxMemoryLimit != nil && o.MaxMemoryLimit.Sign() <= 0 {
+		return fmt.Errorf("oomRecovery.maxMemoryLimit: must be positive, got %s", o.MaxMemoryLimit.String())
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestOOMRecovery_NextMemoryLimit(t *testing.T) {
+	testCases := []struct {
+		name        string
+		oomRecovery *OOMRecovery
+		current     string
+		expected    string
+		raised      bool
+	}{
+		{
+			name:        "default step",
+			oomRecovery: &OOMRecovery{Enable: true},
+			current:     "1Gi",
+			expected:    "1536Mi",
+			raised:      true,
+		},
+		{
+			name:        "custom step",
+			oomRecovery: &OOMRecovery{Enable: true, StepPercent: 100},
+			current:     "1Gi",
+			expected:    "2Gi",
+			raised:      true,
+		},
+		{
+			name:        "capped by ceiling",
+			oomRecovery: &OOMRecovery{Enable: true, StepPercent: 100, MaxMemoryLimit: toPtr(resource.MustParse("3Gi"))},
+			current:     "2Gi",
+			expected:    "3Gi",
+			raised:      true,
+		},
+		{
+			name:        "reach ceiling",
+			oomRecovery: &OOMRecovery{Enable: true, MaxMemoryLimit: toPtr(resource.MustParse("3Gi"))},
+			current:     "3Gi",
+			expected:    "3Gi",
+			raised:      false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			next, raised := tc.oomRecovery.NextMemoryLimit(resource.MustParse(tc.current))
+			assert.Equal(t, tc.raised, raised)
+			assert.Equal(t, 0, next.Cmp(resource.MustParse(tc.expected)), "got %s", next.String())
+		})
+	}
+}
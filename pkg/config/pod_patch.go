@@ -0,0 +1,95 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// PodPatch is applied to the generated mount pod after the builder, for fields not supported by MountPodPatch,
+// e.g. securityContext, priorityClassName. Volumes and envs set by CSI are protected and can not be changed.
+type PodPatch struct {
+	// strategic merge patch of the pod, e.g. {"spec": {"priorityClassName": "high"}}
+	StrategicMergePatch *runtime.RawExtension `json:"strategicMergePatch,omitempty"`
+	// RFC 6902 JSON patch of the pod, applied after strategicMergePatch
+	JSONPatch *runtime.RawExtension `json:"jsonPatch,omitempty"`
+}
+
+func (p PodPatch) IsEmpty() bool {
+	return p.StrategicMergePatch == nil && p.JSONPatch == nil
+}
+
+func (p PodPatch) validate() error {
+	if p.StrategicMergePatch != nil {
+		var m map[string]interface{}
+		if err := json.Unmarshal(p.StrategicMergePatch.Raw, &m); err != nil {
+			return fmt.Errorf("strategicMergePatch: must be an object: %v", err)
+		}
+	}
+	if p.JSONPatch != nil {
+		if _, err := jsonpatch.DecodePatch(p.JSONPatch.Raw); err != nil {
+			return fmt.Errorf("jsonPatch: %v", err)
+		}
+	}
+	// patches are applied to the spec of existing mount pods again when the config changes
+	return p.CheckIdempotent()
+}
+
+// CheckIdempotent returns error if the json patch gives a different result when it's applied again
+func (p PodPatch) CheckIdempotent() error {
+	if p.JSONPatch == nil {
+		return nil
+	}
+	patch, err := jsonpatch.DecodePatch(p.JSONPatch.Raw)
+	if err != nil {
+		return fmt.Errorf("jsonPatch: %v", err)
+	}
+	for i, op := range patch {
+		if !isIdempotentJSONPatchOp(op) {
+			path, _ := op.Path()
+			return fmt.Errorf("jsonPatch %d: %s on list item %s is not idempotent, use replace, or strategicMergePatch instead", i, op.Kind(), path)
+		}
+	}
+	return nil
+}
+
+// isIdempotentJSONPatchOp checks if applying the operation twice gives the same result as applying it once.
+// Inserting, removing or moving list items shifts the list every time it's applied.
+func isIdempotentJSONPatchOp(op jsonpatch.Operation) bool {
+	switch op.Kind() {
+	case "add", "remove", "copy":
+		path, err := op.Path()
+		if err != nil {
+			return true
+		}
+		token := path[strings.LastIndex(path, "/")+1:]
+		if token == "-" {
+			return false
+		}
+		_, err = strconv.Atoi(token)
+		return err != nil
+	case "move":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestMountPodPatch_PodPatch(t *testing.T) {
+	configPath := "/tmp/test-config-pod-patch.yaml"
+	defer os.Remove(configPath)
+	testData := []byte(`
+mountPodPatch:
+  - strategicMergePatch:
+      spec:
+        priorityClassName: high
+  - pvcSelector:
+      matchName: "test"
+    jsonPatch:
+      - op: add
+        path: /metadata/labels/pvc
+        value: ${VOLUME_ID}
+`)
+	assert.NoError(t, os.WriteFile(configPath, testData, 0644))
+	assert.NoError(t, LoadConfig(configPath))
+	defer GlobalConfig.Reset()
+
+	assert.Equal(t, len(GlobalConfig.MountPodPatch), 2)
+	assert.JSONEq(t, `{"spec":{"priorityClassName":"high"}}`, string(GlobalConfig.MountPodPatch[0].StrategicMergePatch.Raw))
+
+	setting := JfsSetting{
+		VolumeId: "pv-1",
+		PVC:      &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+	}
+	patch, err := GlobalConfig.GenMountPodPatch(setting, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(patch.PodPatches), 2)
+	assert.JSONEq(t, `[{"op":"add","path":"/metadata/labels/pvc","value":"pv-1"}]`, string(patch.PodPatches[1].JSONPatch.Raw))
+	// template in global config is not replaced
+	assert.Contains(t, string(GlobalConfig.MountPodPatch[1].JSONPatch.Raw), "${VOLUME_ID}")
+
+	setting.PVC.Name = "other"
+	patch, err = GlobalConfig.GenMountPodPatch(setting, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(patch.PodPatches), 1)
+}
+
+func TestPodPatch_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   PodPatch
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			patch: PodPatch{},
+		},
+		{
+			name: "valid",
+			patch: PodPatch{
+				StrategicMergePatch: &runtime.RawExtension{Raw: []byte(`{"spec":{"priorityClassName":"high"}}`)},
+				JSONPatch:           &runtime.RawExtension{Raw: []byte(`[{"op":"add","path":"/spec/hostPID","value":true}]`)},
+			},
+		},
+		{
+			name:    "strategic merge patch is not an object",
+			patch:   PodPatch{StrategicMergePatch: &runtime.RawExtension{Raw: []byte(`["a"]`)}},
+			wantErr: true,
+		},
+		{
+			name:  "replace list item",
+			patch: PodPatch{JSONPatch: &runtime.RawExtension{Raw: []byte(`[{"op":"replace","path":"/spec/containers/0/args/0","value":"-v"}]`)}},
+		},
+		{
+			name:    "append to list",
+			patch:   PodPatch{JSONPatch: &runtime.RawExtension{Raw: []byte(`[{"op":"add","path":"/spec/containers/0/env/-","value":{"name":"A","value":"a"}}]`)}},
+			wantErr: true,
+		},
+		{
+			name:    "insert into list",
+			patch:   PodPatch{JSONPatch: &runtime.RawExtension{Raw: []byte(`[{"op":"add","path":"/spec/tolerations/0","value":{"operator":"Exists"}}]`)}},
+			wantErr: true,
+		},
+		{
+			name:    "remove list item",
+			patch:   PodPatch{JSONPatch: &runtime.RawExtension{Raw: []byte(`[{"op":"remove","path":"/spec/tolerations/0"}]`)}},
+			wantErr: true,
+		},
+		{
+			name:    "move",
+			patch:   PodPatch{JSONPatch: &runtime.RawExtension{Raw: []byte(`[{"op":"move","from":"/metadata/labels/a","path":"/metadata/labels/b"}]`)}},
+			wantErr: true,
+		},
+		{
+			name:    "json patch is not a list",
+			patch:   PodPatch{JSONPatch: &runtime.RawExtension{Raw: []byte(`{"op":"add"}`)}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.patch.validate()
+			assert.Equal(t, tt.wantErr, err != nil, "validate() error = %v", err)
+		})
+	}
+}
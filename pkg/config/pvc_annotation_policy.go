@@ -0,0 +1,92 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+)
+
+// PVCAnnotationPolicy allows PVCs in namespaces to set juicefs/* annotations. Once any policy is set,
+// juicefs/* annotations not allowed by a matched policy are rejected by the webhook and ignored at mount time.
+// Policies only match namespaces, since the creator of a PVC is unknown when it is mounted.
+type PVCAnnotationPolicy struct {
+	// names of namespaces the policy applies to
+	Namespaces []string `json:"namespaces,omitempty"`
+	// selector of namespaces the policy applies to, it applies to all namespaces if both are omitted
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// annotation keys allowed, in glob patterns, e.g. juicefs/host-path or juicefs/*
+	AllowedKeys []string `json:"allowedKeys"`
+}
+
+// IsPVCAnnotationAllowed checks if PVCs in the namespace can set the annotation.
+// It is allowed if no policy is set, or any matched policy allows it. Annotations not prefixed with juicefs/
+// and mount pod resources, which are checked by resource budgets, are always allowed.
+func (c *Config) IsPVCAnnotationAllowed(key string, namespace *corev1.Namespace) bool {
+	if len(c.PVCAnnotationPolicies) == 0 || !strings.HasPrefix(key, "juicefs/") {
+		return true
+	}
+	switch key {
+	case common.MountPodCpuLimitKey, common.MountPodMemLimitKey, common.MountPodCpuRequestKey, common.MountPodMemRequestKey:
+		return true
+	}
+	for i := range c.PVCAnnotationPolicies {
+		policy := &c.PVCAnnotationPolicies[i]
+		if !policy.isMatch(namespace) {
+			continue
+		}
+		for _, pattern := range policy.AllowedKeys {
+			if ok, _ := path.Match(pattern, key); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isMatch checks if the namespace matches, omitted namespaces match all
+func (p *PVCAnnotationPolicy) isMatch(namespace *corev1.Namespace) bool {
+	if len(p.Namespaces) == 0 && p.NamespaceSelector == nil {
+		return true
+	}
+	if namespace == nil {
+		return false
+	}
+	return slices.Contains(p.Namespaces, namespace.Name) ||
+		(p.NamespaceSelector != nil && matchSelector(p.NamespaceSelector, namespace))
+}
+
+func (p *PVCAnnotationPolicy) validate() error {
+	if p.NamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(p.NamespaceSelector); err != nil {
+			return fmt.Errorf("namespaceSelector: %v", err)
+		}
+	}
+	for _, pattern := range p.AllowedKeys {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("allowedKeys: invalid pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
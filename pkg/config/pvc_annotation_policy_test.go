@@ -0,0 +1,53 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+)
+
+func TestConfig_IsPVCAnnotationAllowed(t *testing.T) {
+	cfg := &Config{}
+	tenantA := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a"}}
+	tenantB := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b", Labels: map[string]string{"tier": "gold"}}}
+	assert.True(t, cfg.IsPVCAnnotationAllowed(common.MountPodHostPath, tenantA), "no policy")
+
+	cfg.PVCAnnotationPolicies = []PVCAnnotationPolicy{
+		{Namespaces: []string{"tenant-a"}, AllowedKeys: []string{common.MountPodImageKey}},
+		{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}}, AllowedKeys: []string{"juicefs/mount-*"}},
+	}
+	assert.True(t, cfg.IsPVCAnnotationAllowed("app.kubernetes.io/name", tenantA), "not juicefs annotation")
+	assert.True(t, cfg.IsPVCAnnotationAllowed(common.MountPodCpuLimitKey, tenantA), "mount pod resources")
+	assert.True(t, cfg.IsPVCAnnotationAllowed(common.MountPodImageKey, tenantA))
+	assert.False(t, cfg.IsPVCAnnotationAllowed(common.MountPodHostPath, tenantA))
+	assert.False(t, cfg.IsPVCAnnotationAllowed(common.CachePVC, tenantA))
+	assert.True(t, cfg.IsPVCAnnotationAllowed(common.CachePVC, tenantB), "namespace selector with glob")
+	assert.False(t, cfg.IsPVCAnnotationAllowed(common.MountPodHostPath, tenantB))
+	assert.False(t, cfg.IsPVCAnnotationAllowed(common.MountPodImageKey, nil), "unknown namespace")
+
+	cfg.PVCAnnotationPolicies = append(cfg.PVCAnnotationPolicies, PVCAnnotationPolicy{AllowedKeys: []string{common.MountPodHostPath}})
+	assert.True(t, cfg.IsPVCAnnotationAllowed(common.MountPodHostPath, nil), "policy without namespaces applies to all")
+
+	cfg.PVCAnnotationPolicies = []PVCAnnotationPolicy{{AllowedKeys: []string{"juicefs/[mount"}}}
+	assert.Error(t, cfg.Validate())
+}
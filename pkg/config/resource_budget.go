@@ -0,0 +1,163 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceBudget caps resources of mount pods caused by PVCs in namespaces, since mount pods run in
+// the namespace of CSI driver and are not counted in resource quotas of these namespaces.
+type ResourceBudget struct {
+	// names of namespaces the budget applies to
+	Namespaces []string `json:"namespaces,omitempty"`
+	// selector of namespaces the budget applies to, it applies to all namespaces if both are omitted
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Reject (default) fails to mount when resources exceed the budget, Clamp lowers them to the budget
+	Policy ResourceBudgetPolicy `json:"policy,omitempty"`
+	// max cpu/memory requests and limits of a single mount pod
+	MaxPerMountPod corev1.ResourceList `json:"maxPerMountPod,omitempty"`
+	// max total cpu/memory limits of all mount pods caused by PVCs in the namespace
+	Total corev1.ResourceList `json:"total,omitempty"`
+}
+
+type ResourceBudgetPolicy string
+
+const (
+	ResourceBudgetPolicyReject ResourceBudgetPolicy = "Reject"
+	ResourceBudgetPolicyClamp  ResourceBudgetPolicy = "Clamp"
+)
+
+var budgetResourceNames = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// GetResourceBudget returns the first budget matching the namespace, nil if none
+func (c *Config) GetResourceBudget(namespace *corev1.Namespace) *ResourceBudget {
+	if namespace == nil {
+		return nil
+	}
+	for i := range c.ResourceBudgets {
+		if c.ResourceBudgets[i].isMatch(namespace) {
+			return &c.ResourceBudgets[i]
+		}
+	}
+	return nil
+}
+
+func (b *ResourceBudget) isMatch(namespace *corev1.Namespace) bool {
+	if len(b.Namespaces) == 0 && b.NamespaceSelector == nil {
+		return true
+	}
+	for _, name := range b.Namespaces {
+		if name == namespace.Name {
+			return true
+		}
+	}
+	return b.NamespaceSelector != nil && matchSelector(b.NamespaceSelector, namespace)
+}
+
+func (b *ResourceBudget) IsClamp() bool {
+	return b != nil && b.Policy == ResourceBudgetPolicyClamp
+}
+
+// Apply checks requests and limits of a mount pod against the budget, used is the total limits of
+// other mount pods in the namespace. Resources over the budget are lowered to the budget in Clamp policy,
+// or an error is returned in Reject policy. Missing limit means unlimited, which exceeds any budget.
+func (b *ResourceBudget) Apply(resources corev1.ResourceRequirements, used corev1.ResourceList) (corev1.ResourceRequirements, error) {
+	if b == nil {
+		return resources, nil
+	}
+	result := *resources.DeepCopy()
+	if result.Limits == nil {
+		result.Limits = corev1.ResourceList{}
+	}
+	if result.Requests == nil {
+		result.Requests = corev1.ResourceList{}
+	}
+	for _, name := range budgetResourceNames {
+		maxValue, ok := b.maxOf(name, used)
+		if !ok {
+			continue
+		}
+		if maxValue.Sign() <= 0 {
+			return resources, fmt.Errorf("%s budget is used up, total %s, used %s", name, b.Total.Name(name, resource.DecimalSI).String(), used.Name(name, resource.DecimalSI).String())
+		}
+		limit, hasLimit := result.Limits[name]
+		if !hasLimit || limit.Cmp(maxValue) > 0 {
+			if !b.IsClamp() {
+				return resources, fmt.Errorf("%s limit %s exceeds the budget %s", name, quantityString(limit, hasLimit), maxValue.String())
+			}
+			result.Limits[name] = maxValue
+		}
+		if request, ok := result.Requests[name]; ok && request.Cmp(maxValue) > 0 {
+			if !b.IsClamp() {
+				return resources, fmt.Errorf("%s request %s exceeds the budget %s", name, request.String(), maxValue.String())
+			}
+			result.Requests[name] = maxValue
+		}
+	}
+	return result, nil
+}
+
+// maxOf returns the max value of the resource allowed for a mount pod, false if not limited
+func (b *ResourceBudget) maxOf(name corev1.ResourceName, used corev1.ResourceList) (resource.Quantity, bool) {
+	maxValue, ok := b.MaxPerMountPod[name]
+	if total, hasTotal := b.Total[name]; hasTotal {
+		remaining := total.DeepCopy()
+		if u, hasUsed := used[name]; hasUsed {
+			remaining.Sub(u)
+		}
+		if !ok || remaining.Cmp(maxValue) < 0 {
+			maxValue, ok = remaining, true
+		}
+	}
+	return maxValue, ok
+}
+
+func quantityString(q resource.Quantity, ok bool) string {
+	if !ok {
+		return "unlimited"
+	}
+	return q.String()
+}
+
+func (b *ResourceBudget) validate() error {
+	switch b.Policy {
+	case "", ResourceBudgetPolicyReject, ResourceBudgetPolicyClamp:
+	default:
+		return fmt.Errorf("policy: invalid value %q, must be one of Reject, Clamp", b.Policy)
+	}
+	if b.NamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(b.NamespaceSelector); err != nil {
+			return fmt.Errorf("namespaceSelector: %v", err)
+		}
+	}
+	for field, list := range map[string]corev1.ResourceList{"maxPerMountPod": b.MaxPerMountPod, "total": b.Total} {
+		for name, q := range list {
+			if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+				return fmt.Errorf("%s: unsupported resource %q, must be one of cpu, memory", field, name)
+			}
+			if q.Sign() <= 0 {
+				return fmt.Errorf("%s.%s: must be positive, got %s", field, name, q.String())
+			}
+		}
+	}
+	return nil
+}
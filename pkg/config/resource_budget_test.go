@@ -0,0 +1,135 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResourceBudget_Apply(t *testing.T) {
+	resources := func(cpuLimit, memLimit, cpuRequest, memRequest string) corev1.ResourceRequirements {
+		r := corev1.ResourceRequirements{Limits: corev1.ResourceList{}, Requests: corev1.ResourceList{}}
+		if cpuLimit != "" {
+			r.Limits[corev1.ResourceCPU] = resource.MustParse(cpuLimit)
+		}
+		if memLimit != "" {
+			r.Limits[corev1.ResourceMemory] = resource.MustParse(memLimit)
+		}
+		r.Requests[corev1.ResourceCPU] = resource.MustParse(cpuRequest)
+		r.Requests[corev1.ResourceMemory] = resource.MustParse(memRequest)
+		return r
+	}
+	perPod := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("4Gi")}
+	total := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10"), corev1.ResourceMemory: resource.MustParse("10Gi")}
+	testCases := []struct {
+		name      string
+		budget    *ResourceBudget
+		resources corev1.ResourceRequirements
+		used      corev1.ResourceList
+		expected  corev1.ResourceRequirements
+		wantErr   bool
+	}{
+		{
+			name:      "nil budget",
+			resources: resources("", "", "1", "1Gi"),
+			expected:  resources("", "", "1", "1Gi"),
+		},
+		{
+			name:      "in budget",
+			budget:    &ResourceBudget{MaxPerMountPod: perPod},
+			resources: resources("1", "2Gi", "1", "1Gi"),
+			expected:  resources("1", "2Gi", "1", "1Gi"),
+		},
+		{
+			name:      "reject limit over budget",
+			budget:    &ResourceBudget{MaxPerMountPod: perPod},
+			resources: resources("5", "2Gi", "1", "1Gi"),
+			wantErr:   true,
+		},
+		{
+			name:      "reject unlimited",
+			budget:    &ResourceBudget{MaxPerMountPod: perPod},
+			resources: resources("1", "", "1", "1Gi"),
+			wantErr:   true,
+		},
+		{
+			name:      "clamp",
+			budget:    &ResourceBudget{Policy: ResourceBudgetPolicyClamp, MaxPerMountPod: perPod},
+			resources: resources("5", "", "3", "1Gi"),
+			expected:  resources("2", "4Gi", "2", "1Gi"),
+		},
+		{
+			name:      "clamp to remaining of total",
+			budget:    &ResourceBudget{Policy: ResourceBudgetPolicyClamp, MaxPerMountPod: perPod, Total: total},
+			resources: resources("2", "4Gi", "1", "1Gi"),
+			used:      corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("9Gi")},
+			expected:  resources("2", "1Gi", "1", "1Gi"),
+		},
+		{
+			name:      "total used up",
+			budget:    &ResourceBudget{Policy: ResourceBudgetPolicyClamp, Total: total},
+			resources: resources("1", "1Gi", "1", "1Gi"),
+			used:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10")},
+			wantErr:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.budget.Apply(tc.resources, tc.used)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			for _, list := range []struct{ got, expected corev1.ResourceList }{
+				{got.Limits, tc.expected.Limits},
+				{got.Requests, tc.expected.Requests},
+			} {
+				assert.Equal(t, len(list.expected), len(list.got), "got %v", list.got)
+				for name, q := range list.expected {
+					actual := list.got[name]
+					assert.Equal(t, 0, q.Cmp(actual), "%s: got %s, expected %s", name, actual.String(), q.String())
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_GetResourceBudget(t *testing.T) {
+	cfg := &Config{ResourceBudgets: []ResourceBudget{
+		{Namespaces: []string{"tenant-a"}, Policy: ResourceBudgetPolicyClamp},
+		{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "free"}}},
+		{},
+	}}
+	budget := cfg.GetResourceBudget(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Labels: map[string]string{"tier": "free"}}})
+	assert.Equal(t, &cfg.ResourceBudgets[0], budget)
+	budget = cfg.GetResourceBudget(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b", Labels: map[string]string{"tier": "free"}}})
+	assert.Equal(t, &cfg.ResourceBudgets[1], budget)
+	budget = cfg.GetResourceBudget(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-c"}})
+	assert.Equal(t, &cfg.ResourceBudgets[2], budget)
+	assert.Nil(t, cfg.GetResourceBudget(nil))
+
+	cfg.ResourceBudgets = []ResourceBudget{{Policy: "Deny"}}
+	assert.Error(t, cfg.Validate())
+	cfg.ResourceBudgets = []ResourceBudget{{Total: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}}}
+	assert.Error(t, cfg.Validate())
+}
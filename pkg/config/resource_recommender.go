@@ -0,0 +1,117 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ResourceRecommender records cpu and memory usage of mount pods in node reconciler,
+// and publishes the recommended requests/limits as mount pod annotations.
+type ResourceRecommender struct {
+	// enable recording usage and publishing recommendations
+	Enable bool `json:"enable,omitempty"`
+	// apply recommended resources when mount pod is recreated or smoothly upgraded
+	Apply bool `json:"apply,omitempty"`
+	// interval between two samples, the default is 1m
+	SampleInterval string `json:"sampleInterval,omitempty"`
+	// minimal samples before publishing recommendations, the default is 30
+	MinSamples int `json:"minSamples,omitempty"`
+	// max samples kept for each mount pod, the default is 1440
+	MaxSamples int `json:"maxSamples,omitempty"`
+	// headroom in percentage added to observed usage, the default is 20
+	MarginPercent *int `json:"marginPercent,omitempty"`
+}
+
+const (
+	defaultRecommenderSampleInterval = time.Minute
+	defaultRecommenderMinSamples     = 30
+	defaultRecommenderMaxSamples     = 1440
+	defaultRecommenderMarginPercent  = 20
+)
+
+func (r *ResourceRecommender) IsEnabled() bool {
+	return r != nil && r.Enable
+}
+
+func (r *ResourceRecommender) ShouldApply() bool {
+	return r.IsEnabled() && r.Apply
+}
+
+func (r *ResourceRecommender) GetSampleInterval() time.Duration {
+	if r == nil || r.SampleInterval == "" {
+		return defaultRecommenderSampleInterval
+	}
+	d, err := time.ParseDuration(r.SampleInterval)
+	if err != nil || d <= 0 {
+		return defaultRecommenderSampleInterval
+	}
+	return d
+}
+
+func (r *ResourceRecommender) GetMinSamples() int {
+	if r == nil || r.MinSamples <= 0 {
+		return defaultRecommenderMinSamples
+	}
+	return r.MinSamples
+}
+
+func (r *ResourceRecommender) GetMaxSamples() int {
+	if r == nil || r.MaxSamples <= 0 {
+		return defaultRecommenderMaxSamples
+	}
+	if r.MaxSamples < r.GetMinSamples() {
+		return r.GetMinSamples()
+	}
+	return r.MaxSamples
+}
+
+func (r *ResourceRecommender) GetMarginPercent() int {
+	if r == nil || r.MarginPercent == nil {
+		return defaultRecommenderMarginPercent
+	}
+	return *r.MarginPercent
+}
+
+func (r *ResourceRecommender) validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.SampleInterval != "" {
+		d, err := time.ParseDuration(r.SampleInterval)
+		if err != nil {
+			return fmt.Errorf("resourceRecommender.sampleInterval: invalid duration %q: %v", r.SampleInterval, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("resourceRecommender.sampleInterval: must be positive, got %q", r.SampleInterval)
+		}
+	}
+	if r.MinSamples < 0 {
+		return fmt.Errorf("resourceRecommender.minSamples: must not be negative, got %d", r.MinSamples)
+	}
+	if r.MaxSamples < 0 {
+		return fmt.Errorf("resourceRecommender.maxSamples: must not be negative, got %d", r.MaxSamples)
+	}
+	if r.MaxSamples > 0 && r.MaxSamples < r.GetMinSamples() {
+		return fmt.Errorf("resourceRecommender.maxSamples: must not be less than minSamples %d, got %d", r.GetMinSamples(), r.MaxSamples)
+	}
+	if r.MarginPercent != nil && *r.MarginPercent < 0 {
+		return fmt.Errorf("resourceRecommender.marginPercent: must not be negative, got %d", *r.MarginPercent)
+	}
+	return nil
+}
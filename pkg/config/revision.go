@@ -0,0 +1,276 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	k8s "github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+const (
+	// ConfigDataKey is the key of config in the global config map
+	ConfigDataKey = "config.yaml"
+	// configDiffKey is the key of diff from the previous revision in revision config maps
+	configDiffKey = "diff"
+)
+
+// ConfigRevisionHistoryLimit is the max number of revisions kept for the global config map,
+// history is disabled if it is not positive.
+var ConfigRevisionHistoryLimit = 10
+
+// ConfigRevision is a revision of the global config map, saved as config map <name>-rev-<revision>
+type ConfigRevision struct {
+	Revision int       `json:"revision"`
+	Name     string    `json:"name"`
+	Author   string    `json:"author"`
+	Time     time.Time `json:"time"`
+	Config   string    `json:"config"`
+	// Diff is the unified diff from the previous revision
+	Diff string `json:"diff"`
+}
+
+func configRevisionName(cmName string, revision int) string {
+	return fmt.Sprintf("%s-rev-%d", cmName, revision)
+}
+
+func parseConfigRevision(cm *corev1.ConfigMap) (ConfigRevision, bool) {
+	revision, err := strconv.Atoi(cm.Annotations[common.ConfigRevisionAnnotationKey])
+	if err != nil || revision <= 0 {
+		return ConfigRevision{}, false
+	}
+	r := ConfigRevision{
+		Revision: revision,
+		Name:     cm.Name,
+		Author:   cm.Annotations[common.ConfigAuthorAnnotationKey],
+		Time:     cm.CreationTimestamp.Time,
+		Config:   cm.Data[ConfigDataKey],
+		Diff:     cm.Data[configDiffKey],
+	}
+	if t, err := time.Parse(time.RFC3339, cm.Annotations[common.ConfigUpdateTimeAnnotationKey]); err == nil {
+		r.Time = t
+	}
+	return r, true
+}
+
+// ValidateConfigData checks if data can be used as config.yaml of the global config map
+func ValidateConfigData(data string) error {
+	cfg := &Config{}
+	if err := cfg.Unmarshal([]byte(data)); err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
+
+// DiffConfig returns the unified diff between two configs
+func DiffConfig(from, to, fromName, toName string) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+	return diff
+}
+
+// ListConfigRevisions lists revisions of the config map, the latest first
+func ListConfigRevisions(ctx context.Context, client *k8s.K8sClient, namespace, cmName string) ([]ConfigRevision, error) {
+	cms, err := client.ListConfigMap(ctx, namespace, &metav1.LabelSelector{
+		MatchLabels: map[string]string{common.ConfigRevisionOfLabelKey: cmName},
+	})
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]ConfigRevision, 0, len(cms))
+	for i := range cms {
+		if r, ok := parseConfigRevision(&cms[i]); ok {
+			revisions = append(revisions, r)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision > revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// GetConfigRevision gets a revision of the config map
+func GetConfigRevision(ctx context.Context, client *k8s.K8sClient, namespace, cmName string, revision int) (*ConfigRevision, error) {
+	cm, err := client.GetConfigMap(ctx, configRevisionName(cmName, revision), namespace)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, fmt.Errorf("revision %d of config map %s not found", revision, cmName)
+		}
+		return nil, err
+	}
+	r, ok := parseConfigRevision(cm)
+	if !ok {
+		return nil, fmt.Errorf("invalid revision config map %s", cm.Name)
+	}
+	return &r, nil
+}
+
+// DiffConfigRevisions returns the diff from revision from to revision to, to the current config if to is 0
+func DiffConfigRevisions(ctx context.Context, client *k8s.K8sClient, namespace, cmName string, from, to int) (string, error) {
+	fromRev, err := GetConfigRevision(ctx, client, namespace, cmName, from)
+	if err != nil {
+		return "", err
+	}
+	toName := "current"
+	var toConfig string
+	if to > 0 {
+		toRev, err := GetConfigRevision(ctx, client, namespace, cmName, to)
+		if err != nil {
+			return "", err
+		}
+		toName, toConfig = fmt.Sprintf("revision %d", to), toRev.Config
+	} else {
+		cm, err := client.GetConfigMap(ctx, cmName, namespace)
+		if err != nil {
+			return "", err
+		}
+		toConfig = cm.Data[ConfigDataKey]
+	}
+	return DiffConfig(fromRev.Config, toConfig, fmt.Sprintf("revision %d", from), toName), nil
+}
+
+// UpdateConfigMapWithRevision validates the config in cm, updates the config map and records it as a new revision.
+// Revisions beyond ConfigRevisionHistoryLimit are removed, the oldest first.
+func UpdateConfigMapWithRevision(ctx context.Context, client *k8s.K8sClient, cm *corev1.ConfigMap, author string) (*corev1.ConfigMap, error) {
+	if err := ValidateConfigData(cm.Data[ConfigDataKey]); err != nil {
+		return nil, err
+	}
+	if ConfigRevisionHistoryLimit <= 0 {
+		return client.CoreV1().ConfigMaps(cm.Namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	current, err := client.GetConfigMap(ctx, cm.Name, cm.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := ListConfigRevisions(ctx, client, cm.Namespace, cm.Name)
+	if err != nil {
+		return nil, err
+	}
+	latest := 0
+	if len(revisions) > 0 {
+		latest = revisions[0].Revision
+	}
+	// the config map is created by helm or edited by kubectl, record the current config so that it can be rolled back to.
+	// its author is unknown.
+	if len(revisions) == 0 || revisions[0].Config != current.Data[ConfigDataKey] {
+		prev := ""
+		if len(revisions) > 0 {
+			prev = revisions[0].Config
+		}
+		latest++
+		if err := createConfigRevision(ctx, client, current, latest, "", prev); err != nil {
+			return nil, err
+		}
+	}
+
+	cm = cm.DeepCopy()
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+	cm.Annotations[common.ConfigRevisionAnnotationKey] = strconv.Itoa(latest + 1)
+	cm.Annotations[common.ConfigAuthorAnnotationKey] = author
+	cm.Annotations[common.ConfigUpdateTimeAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
+	updated, err := client.CoreV1().ConfigMaps(cm.Namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if err := createConfigRevision(ctx, client, updated, latest+1, author, current.Data[ConfigDataKey]); err != nil {
+		return updated, err
+	}
+	pruneConfigRevisions(ctx, client, updated.Namespace, updated.Name)
+	return updated, nil
+}
+
+// RollbackConfigMap sets the config of the config map to the one in revision, which is recorded as a new revision
+func RollbackConfigMap(ctx context.Context, client *k8s.K8sClient, namespace, cmName string, revision int, author string) (*corev1.ConfigMap, error) {
+	r, err := GetConfigRevision(ctx, client, namespace, cmName, revision)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateConfigData(r.Config); err != nil {
+		return nil, fmt.Errorf("config of revision %d is invalid: %v", revision, err)
+	}
+	cm, err := client.GetConfigMap(ctx, cmName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[ConfigDataKey] = r.Config
+	return UpdateConfigMapWithRevision(ctx, client, cm, author)
+}
+
+func createConfigRevision(ctx context.Context, client *k8s.K8sClient, cm *corev1.ConfigMap, revision int, author, prev string) error {
+	data := cm.Data[ConfigDataKey]
+	rev := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configRevisionName(cm.Name, revision),
+			Namespace: cm.Namespace,
+			Labels:    map[string]string{common.ConfigRevisionOfLabelKey: cm.Name},
+			Annotations: map[string]string{
+				common.ConfigRevisionAnnotationKey:   strconv.Itoa(revision),
+				common.ConfigAuthorAnnotationKey:     author,
+				common.ConfigUpdateTimeAnnotationKey: time.Now().UTC().Format(time.RFC3339),
+			},
+			// revisions are removed along with the config map
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Name:       cm.Name,
+				UID:        cm.UID,
+			}},
+		},
+		Data: map[string]string{
+			ConfigDataKey: data,
+			configDiffKey: DiffConfig(prev, data, fmt.Sprintf("revision %d", revision-1), fmt.Sprintf("revision %d", revision)),
+		},
+	}
+	if err := client.CreateConfigMap(ctx, rev); err != nil {
+		return fmt.Errorf("create revision %d of config map %s error: %v", revision, cm.Name, err)
+	}
+	log.Info("config revision recorded", "configmap", cm.Name, "revision", revision, "author", author)
+	return nil
+}
+
+func pruneConfigRevisions(ctx context.Context, client *k8s.K8sClient, namespace, cmName string) {
+	revisions, err := ListConfigRevisions(ctx, client, namespace, cmName)
+	if err != nil {
+		log.Error(err, "list config revisions error", "configmap", cmName)
+		return
+	}
+	for i := ConfigRevisionHistoryLimit; i < len(revisions); i++ {
+		if err := client.DeleteConfigMap(ctx, revisions[i].Name, namespace); err != nil && !k8serrors.IsNotFound(err) {
+			log.Error(err, "delete config revision error", "name", revisions[i].Name)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	k8s "github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+func TestConfigRevision(t *testing.T) {
+	defer func(limit int) { ConfigRevisionHistoryLimit = limit }(ConfigRevisionHistoryLimit)
+	ConfigRevisionHistoryLimit = 3
+
+	ctx := context.TODO()
+	ns, name := "kube-system", "juicefs-csi-driver-config"
+	client := &k8s.K8sClient{Interface: fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Data:       map[string]string{ConfigDataKey: "enableNodeSelector: false\n"},
+	})}
+	update := func(data, author string) error {
+		cm, err := client.GetConfigMap(ctx, name, ns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cm.Data[ConfigDataKey] = data
+		_, err = UpdateConfigMapWithRevision(ctx, client, cm, author)
+		return err
+	}
+
+	// the initial config is recorded as revision 1
+	if err := update("enableNodeSelector: true\n", "alice"); err != nil {
+		t.Fatalf("UpdateConfigMapWithRevision() error = %v", err)
+	}
+	revisions, err := ListConfigRevisions(ctx, client, ns, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Revision != 2 || revisions[0].Author != "alice" || revisions[1].Author != "" {
+		t.Fatalf("revisions = %+v, want [2 by alice, 1]", revisions)
+	}
+	if !strings.Contains(revisions[0].Diff, "-enableNodeSelector: false") || !strings.Contains(revisions[0].Diff, "+enableNodeSelector: true") {
+		t.Errorf("diff = %s", revisions[0].Diff)
+	}
+	cm, _ := client.GetConfigMap(ctx, name, ns)
+	if cm.Annotations[common.ConfigRevisionAnnotationKey] != "2" || cm.Annotations[common.ConfigAuthorAnnotationKey] != "alice" {
+		t.Errorf("annotations = %v", cm.Annotations)
+	}
+
+	// invalid config is rejected
+	if err := update("mountPodPatch:\n- cacheDirs:\n  - type: Unknown\n", "bob"); err == nil {
+		t.Errorf("UpdateConfigMapWithRevision() expect error for invalid config")
+	}
+
+	// changes out of history are recorded before the update
+	cm.Data[ConfigDataKey] = "enableNodeSelector: false\n"
+	if err := client.UpdateConfigMap(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	if err := update("enableNodeSelector: true\nmountPodPatch:\n- ceMountImage: juicedata/mount:ce-v1.2.0\n", "bob"); err != nil {
+		t.Fatalf("UpdateConfigMapWithRevision() error = %v", err)
+	}
+	revisions, _ = ListConfigRevisions(ctx, client, ns, name)
+	if len(revisions) != 3 || revisions[0].Revision != 4 || revisions[1].Revision != 3 || revisions[1].Author != "" {
+		t.Fatalf("revisions = %+v, want [4 3 2] after pruned", revisions)
+	}
+
+	diff, err := DiffConfigRevisions(ctx, client, ns, name, 2, 0)
+	if err != nil || !strings.Contains(diff, "+- ceMountImage: juicedata/mount:ce-v1.2.0") {
+		t.Errorf("DiffConfigRevisions() = %s, %v", diff, err)
+	}
+
+	cm, err = RollbackConfigMap(ctx, client, ns, name, 2, "carol")
+	if err != nil {
+		t.Fatalf("RollbackConfigMap() error = %v", err)
+	}
+	if cm.Data[ConfigDataKey] != "enableNodeSelector: true\n" || cm.Annotations[common.ConfigRevisionAnnotationKey] != "5" {
+		t.Errorf("config map = %v, %v", cm.Data, cm.Annotations)
+	}
+	if _, err := RollbackConfigMap(ctx, client, ns, name, 1, "carol"); err == nil {
+		t.Errorf("RollbackConfigMap() expect error for pruned revision")
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/encryption"
+)
+
+// SecretEncryption encrypts credentials in secrets generated for mount pods with envelope encryption.
+// Each key file contains a base64 encoded 32 bytes key, the first key encrypts new secrets and the others are
+// only used to decrypt, so that secrets encrypted by them are wrapped again with the first key.
+type SecretEncryption struct {
+	KeyFiles []string `json:"keyFiles,omitempty"`
+}
+
+func (s *SecretEncryption) validate() error {
+	if s == nil {
+		return nil
+	}
+	if len(s.KeyFiles) == 0 {
+		return fmt.Errorf("secretEncryption.keyFiles should not be empty")
+	}
+	for _, f := range s.KeyFiles {
+		if !filepath.IsAbs(f) {
+			return fmt.Errorf("secretEncryption.keyFiles: %s is not an absolute path", f)
+		}
+	}
+	return nil
+}
+
+// SecretKeyring returns the keyring loaded from key files, nil if secret encryption is not configured
+func SecretKeyring() (*encryption.Keyring, error) {
+	s := GlobalConfig.SecretEncryption
+	if s == nil {
+		return nil, nil
+	}
+	return encryption.LoadKeyring(s.KeyFiles...)
+}
+
+// DecryptSecretData returns data of the secret, decrypted if it is encrypted
+func DecryptSecretData(secret *corev1.Secret) (map[string]string, error) {
+	data := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	if !encryption.IsEncrypted(data) {
+		return data, nil
+	}
+	keyring, err := SecretKeyring()
+	if err != nil {
+		return nil, err
+	}
+	if keyring == nil {
+		return nil, fmt.Errorf("secret %s is encrypted, but secretEncryption is not configured", secret.Name)
+	}
+	return keyring.Decrypt(data)
+}
+
+var shellNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SecretFilesDir is the directory in mount containers where keys of encrypted secrets are written to if they are
+// not shell variable names, e.g. session-token, because shells cannot export them
+const SecretFilesDir = "/root/.juicefs-secrets"
+
+// DecryptSecretDir decrypts the encrypted secret mounted in dir with key files, and returns the script of SecretEnvFile.
+// It runs in mount containers, so that credentials are only decrypted in memory of the mount container.
+func DecryptSecretDir(dir string, keyFiles []string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	data := make(map[string]string, len(entries))
+	for _, e := range entries {
+		// skip ..data and the timestamped directory of the secret volume
+		if strings.HasPrefix(e.Name(), "..") || e.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return "", err
+		}
+		data[e.Name()] = string(content)
+	}
+	if !encryption.IsEncrypted(data) {
+		return "", fmt.Errorf("secret in %s is not encrypted", dir)
+	}
+	keyring, err := encryption.LoadKeyring(keyFiles...)
+	if err != nil {
+		return "", err
+	}
+	if data, err = keyring.Decrypt(data); err != nil {
+		return "", err
+	}
+	return SecretEnvFile(data)
+}
+
+// SecretEnvFile generates the script evaluated by mount containers whose secret is encrypted, it exports
+// credentials and envs, and writes the rsa key, init config and keys which cannot be exported to files
+func SecretEnvFile(data map[string]string) (string, error) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := []string{}
+	for _, k := range keys {
+		v := data[k]
+		switch {
+		case k == "jfsSettings" || k == "check_mount.sh":
+			// not used by mount containers
+		case k == "encrypt_rsa_key":
+			lines = append(lines, fmt.Sprintf("(umask 077 && mkdir -p /root/.rsa && printf '%%s' %s > /root/.rsa/rsa-key.pem)", shellQuote(v)))
+		case k == "initconfig":
+			setting := &JfsSetting{}
+			if err := setting.Load(data["jfsSettings"]); err != nil {
+				return "", fmt.Errorf("load jfsSettings: %v", err)
+			}
+			confPath := filepath.Join(ROConfPath, setting.Name+".conf")
+			lines = append(lines, fmt.Sprintf("(umask 077 && mkdir -p %s && printf '%%s' %s > %s)", ROConfPath, shellQuote(v), shellQuote(confPath)))
+		case !shellNameRegexp.MatchString(k):
+			filePath := filepath.Join(SecretFilesDir, filepath.Base(k))
+			lines = append(lines, fmt.Sprintf("(umask 077 && mkdir -p %s && printf '%%s' %s > %s)", SecretFilesDir, shellQuote(v), shellQuote(filePath)))
+		default:
+			lines = append(lines, fmt.Sprintf("export %s=%s", k, shellQuote(v)))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// shellQuote quotes s for POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
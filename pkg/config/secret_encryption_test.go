@@ -0,0 +1,155 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/encryption"
+)
+
+func TestSecretEncryption_validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		s       *SecretEncryption
+		wantErr bool
+	}{
+		{name: "nil", s: nil},
+		{name: "key files", s: &SecretEncryption{KeyFiles: []string{"/etc/juicefs-csi/keys/key2", "/etc/juicefs-csi/keys/key1"}}},
+		{name: "empty", s: &SecretEncryption{}, wantErr: true},
+		{name: "relative path", s: &SecretEncryption{KeyFiles: []string{"key1"}}, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantErr, tc.s.validate() != nil)
+		})
+	}
+}
+
+func TestDecryptSecretData(t *testing.T) {
+	defer GlobalConfig.Reset()
+	keyFile := t.TempDir() + "/key"
+	if err := os.WriteFile(keyFile, []byte("MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE=\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	GlobalConfig.SecretEncryption = &SecretEncryption{KeyFiles: []string{keyFile}}
+	keyring, err := SecretKeyring()
+	if !assert.NoError(t, err) {
+		return
+	}
+	data := map[string]string{"metaurl": "redis://:pass@127.0.0.1:6379/0", "session-token": "t"}
+	encrypted, err := keyring.Encrypt(data, "metaurl")
+	if !assert.NoError(t, err) {
+		return
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "juicefs-test-secret"}, Data: map[string][]byte{}}
+	for k, v := range encrypted {
+		secret.Data[k] = []byte(v)
+	}
+	got, err := DecryptSecretData(secret)
+	assert.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	GlobalConfig.SecretEncryption = nil
+	_, err = DecryptSecretData(secret)
+	assert.Error(t, err, "encrypted secret can not be decrypted without keys")
+	got, err = DecryptSecretData(&corev1.Secret{Data: map[string][]byte{"token": []byte("t")}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"token": "t"}, got)
+}
+
+func TestMountSecretName_Encrypted(t *testing.T) {
+	defer GlobalConfig.Reset()
+	assert.Equal(t, "juicefs-vol-secret", MountSecretName("vol", false))
+	GlobalConfig.SecretEncryption = &SecretEncryption{KeyFiles: []string{"/etc/juicefs-secret-encryption/key1"}}
+	assert.Equal(t, "juicefs-vol-enc-secret", MountSecretName("vol", false))
+	assert.Equal(t, "juicefs-vol-ro-enc-secret", MountSecretName("vol", true))
+	assert.True(t, IsEncryptedSecretName(MountSecretName("vol", false)))
+	assert.Equal(t, []string{"juicefs-vol-secret", "juicefs-vol-enc-secret", "juicefs-vol-ro-secret", "juicefs-vol-ro-enc-secret"}, MountSecretNames("vol"))
+
+	// mount pods refer to the secret they are created with
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{common.PodUniqueIdLabelKey: "vol"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{}}},
+	}
+	assert.Equal(t, "juicefs-vol-secret", MountPodSecretName(pod))
+	pod.Spec.Containers[0].Env = []corev1.EnvVar{{Name: common.JfsEncryptedSecretDirEnv, Value: "/etc/juicefs-encrypted-secret"}}
+	GlobalConfig.SecretEncryption = nil
+	assert.Equal(t, "juicefs-vol-enc-secret", MountPodSecretName(pod))
+}
+
+func TestSecretEnvFile(t *testing.T) {
+	got, err := SecretEnvFile(map[string]string{
+		"jfsSettings":     `{"name":"test"}`,
+		"metaurl":         "redis://:it's@127.0.0.1:6379/0",
+		"encrypt_rsa_key": "rsa",
+		"initconfig":      "conf",
+		"session-token":   "t",
+		"check_mount.sh":  "echo",
+	})
+	assert.NoError(t, err)
+	want := `(umask 077 && mkdir -p /root/.rsa && printf '%s' 'rsa' > /root/.rsa/rsa-key.pem)
+(umask 077 && mkdir -p /etc/juicefs && printf '%s' 'conf' > '/etc/juicefs/test.conf')
+export metaurl='redis://:it'\''s@127.0.0.1:6379/0'
+(umask 077 && mkdir -p /root/.juicefs-secrets && printf '%s' 't' > '/root/.juicefs-secrets/session-token')
+`
+	assert.Equal(t, want, got)
+}
+
+func TestDecryptSecretDir(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE="), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := encryption.LoadKeyring(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := keyring.Encrypt(map[string]string{"metaurl": "redis://:pass@127.0.0.1:6379/0", "check_mount.sh": "echo"}, "metaurl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// files of the secret volume are symlinks to ..data
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "..2026_10_19")
+	assert.NoError(t, os.Mkdir(dataDir, 0755))
+	assert.NoError(t, os.Symlink(dataDir, filepath.Join(dir, "..data")))
+	for k, v := range encrypted {
+		assert.NoError(t, os.WriteFile(filepath.Join(dataDir, k), []byte(v), 0400))
+		assert.NoError(t, os.Symlink(filepath.Join("..data", k), filepath.Join(dir, k)))
+	}
+
+	got, err := DecryptSecretDir(dir, []string{keyFile})
+	assert.NoError(t, err)
+	assert.Equal(t, "export metaurl='redis://:pass@127.0.0.1:6379/0'\n", got)
+
+	otherKey := filepath.Join(t.TempDir(), "other")
+	if err := os.WriteFile(otherKey, []byte("MTExMTExMTExMTExMTExMTExMTExMTExMTExMTExMTE="), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = DecryptSecretDir(dir, []string{otherKey})
+	assert.ErrorContains(t, err, "not found")
+	_, err = DecryptSecretDir(t.TempDir(), []string{keyFile})
+	assert.ErrorContains(t, err, "is not encrypted")
+}
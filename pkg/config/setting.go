@@ -33,12 +33,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/klog/v2"
 
 	"github.com/juicedata/juicefs-csi-driver/pkg/common"
 	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
 	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/credential"
 	"github.com/juicedata/juicefs-csi-driver/pkg/util/security"
 )
 
@@ -88,25 +90,35 @@ type JfsSetting struct {
 	MountPath  string   // mountPath of mount pod or process mount
 	TargetPath string   `json:"-"` // which bind to container path
 	Options    []string // mount options
-	FormatCmd  string   // format or auth
-	SubPath    string   // subPath which is to be created or deleted
-	SecretName string   // secret with JuiceFS volume credentials
+	// the client runs read-only and is dedicated to the volume, for read-only volumes
+	ReadOnly   bool   `json:"read_only,omitempty"`
+	FormatCmd  string // format or auth
+	SubPath    string // subPath which is to be created or deleted
+	SecretName string // secret with JuiceFS volume credentials
+	// external provider of credentials, whose values are not put in secret
+	Credential *credential.Ref `json:"credential,omitempty"`
+	// the mount pod runs without privileged, only with CAP_SYS_ADMIN
+	NonPrivileged bool `json:"non_privileged,omitempty"`
 
 	Attr *PodAttr
 
 	PV   *corev1.PersistentVolume      `json:"-"`
 	PVC  *corev1.PersistentVolumeClaim `json:"-"`
 	Node *corev1.Node                  `json:"-"`
-	SC   *storagev1.StorageClass       `json:"-"`
+	// namespace of PVC, used to match namespaceSelector of mountPodPatch
+	Namespace *corev1.Namespace       `json:"-"`
+	SC        *storagev1.StorageClass `json:"-"`
 
 	AppPod         *corev1.Pod `json:"-"`
 	MountShareMode string      `json:"-"`
+	// warmup after mount in sidecar, it is per volume and does not affect the client
+	Warmup *WarmupSetting `json:"-"`
 }
 
 func (s *JfsSetting) String() string {
-	setting := *s
+	setting := s.withoutProvidedCredentials()
 	setting.MountPath = filepath.Join(PodMountBase, setting.UniqueId)
-	if setting.MountShareMode != "" {
+	if setting.SharesClient() {
 		setting.VolumeId = setting.UniqueId
 		setting.SubPath = ""
 	}
@@ -114,6 +126,30 @@ func (s *JfsSetting) String() string {
 	return string(data)
 }
 
+// withoutProvidedCredentials returns a copy of setting whose credentials fetched from the external provider are cleared,
+// they are fetched again by mount containers and should not be put in secret or affect hash.
+func (s *JfsSetting) withoutProvidedCredentials() JfsSetting {
+	setting := *s
+	ref := s.Credential
+	if ref.Provides("metaurl") {
+		setting.MetaUrl = ""
+		setting.Source = setting.Name
+	}
+	if ref.Provides("secret-key") {
+		setting.SecretKey = ""
+	}
+	if ref.Provides("secret-key2") {
+		setting.SecretKey2 = ""
+	}
+	if ref.Provides("token") {
+		setting.Token = ""
+	}
+	if ref.Provides("passphrase") {
+		setting.Passphrase = ""
+	}
+	return setting
+}
+
 func maskIfNeeded(src, cmp string) string {
 	if src == "" {
 		return ""
@@ -197,6 +233,7 @@ type PodAttr struct {
 	Env                           []corev1.EnvVar       `json:"env,omitempty"`
 	CacheDirs                     []MountPatchCacheDir  `json:"cacheDirs,omitempty"`
 	InitContainers                []corev1.Container    `json:"initContainers,omitempty"`
+	PodPatches                    []PodPatch            `json:"podPatches,omitempty"`
 	HostnameKey                   string                `json:"-"`
 
 	// inherit from csi
@@ -251,12 +288,20 @@ type CacheEphemeral struct {
 // pv: the PersistentVolume
 // pvc: the PersistentVolumeClaim
 func ParseSetting(ctx context.Context, secrets, volCtx map[string]string, options []string, volumeId, uniqueId, uuid string, pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim) (*JfsSetting, error) {
-	return ParseSettingWithNode(ctx, secrets, volCtx, options, volumeId, uniqueId, uuid, pv, pvc, nil)
+	return ParseSettingWithNode(ctx, secrets, volCtx, options, volumeId, uniqueId, uuid, pv, pvc, nil, nil)
 }
 
-func ParseSettingWithNode(ctx context.Context, secrets, volCtx map[string]string, options []string, volumeId, uniqueId, uuid string, pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim, node *corev1.Node) (*JfsSetting, error) {
+// node: the node of mount pod, used to match nodeSelector of mountPodPatch
+// namespace: the namespace of pvc, used to match namespaceSelector of mountPodPatch. if nil, match by its name only
+func ParseSettingWithNode(ctx context.Context, secrets, volCtx map[string]string, options []string, volumeId, uniqueId, uuid string, pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim, node *corev1.Node, namespace *corev1.Namespace) (*JfsSetting, error) {
+	return ParseSettingWithAppPod(ctx, secrets, volCtx, options, volumeId, uniqueId, uuid, pv, pvc, node, namespace, nil)
+}
+
+// appPod: the app pod which the sidecar is injected into, used to match appPodSelector and resourcePercentages of mountPodPatch
+func ParseSettingWithAppPod(ctx context.Context, secrets, volCtx map[string]string, options []string, volumeId, uniqueId, uuid string, pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim, node *corev1.Node, namespace *corev1.Namespace, appPod *corev1.Pod) (*JfsSetting, error) {
 	jfsSetting := JfsSetting{
 		Options: []string{},
+		AppPod:  appPod,
 	}
 	if options != nil {
 		jfsSetting.Options = options
@@ -264,6 +309,10 @@ func ParseSettingWithNode(ctx context.Context, secrets, volCtx map[string]string
 	if secrets == nil {
 		return &jfsSetting, nil
 	}
+	secrets, credentialRef, err := ResolveCredentials(ctx, secrets)
+	if err != nil {
+		return nil, err
+	}
 
 	secretStr, err := json.Marshal(secrets)
 	if err != nil {
@@ -286,9 +335,10 @@ func ParseSettingWithNode(ctx context.Context, secrets, volCtx map[string]string
 	jfsSetting.PV = pv
 	jfsSetting.PVC = pvc
 	jfsSetting.Node = node
+	jfsSetting.Namespace = namespace
 	jfsSetting.VolumeId = volumeId
 	jfsSetting.UniqueId = uniqueId
-	jfsSetting.SecretName = fmt.Sprintf("juicefs-%s-secret", jfsSetting.UniqueId)
+	jfsSetting.Credential = credentialRef
 
 	jfsSetting.UsePod = !ByProcess
 	jfsSetting.Source = jfsSetting.Name
@@ -356,6 +406,7 @@ func ParseSettingWithNode(ctx context.Context, secrets, volCtx map[string]string
 			}
 			jfsSetting.HostPath = hostPaths
 		}
+		jfsSetting.NonPrivileged = volCtx[common.MountPodPrivilegedKey] == common.False
 	}
 
 	if err := GenPodAttrWithCfg(&jfsSetting, volCtx, false); err != nil {
@@ -364,6 +415,9 @@ func ParseSettingWithNode(ctx context.Context, secrets, volCtx map[string]string
 	if err := genAndValidOptions(&jfsSetting); err != nil {
 		return nil, fmt.Errorf("genAndValidOptions error: %v", err)
 	}
+	jfsSetting.ReadOnly = isReadOnly(jfsSetting.Options, pv)
+	jfsSetting.genReadOnlyOptions()
+	jfsSetting.SecretName = MountSecretName(jfsSetting.UniqueId, jfsSetting.ReadOnly)
 	if err := GenCacheDirs(&jfsSetting, volCtx); err != nil {
 		return nil, fmt.Errorf("genCacheDirs error: %v", err)
 	}
@@ -556,6 +610,87 @@ func GenCacheDirs(jfsSetting *JfsSetting, volCtx map[string]string) error {
 	return nil
 }
 
+// isReadOnly checks if the volume is read-only by mount options, or by the PV which is marked read-only
+func isReadOnly(options []string, pv *corev1.PersistentVolume) bool {
+	if util.ContainsString(options, "ro") || util.ContainsString(options, "read-only") {
+		return true
+	}
+	if pv == nil {
+		return false
+	}
+	if pv.Spec.CSI != nil && pv.Spec.CSI.ReadOnly {
+		return true
+	}
+	return len(pv.Spec.AccessModes) == 1 && pv.Spec.AccessModes[0] == corev1.ReadOnlyMany
+}
+
+// genReadOnlyOptions makes the client itself read-only rather than only its mount point, so that writes are
+// rejected by the client even if the mount point is bound writable. The client of community edition runs
+// with --read-only, and that of enterprise edition is mounted with ro.
+func (s *JfsSetting) genReadOnlyOptions() {
+	if !s.ReadOnly {
+		return
+	}
+	s.Options = append(util.StripReadonlyOption(s.Options), "ro")
+	if s.IsCe {
+		s.Options = append(s.Options, "read-only")
+	}
+}
+
+// SharesClient checks if the client is shared by volumes of the same StorageClass or file system in share mode,
+// read-only clients are dedicated to the volume even in share mode
+func (s *JfsSetting) SharesClient() bool {
+	return s.MountShareMode != "" && !s.ReadOnly
+}
+
+// MountSecretName returns the name of the secret of mount pods of uniqueId, read-only clients have their own secret.
+// Encrypted secrets are named differently, so that mount pods created before secretEncryption is configured keep
+// referring to their plaintext secret.
+func MountSecretName(uniqueId string, readOnly bool) string {
+	return mountSecretName(uniqueId, readOnly, GlobalConfig.SecretEncryption != nil)
+}
+
+// MountPodSecretName returns the name of the secret the mount pod refers to
+func MountPodSecretName(pod *corev1.Pod) string {
+	encrypted := false
+	if len(pod.Spec.Containers) != 0 {
+		for _, env := range pod.Spec.Containers[0].Env {
+			if env.Name == common.JfsEncryptedSecretDirEnv {
+				encrypted = true
+			}
+		}
+	}
+	return mountSecretName(pod.Labels[common.PodUniqueIdLabelKey], pod.Annotations[common.JuicefsReadOnlyKey] == common.True, encrypted)
+}
+
+// IsEncryptedSecretName returns true if the secret of mount pods is encrypted
+func IsEncryptedSecretName(name string) bool {
+	return strings.HasSuffix(name, "-enc-secret")
+}
+
+// MountSecretNames returns names of all secrets mount pods of uniqueId may refer to, read-only or not,
+// encrypted or not
+func MountSecretNames(uniqueId string) []string {
+	var names []string
+	for _, readOnly := range []bool{false, true} {
+		for _, encrypted := range []bool{false, true} {
+			names = append(names, mountSecretName(uniqueId, readOnly, encrypted))
+		}
+	}
+	return names
+}
+
+func mountSecretName(uniqueId string, readOnly, encrypted bool) string {
+	name := "juicefs-" + uniqueId
+	if readOnly {
+		name += "-ro"
+	}
+	if encrypted {
+		name += "-enc"
+	}
+	return name + "-secret"
+}
+
 func genAndValidOptions(JfsSetting *JfsSetting) error {
 	mountOptions := []string{}
 	for _, option := range JfsSetting.Options {
@@ -613,7 +748,7 @@ func GenPodAttrWithCfg(setting *JfsSetting, volCtx map[string]string, replaceTem
 			Tolerations:          util.CopySlice(CSIPod.Spec.Tolerations),
 			PreemptionPolicy:     CSIPod.Spec.PreemptionPolicy,
 			ServiceAccountName:   CSIPod.Spec.ServiceAccountName,
-			Resources:            getDefaultResource(),
+			Resources:            GetDefaultResource(),
 			Labels:               make(map[string]string),
 			Annotations:          make(map[string]string),
 		}
@@ -640,7 +775,8 @@ func GenPodAttrWithCfg(setting *JfsSetting, volCtx map[string]string, replaceTem
 		memoryLimit := volCtx[common.MountPodMemLimitKey]
 		cpuRequest := volCtx[common.MountPodCpuRequestKey]
 		memoryRequest := volCtx[common.MountPodMemRequestKey]
-		attr.Resources, err = ParsePodResources(cpuLimit, memoryLimit, cpuRequest, memoryRequest, getDefaultResource())
+		budget := GlobalConfig.GetResourceBudget(setting.GetNamespace())
+		attr.Resources, err = ParsePodResources(cpuLimit, memoryLimit, cpuRequest, memoryRequest, GetDefaultResource(), budget)
 		if err != nil {
 			log.Error(err, "Parse resource error")
 			return err
@@ -666,8 +802,29 @@ func GenPodAttrWithCfg(setting *JfsSetting, volCtx map[string]string, replaceTem
 	}
 	setting.Attr = attr
 	// apply config patch
-	applyConfigPatch(setting, replaceTemplate)
+	if err := applyConfigPatch(setting, replaceTemplate); err != nil {
+		return err
+	}
+
+	return applyResourceBudget(setting)
+}
 
+// applyResourceBudget checks resources of mount pod after all config is applied against the budget of the pvc namespace,
+// without the usage of other mount pods, which is checked when mount pod is created
+func applyResourceBudget(setting *JfsSetting) error {
+	if setting.PVC == nil || setting.Attr == nil {
+		return nil
+	}
+	namespace := setting.GetNamespace()
+	budget := GlobalConfig.GetResourceBudget(namespace)
+	if budget == nil {
+		return nil
+	}
+	resources, err := budget.Apply(setting.Attr.Resources, nil)
+	if err != nil {
+		return fmt.Errorf("mount pod resources exceed the budget of namespace %s: %v", namespace.Name, err)
+	}
+	setting.Attr.Resources = resources
 	return nil
 }
 
@@ -685,7 +842,7 @@ func GenSettingAttrWithMountPod(ctx context.Context, client *k8sclient.K8sClient
 	// maybe has multiple pv, we need to get the first one
 	if StorageClassShareMount || FSShareMount {
 		for _, target := range mountPod.Annotations {
-			if v := getPVNameFromTarget(target); v != "" {
+			if v := GetPVNameFromTarget(target); v != "" {
 				pvName = v
 				break
 			}
@@ -717,7 +874,8 @@ func GenSettingAttrWithMountPod(ctx context.Context, client *k8sclient.K8sClient
 	}
 
 	// get settings from secret
-	secretName := fmt.Sprintf("juicefs-%s-secret", mountPod.Labels[common.PodUniqueIdLabelKey])
+	readOnly := mountPod.Annotations[common.JuicefsReadOnlyKey] == common.True
+	secretName := MountPodSecretName(mountPod)
 	secret, err := client.GetSecret(ctx, secretName, mountPod.Namespace)
 	if err != nil {
 		log.Error(err, "Get secret error", "secret", secretName)
@@ -729,10 +887,19 @@ func GenSettingAttrWithMountPod(ctx context.Context, client *k8sclient.K8sClient
 			log.V(1).Info("Get node error, skip node-aware mount pod patch", "node", mountPod.Spec.NodeName, "error", err)
 		}
 	}
-	setting, err := RevertSettingWithNode(mountPod, pvc, pv, secret, custSecret, node)
+	var namespace *corev1.Namespace
+	if pvc != nil {
+		namespace, err = client.GetNamespaceByCache(ctx, pvc.Namespace)
+		if err != nil {
+			log.V(1).Info("Get namespace error, match namespaceSelector of mount pod patch and resource budget by name", "namespace", pvc.Namespace, "error", err)
+			namespace = nil
+		}
+	}
+	setting, err := RevertSettingWithNamespace(mountPod, pvc, pv, secret, custSecret, node, namespace)
 	if err != nil {
 		return nil, err
 	}
+	setting.ReadOnly = setting.ReadOnly || readOnly
 	if err = setting.ReNew(mountPod, pvc, pv, custSecret); err != nil {
 		return nil, err
 	}
@@ -763,6 +930,11 @@ func RevertSetting(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeClaim, pv *
 }
 
 func RevertSettingWithNode(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeClaim, pv *corev1.PersistentVolume, pvcSecret, custSecret *corev1.Secret, node *corev1.Node) (*JfsSetting, error) {
+	return RevertSettingWithNamespace(mountPod, pvc, pv, pvcSecret, custSecret, node, nil)
+}
+
+// namespace: the namespace of pvc, used to match namespaceSelector of mountPodPatch and resource budgets. if nil, match by its name only
+func RevertSettingWithNamespace(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeClaim, pv *corev1.PersistentVolume, pvcSecret, custSecret *corev1.Secret, node *corev1.Node, namespace *corev1.Namespace) (*JfsSetting, error) {
 	var (
 		options []string
 		subPath string
@@ -770,9 +942,9 @@ func RevertSettingWithNode(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeCla
 	)
 	// get settings from pv pvcSecret
 	if pvcSecret != nil {
-		secretsMap := make(map[string]string)
-		for k, v := range pvcSecret.Data {
-			secretsMap[k] = string(v[:])
+		secretsMap, err := DecryptSecretData(pvcSecret)
+		if err != nil {
+			return nil, err
 		}
 		setting := &JfsSetting{}
 		if secretsMap["jfsSettings"] != "" {
@@ -780,6 +952,7 @@ func RevertSettingWithNode(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeCla
 				return nil, err
 			}
 			setting.Node = node
+			setting.Namespace = namespace
 			setting.JuiceFSSecret = pvcSecret
 			return setting, nil
 		}
@@ -801,6 +974,7 @@ func RevertSettingWithNode(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeCla
 			pv,
 			pvc,
 			node,
+			namespace,
 		)
 		if err != nil {
 			return nil, err
@@ -845,7 +1019,13 @@ func RevertSettingWithNode(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeCla
 			memoryLimit := pvc.Annotations[common.MountPodMemLimitKey]
 			cpuRequest := pvc.Annotations[common.MountPodCpuRequestKey]
 			memoryRequest := pvc.Annotations[common.MountPodMemRequestKey]
-			resources, err := ParsePodResources(cpuLimit, memoryLimit, cpuRequest, memoryRequest, attr.Resources)
+			budgetNamespace := namespace
+			if budgetNamespace == nil {
+				// namespace is not got, budget is matched by its name
+				budgetNamespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pvc.Namespace}}
+			}
+			budget := GlobalConfig.GetResourceBudget(budgetNamespace)
+			resources, err := ParsePodResources(cpuLimit, memoryLimit, cpuRequest, memoryRequest, attr.Resources, budget)
 			if err != nil {
 				return nil, fmt.Errorf("parse pvc resources error: %v", err)
 			}
@@ -859,6 +1039,7 @@ func RevertSettingWithNode(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeCla
 		PV:        pv,
 		PVC:       pvc,
 		Node:      node,
+		Namespace: namespace,
 		Name:      mountPod.Annotations[common.JuiceFSUUID],
 		VolumeId:  mountPod.Annotations[common.UniqueId],
 		Options:   options,
@@ -878,6 +1059,20 @@ func RevertSettingWithNode(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeCla
 	return setting, nil
 }
 
+// GetNamespace returns the namespace of pvc or app pod, which has only the name if it's not got from apiserver
+func (s *JfsSetting) GetNamespace() *corev1.Namespace {
+	if s.Namespace != nil {
+		return s.Namespace
+	}
+	if s.PVC != nil {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: s.PVC.Namespace}}
+	}
+	if s.AppPod != nil {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: s.AppPod.Namespace}}
+	}
+	return nil
+}
+
 // ReNew update setting with new pod, pvc, pv and customs secret
 func (s *JfsSetting) ReNew(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeClaim, pv *corev1.PersistentVolume, custSecret *corev1.Secret) error {
 	if s == nil {
@@ -888,6 +1083,12 @@ func (s *JfsSetting) ReNew(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeCla
 		for k, v := range custSecret.Data {
 			secretsMap[k] = string(v[:])
 		}
+		if resolved, credentialRef, err := ResolveCredentials(context.TODO(), secretsMap); err != nil {
+			log.Error(err, "resolve credentials error")
+		} else {
+			secretsMap = resolved
+			s.Credential = credentialRef
+		}
 		KeysCompatible(secretsMap)
 		custSetting := &JfsSetting{}
 		secretStr, err := json.Marshal(secretsMap)
@@ -951,6 +1152,7 @@ func (s *JfsSetting) ReNew(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeCla
 	s.PVC = pvc
 	if pv != nil {
 		s.Options = pv.Spec.MountOptions
+		s.genReadOnlyOptions()
 	}
 	if s.Attr.Labels == nil {
 		s.Attr.Labels = make(map[string]string)
@@ -963,7 +1165,12 @@ func (s *JfsSetting) ReNew(mountPod *corev1.Pod, pvc *corev1.PersistentVolumeCla
 		return err
 	}
 	// apply config without replace template to calculate hash
-	applyConfigPatch(s, false)
+	if err := applyConfigPatch(s, false); err != nil {
+		return err
+	}
+	if err := applyResourceBudget(s); err != nil {
+		return err
+	}
 	s.ClientConfPath = DefaultClientConfPath
 	if err := GenCacheDirs(s, nil); err != nil {
 		return err
@@ -1078,6 +1285,33 @@ func (s *JfsSetting) genFormatCmd(secrets map[string]string) error {
 	return nil
 }
 
+// ResolveCredentials returns a copy of secrets with credentials fetched from the external provider set in secrets,
+// which take precedence over the ones in secrets.
+func ResolveCredentials(ctx context.Context, secrets map[string]string) (map[string]string, *credential.Ref, error) {
+	ref, err := credential.ParseRef(secrets)
+	if err != nil || ref == nil {
+		return secrets, nil, err
+	}
+	if err := GlobalConfig.CredentialProviders.allows(ref); err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "credential provider %s is not allowed: %v", ref.Provider, err)
+	}
+	values, err := credential.Resolve(ctx, ref)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Unavailable, "fetch credentials from %s provider error: %v", ref.Provider, err)
+	}
+	resolved := make(map[string]string, len(secrets)+len(values))
+	for k, v := range secrets {
+		resolved[k] = v
+	}
+	for k, v := range values {
+		// drop the compatible key, e.g. secretkey for secret-key
+		delete(resolved, credential.EnvName(k))
+		resolved[k] = v
+	}
+	log.V(1).Info("credentials are fetched from provider", "provider", ref.Provider, "keys", ref.Keys)
+	return resolved, ref, nil
+}
+
 func parseYamlOrJson(source string, dst interface{}) error {
 	if err := yaml.Unmarshal([]byte(source), &dst); err != nil {
 		if err := json.Unmarshal([]byte(source), &dst); err != nil {
@@ -1092,7 +1326,8 @@ func ParseYamlOrJson(source string, dst interface{}) error {
 	return parseYamlOrJson(source, dst)
 }
 
-func ParsePodResources(cpuLimit, memoryLimit, cpuRequest, memoryRequest string, defaultResources corev1.ResourceRequirements) (corev1.ResourceRequirements, error) {
+// ParsePodResources parses resources of mount pod with defaults, and applies the resource budget if not nil
+func ParsePodResources(cpuLimit, memoryLimit, cpuRequest, memoryRequest string, defaultResources corev1.ResourceRequirements, budget *ResourceBudget) (corev1.ResourceRequirements, error) {
 	podLimit := map[corev1.ResourceName]resource.Quantity{}
 	podRequest := map[corev1.ResourceName]resource.Quantity{}
 	// set default value
@@ -1137,13 +1372,18 @@ func ParsePodResources(cpuLimit, memoryLimit, cpuRequest, memoryRequest string,
 			delete(podRequest, corev1.ResourceMemory)
 		}
 	}
-	return corev1.ResourceRequirements{
+	resources, err := budget.Apply(corev1.ResourceRequirements{
 		Limits:   podLimit,
 		Requests: podRequest,
-	}, nil
+	}, nil)
+	if err != nil {
+		return corev1.ResourceRequirements{}, fmt.Errorf("mount pod resources exceed the budget: %v", err)
+	}
+	return resources, nil
 }
 
-func getDefaultResource() corev1.ResourceRequirements {
+// GetDefaultResource returns the default resources of mount pod
+func GetDefaultResource() corev1.ResourceRequirements {
 	return corev1.ResourceRequirements{
 		Limits: corev1.ResourceList{
 			corev1.ResourceCPU:    resource.MustParse(common.DefaultMountPodCpuLimit),
@@ -1310,10 +1550,13 @@ func applyPatchResourcePercentages(setting *JfsSetting, patch MountPodPatch, app
 	applyResourcePercentages(&setting.Attr.Resources.Limits, patch.ResourcePercentages.Limits, minLimits, appResources.Limits)
 }
 
-func applyConfigPatch(setting *JfsSetting, replaceTemplate bool) {
+func applyConfigPatch(setting *JfsSetting, replaceTemplate bool) error {
 	attr := setting.Attr
 	// overwrite by mountpod patch
-	patch := GlobalConfig.GenMountPodPatch(*setting, replaceTemplate, setting.Node)
+	patch, err := GlobalConfig.GenMountPodPatch(*setting, replaceTemplate, setting.Node)
+	if err != nil {
+		return err
+	}
 	appResources := corev1.ResourceRequirements{}
 	if setting.AppPod != nil && setting.PVC != nil {
 		appResources = getAppContainerResources(setting.AppPod, setting.PVC)
@@ -1360,6 +1603,7 @@ func applyConfigPatch(setting *JfsSetting, replaceTemplate bool) {
 	attr.Env = patch.Env
 	attr.InitContainers = patch.InitContainers
 	attr.CacheDirs = patch.CacheDirs
+	attr.PodPatches = patch.PodPatches
 
 	newOptions := make([]string, 0)
 	patchOptionsMap := make(map[string]bool)
@@ -1392,6 +1636,7 @@ func applyConfigPatch(setting *JfsSetting, replaceTemplate bool) {
 		setting.CleanCache = v == "true"
 		delete(attr.Annotations, common.CleanCacheKey)
 	}
+	return nil
 }
 
 // IsCEMountPod check if the pod is a mount pod of CE
@@ -1405,7 +1650,8 @@ func IsCEMountPod(pod *corev1.Pod) bool {
 	return false
 }
 
-func getPVNameFromTarget(target string) string {
+// GetPVNameFromTarget returns the PV name in target path of app pod, or empty if not found
+func GetPVNameFromTarget(target string) string {
 	pair := strings.Split(target, "volumes/kubernetes.io~csi")
 	if len(pair) != 2 {
 		return ""
@@ -1420,10 +1666,15 @@ func getPVNameFromTarget(target string) string {
 }
 
 func GenHashOfSetting(log klog.Logger, setting JfsSetting) string {
+	// credentials from the external provider may be rotated, and they are not saved in secret
+	setting = setting.withoutProvidedCredentials()
 	// target path should not affect hash val
 	setting.TargetPath = ""
-	setting.VolumeId = ""
-	setting.SubPath = ""
+	if !setting.ReadOnly {
+		// read-only clients mount the path of the volume only, and are not shared by other volumes
+		setting.VolumeId = ""
+		setting.SubPath = ""
+	}
 	setting.InitConfig = ""
 	// in Publish, setting hash is calculated before mountPath is set correctly. Set it as the same as Publish
 	setting.MountPath = filepath.Join(PodMountBase, setting.UniqueId)
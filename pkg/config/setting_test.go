@@ -19,6 +19,8 @@ package config
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -33,6 +35,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/credential"
 )
 
 var (
@@ -1411,7 +1414,7 @@ func Test_parsePodResources(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ParsePodResources(tt.args.cpuLimit, tt.args.memoryLimit, tt.args.cpuRequest, tt.args.memoryRequest, getDefaultResource())
+			got, err := ParsePodResources(tt.args.cpuLimit, tt.args.memoryLimit, tt.args.cpuRequest, tt.args.memoryRequest, GetDefaultResource(), nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parsePodResources() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1544,8 +1547,8 @@ func Test_getPVNameFromTarget(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getPVNameFromTarget(tt.target); got != tt.want {
-				t.Errorf("getPVNameFromTarget() = %v, want %v", got, tt.want)
+			if got := GetPVNameFromTarget(tt.target); got != tt.want {
+				t.Errorf("GetPVNameFromTarget() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -1800,7 +1803,9 @@ func Test_applyConfigPatch(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			GlobalConfig.MountPodPatch = []MountPodPatch{tt.args.patch}
-			applyConfigPatch(tt.args.setting, true)
+			if err := applyConfigPatch(tt.args.setting, true); err != nil {
+				t.Fatal(err)
+			}
 			assert.Equal(t, tt.want, tt.args.setting)
 		})
 	}
@@ -1975,7 +1980,9 @@ func TestApplyConfigPatchResourcePercentages(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			applyConfigPatch(tt.setting, true)
+			if err := applyConfigPatch(tt.setting, true); err != nil {
+				t.Fatal(err)
+			}
 
 			assert.Equal(t, tt.wantRequestCPU, tt.setting.Attr.Resources.Requests.Cpu().MilliValue())
 			assert.Equal(t, tt.wantRequestMemory, tt.setting.Attr.Resources.Requests.Memory().String())
@@ -2066,3 +2073,164 @@ func TestGenHashOfSetting(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSettingWithCredentialProvider(t *testing.T) {
+	defer func(c *credential.Cache) { credential.DefaultCache = c }(credential.DefaultCache)
+	// fetch credentials every time
+	credential.DefaultCache = credential.NewCache(0)
+	dir := t.TempDir()
+	defer GlobalConfig.Reset()
+	GlobalConfig.CredentialProviders = &CredentialProviders{AllowedDirs: []string{dir}}
+	writeCredential := func(key, value string) {
+		if err := os.WriteFile(filepath.Join(dir, key), []byte(value), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCredential("metaurl", "redis://:pass@redis:6379/1")
+	writeCredential("secret-key", "sk1")
+	secrets := map[string]string{
+		"name":                 "test",
+		"storage":              "s3",
+		"bucket":               "http://test.s3.amazonaws.com",
+		"access-key":           "ak",
+		"secretkey":            "should-be-overridden",
+		credential.ProviderKey: "file",
+		credential.PathKey:     dir,
+	}
+	setting, err := ParseSetting(context.TODO(), secrets, nil, nil, "vol", "vol", "uuid", nil, nil)
+	if err != nil {
+		t.Fatalf("ParseSetting() error = %v", err)
+	}
+	assert.True(t, setting.IsCe)
+	assert.Equal(t, "redis://:pass@redis:6379/1", setting.Source)
+	assert.Equal(t, "sk1", setting.SecretKey)
+	assert.Equal(t, []string{"metaurl", "secret-key"}, setting.Credential.Keys)
+	assert.Equal(t, "should-be-overridden", secrets["secretkey"], "secrets should not be modified")
+
+	str := setting.String()
+	assert.NotContains(t, str, "pass@redis")
+	assert.NotContains(t, str, "sk1")
+	loaded := &JfsSetting{}
+	assert.NoError(t, loaded.Load(str))
+	assert.Equal(t, setting.Credential, loaded.Credential)
+
+	// rotated credentials do not change hash
+	writeCredential("secret-key", "sk2")
+	rotated, err := ParseSetting(context.TODO(), secrets, nil, nil, "vol", "vol", "uuid", nil, nil)
+	if err != nil {
+		t.Fatalf("ParseSetting() error = %v", err)
+	}
+	assert.Equal(t, "sk2", rotated.SecretKey)
+	assert.Equal(t, GenHashOfSetting(klog.NewKlogr(), *setting), GenHashOfSetting(klog.NewKlogr(), *rotated))
+
+	secrets[credential.PathKey] = "relative"
+	_, err = ParseSetting(context.TODO(), secrets, nil, nil, "vol", "vol", "uuid", nil, nil)
+	assert.Error(t, err)
+
+	secrets[credential.PathKey] = filepath.Join(dir, "..")
+	_, err = ParseSetting(context.TODO(), secrets, nil, nil, "vol", "vol", "uuid", nil, nil)
+	assert.ErrorContains(t, err, "is not in credentialProviders.allowedDirs")
+}
+
+func TestParseSetting_readOnly(t *testing.T) {
+	secrets := map[string]string{"name": "test", "metaurl": "redis://127.0.0.1:6379/0"}
+	rw, err := ParseSetting(context.TODO(), secrets, nil, []string{"cache-size=100"}, "vol", "vol", "uuid", nil, nil)
+	if err != nil {
+		t.Fatalf("ParseSetting() error = %v", err)
+	}
+	assert.False(t, rw.ReadOnly)
+	assert.Equal(t, "juicefs-vol-secret", rw.SecretName)
+
+	ro, err := ParseSetting(context.TODO(), secrets, nil, []string{"cache-size=100", "ro"}, "vol", "vol", "uuid", nil, nil)
+	if err != nil {
+		t.Fatalf("ParseSetting() error = %v", err)
+	}
+	assert.True(t, ro.ReadOnly)
+	assert.Equal(t, []string{"cache-size=100", "ro", "read-only"}, ro.Options, "community edition client runs with --read-only")
+	assert.Equal(t, "juicefs-vol-ro-secret", ro.SecretName)
+	assert.NotEqual(t, GenHashOfSetting(klog.NewKlogr(), *rw), GenHashOfSetting(klog.NewKlogr(), *ro))
+
+	loaded := &JfsSetting{}
+	assert.NoError(t, loaded.Load(ro.String()))
+	assert.True(t, loaded.ReadOnly)
+
+	// pv marked read-only
+	pv := &corev1.PersistentVolume{Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{
+		CSI: &corev1.CSIPersistentVolumeSource{VolumeHandle: "vol", ReadOnly: true},
+	}}}
+	ro, err = ParseSetting(context.TODO(), map[string]string{"name": "test", "token": "token"}, nil, nil, "vol", "vol", "uuid", pv, nil)
+	if err != nil {
+		t.Fatalf("ParseSetting() error = %v", err)
+	}
+	assert.True(t, ro.ReadOnly)
+	assert.Equal(t, []string{"ro"}, ro.Options)
+
+	// read-only client is not shared in share mode, and mounts the sub path of the volume
+	rw.MountShareMode, ro.MountShareMode = "fsShareMount", "fsShareMount"
+	assert.True(t, rw.SharesClient())
+	assert.False(t, ro.SharesClient())
+	other := *ro
+	other.VolumeId, other.SubPath = "vol2", "pvc-2"
+	assert.NotEqual(t, GenHashOfSetting(klog.NewKlogr(), *ro), GenHashOfSetting(klog.NewKlogr(), other))
+}
+
+func TestGenSettingAttrWithMountPodAppliesResourceBudget(t *testing.T) {
+	defer GlobalConfig.Reset()
+	GlobalConfig.ResourceBudgets = []ResourceBudget{{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "free"}},
+		Policy:            ResourceBudgetPolicyClamp,
+		MaxPerMountPod:    corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+	}}
+	GlobalConfig.MountPodPatch = []MountPodPatch{{
+		Resources: &corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("8Gi")},
+		},
+	}}
+
+	mountPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mount-pod",
+			Namespace: "kube-system",
+			Labels: map[string]string{
+				common.PodUniqueIdLabelKey:  "unique-id",
+				common.PodJuiceHashLabelKey: "old-hash",
+			},
+			Annotations: map[string]string{
+				common.UniqueId:    "unique-id",
+				common.JuiceFSUUID: "test-fs",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:    "jfs-mount",
+				Image:   "juicedata/mount:ee-nightly",
+				Command: []string{"sh", "-c", "exec /sbin/mount.juicefs test /jfs/unique-id -o foreground,no-update"},
+			}},
+		},
+	}
+	client := &k8sclient.K8sClient{
+		Interface: fake.NewSimpleClientset(
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "juicefs-unique-id-secret", Namespace: "kube-system"}},
+			&corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "unique-id"},
+				Spec: corev1.PersistentVolumeSpec{
+					PersistentVolumeSource: corev1.PersistentVolumeSource{
+						CSI: &corev1.CSIPersistentVolumeSource{VolumeAttributes: map[string]string{}},
+					},
+					ClaimRef: &corev1.ObjectReference{Name: "data", Namespace: "tenant-a"},
+				},
+			},
+			&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "tenant-a"}},
+			// budget is matched by labels of the namespace
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Labels: map[string]string{"tier": "free"}}},
+		),
+	}
+
+	setting, err := GenSettingAttrWithMountPod(context.TODO(), client, mountPod)
+	assert.NoError(t, err)
+	assert.Equal(t, "free", setting.GetNamespace().Labels["tier"])
+	memory := setting.Attr.Resources.Limits[corev1.ResourceMemory]
+	assert.Equal(t, "2Gi", memory.String(), "memory limit raised by mountPodPatch should be clamped")
+	cpu := setting.Attr.Resources.Limits[corev1.ResourceCPU]
+	assert.Equal(t, "2", cpu.String())
+}
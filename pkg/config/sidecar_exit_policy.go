@@ -0,0 +1,93 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+)
+
+// SidecarExitPolicy decides how sidecars are terminated after app containers exit, it can be overridden by
+// annotations juicefs/sidecar-exit-policy and juicefs/sidecar-exit-grace-period of the app pod
+type SidecarExitPolicy struct {
+	// immediate, grace or wait-for-upload-flush, the default is grace
+	Policy string `json:"policy,omitempty"`
+	// for grace, how long sidecars can take to exit after they are umounted before they are killed;
+	// for wait-for-upload-flush, the longest time to wait for the upload. The default is 5m
+	GracePeriod string `json:"gracePeriod,omitempty"`
+}
+
+const (
+	// umount and kill sidecars at once
+	SidecarExitImmediate = "immediate"
+	// umount sidecars, and kill them if they are still running after the grace period
+	SidecarExitGrace = "grace"
+	// wait for data in the write-back staging area to be uploaded before umount and kill sidecars
+	SidecarExitWaitForUploadFlush = "wait-for-upload-flush"
+
+	defaultSidecarExitGracePeriod = 5 * time.Minute
+)
+
+// Resolve returns the exit policy of sidecars and its grace period, annotations of the app pod override the config.
+// Invalid annotations are ignored with an error returned.
+func (p *SidecarExitPolicy) Resolve(annotations map[string]string) (policy string, gracePeriod time.Duration, err error) {
+	policy, gracePeriod = SidecarExitGrace, defaultSidecarExitGracePeriod
+	if p != nil {
+		if p.Policy != "" {
+			policy = p.Policy
+		}
+		if d, e := time.ParseDuration(p.GracePeriod); e == nil && d >= 0 {
+			gracePeriod = d
+		}
+	}
+	if v, ok := annotations[common.SidecarExitPolicyKey]; ok {
+		if isValidSidecarExitPolicy(v) {
+			policy = v
+		} else {
+			err = fmt.Errorf("%s: unknown policy %q", common.SidecarExitPolicyKey, v)
+		}
+	}
+	if v, ok := annotations[common.SidecarExitGracePeriodKey]; ok {
+		if d, e := time.ParseDuration(v); e == nil && d >= 0 {
+			gracePeriod = d
+		} else {
+			err = fmt.Errorf("%s: invalid duration %q", common.SidecarExitGracePeriodKey, v)
+		}
+	}
+	return
+}
+
+func isValidSidecarExitPolicy(policy string) bool {
+	return policy == SidecarExitImmediate || policy == SidecarExitGrace || policy == SidecarExitWaitForUploadFlush
+}
+
+func (p *SidecarExitPolicy) validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.Policy != "" && !isValidSidecarExitPolicy(p.Policy) {
+		return fmt.Errorf("sidecarExitPolicy.policy: unknown policy %q, expect %s, %s or %s", p.Policy, SidecarExitImmediate, SidecarExitGrace, SidecarExitWaitForUploadFlush)
+	}
+	if p.GracePeriod != "" {
+		if d, err := time.ParseDuration(p.GracePeriod); err != nil || d < 0 {
+			return fmt.Errorf("sidecarExitPolicy.gracePeriod: invalid duration %q", p.GracePeriod)
+		}
+	}
+	return nil
+}
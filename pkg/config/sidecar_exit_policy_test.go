@@ -0,0 +1,66 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+)
+
+func TestSidecarExitPolicy_Resolve(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      *SidecarExitPolicy
+		annotations map[string]string
+		wantPolicy  string
+		wantGrace   time.Duration
+		wantErr     bool
+	}{
+		{name: "default", wantPolicy: SidecarExitGrace, wantGrace: 5 * time.Minute},
+		{
+			name:       "global",
+			policy:     &SidecarExitPolicy{Policy: SidecarExitWaitForUploadFlush, GracePeriod: "30m"},
+			wantPolicy: SidecarExitWaitForUploadFlush, wantGrace: 30 * time.Minute,
+		},
+		{
+			name:        "annotations override",
+			policy:      &SidecarExitPolicy{Policy: SidecarExitWaitForUploadFlush, GracePeriod: "30m"},
+			annotations: map[string]string{common.SidecarExitPolicyKey: SidecarExitGrace, common.SidecarExitGracePeriodKey: "30s"},
+			wantPolicy:  SidecarExitGrace, wantGrace: 30 * time.Second,
+		},
+		{
+			name:        "invalid annotations",
+			policy:      &SidecarExitPolicy{Policy: SidecarExitImmediate},
+			annotations: map[string]string{common.SidecarExitPolicyKey: "never"},
+			wantPolicy:  SidecarExitImmediate, wantGrace: 5 * time.Minute, wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, grace, err := tt.policy.Resolve(tt.annotations)
+			assert.Equal(t, tt.wantErr, err != nil, "Resolve() error = %v", err)
+			assert.Equal(t, tt.wantPolicy, policy)
+			assert.Equal(t, tt.wantGrace, grace)
+		})
+	}
+	assert.Error(t, (&SidecarExitPolicy{Policy: "never"}).validate())
+	assert.Error(t, (&SidecarExitPolicy{GracePeriod: "5"}).validate())
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+)
+
+// volumeContextKeys are keys of StorageClass parameters and PV volumeAttributes used by CSI driver
+var volumeContextKeys = map[string]bool{
+	"subPath":                     true,
+	"capacity":                    true,
+	"mountOptions":                true,
+	"pathPattern":                 true,
+	"secretFinalizer":             true,
+	common.MountPodCpuLimitKey:    true,
+	common.MountPodMemLimitKey:    true,
+	common.MountPodCpuRequestKey:  true,
+	common.MountPodMemRequestKey:  true,
+	common.MountPodLabelKey:       true,
+	common.MountPodAnnotationKey:  true,
+	common.MountPodServiceAccount: true,
+	common.MountPodImageKey:       true,
+	common.DeleteDelay:            true,
+	common.CleanCacheKey:          true,
+	common.CachePVC:               true,
+	common.CacheEmptyDir:          true,
+	common.CacheInlineVolume:      true,
+	common.MountPodHostPath:       true,
+	common.MountPodPrivilegedKey:  true,
+	common.ControllerQuotaSetKey:  true,
+	common.WarmupPathsKey:         true,
+	common.WarmupTimeoutKey:       true,
+}
+
+// keys with these prefixes are set by kubernetes or CSI sidecars
+var externalVolumeContextPrefixes = []string{"csi.storage.k8s.io/", "storage.kubernetes.io/"}
+
+// ValidateVolumeContext checks StorageClass parameters or PV volumeAttributes, and mount options, with the same
+// parsing as mounting, so that mistakes are found before the volume is used.
+// Values with templates (${...}) are skipped, since they are expanded in provisioning.
+// Unknown keys prefixed with juicefs/ are rejected, other unknown keys are returned as warnings.
+func ValidateVolumeContext(volCtx map[string]string, mountOptions []string) (warnings []string, err error) {
+	keys := make([]string, 0, len(volCtx))
+	for k := range volCtx {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ctx := make(map[string]string, len(volCtx))
+	for _, k := range keys {
+		v := volCtx[k]
+		if !volumeContextKeys[k] && !hasExternalPrefix(k) {
+			msg := fmt.Sprintf("unknown key %q", k)
+			if similar := similarVolumeContextKey(k); similar != "" {
+				msg += fmt.Sprintf(", do you mean %q?", similar)
+			}
+			if strings.HasPrefix(k, "juicefs/") {
+				return warnings, fmt.Errorf("%s", msg)
+			}
+			warnings = append(warnings, msg)
+			continue
+		}
+		if !strings.Contains(v, "${") {
+			ctx[k] = v
+		}
+	}
+
+	resources, err := ParsePodResources(ctx[common.MountPodCpuLimitKey], ctx[common.MountPodMemLimitKey],
+		ctx[common.MountPodCpuRequestKey], ctx[common.MountPodMemRequestKey], GetDefaultResource(), nil)
+	if err != nil {
+		return warnings, fmt.Errorf("invalid mount pod resources: %v", err)
+	}
+	for _, key := range []string{common.MountPodLabelKey, common.MountPodAnnotationKey} {
+		if err := validateMetadataOfVolumeContext(key, ctx[key]); err != nil {
+			return warnings, err
+		}
+	}
+	if v := ctx[common.DeleteDelay]; v != "" {
+		if _, err := time.ParseDuration(v); err != nil {
+			return warnings, fmt.Errorf("%s: invalid duration %q: %v", common.DeleteDelay, v, err)
+		}
+	}
+	if v := ctx[common.MountPodPrivilegedKey]; v != "" && v != common.True && v != common.False {
+		return warnings, fmt.Errorf("%s: invalid value %q, should be \"true\" or \"false\"", common.MountPodPrivilegedKey, v)
+	}
+	if _, err := ParseWarmupSetting(ctx); err != nil {
+		return warnings, err
+	}
+	if v := ctx[common.MountPodServiceAccount]; v != "" {
+		if errs := validation.IsDNS1123Subdomain(v); len(errs) > 0 {
+			return warnings, fmt.Errorf("%s: invalid service account %q: %s", common.MountPodServiceAccount, v, strings.Join(errs, "; "))
+		}
+	}
+	for _, name := range strings.Split(strings.TrimSpace(ctx[common.CachePVC]), ",") {
+		if name == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return warnings, fmt.Errorf("%s: invalid pvc name %q: %s", common.CachePVC, name, strings.Join(errs, "; "))
+		}
+	}
+
+	options := []string{}
+	if v, ok := volCtx["mountOptions"]; ok && !strings.Contains(v, "${") {
+		options = strings.Split(v, ",")
+	}
+	for _, o := range mountOptions {
+		if !strings.Contains(o, "${") {
+			options = append(options, strings.Split(strings.TrimSpace(o), ",")...)
+		}
+	}
+	setting := &JfsSetting{Options: options, Attr: &PodAttr{Resources: resources}}
+	if err := genAndValidOptions(setting); err != nil {
+		return warnings, err
+	}
+	if err := GenCacheDirs(setting, ctx); err != nil {
+		return warnings, fmt.Errorf("invalid cache dirs: %v", err)
+	}
+	return warnings, nil
+}
+
+func hasExternalPrefix(key string) bool {
+	for _, prefix := range externalVolumeContextPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateMetadataOfVolumeContext(key, value string) error {
+	if value == "" {
+		return nil
+	}
+	m := make(map[string]string)
+	if err := parseYamlOrJson(value, &m); err != nil {
+		return fmt.Errorf("%s: %v", key, err)
+	}
+	for k, v := range m {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return fmt.Errorf("%s: invalid key %q: %s", key, k, strings.Join(errs, "; "))
+		}
+		if key != common.MountPodLabelKey {
+			continue
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return fmt.Errorf("%s: invalid value %q for key %q: %s", key, v, k, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// similarVolumeContextKey returns the known key most similar to key, empty if none is similar enough
+func similarVolumeContextKey(key string) string {
+	best, bestDistance := "", 4
+	for k := range volumeContextKeys {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(k)); d < bestDistance || (d == bestDistance && k < best) {
+			best, bestDistance = k, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
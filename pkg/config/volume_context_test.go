@@ -0,0 +1,115 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+)
+
+func TestValidateVolumeContext(t *testing.T) {
+	testCases := []struct {
+		name         string
+		volCtx       map[string]string
+		mountOptions []string
+		wantErr      string
+		wantWarnings []string
+	}{
+		{
+			name: "valid",
+			volCtx: map[string]string{
+				"pathPattern":                                  "${.pvc.namespace}-${.pvc.name}",
+				common.MountPodCpuLimitKey:                     "2",
+				common.MountPodMemLimitKey:                     "${.pvc.annotations.mem}",
+				common.MountPodLabelKey:                        "team: data",
+				common.CachePVC:                                "cache-a,cache-b",
+				common.CacheEmptyDir:                           "Memory:1Gi",
+				common.DeleteDelay:                             "1m",
+				common.MountPodPrivilegedKey:                   "false",
+				"csi.storage.k8s.io/node-publish-secret-name":  "juicefs-secret",
+				"storage.kubernetes.io/csiProvisionerIdentity": "1234-csi.juicefs.com",
+			},
+			mountOptions: []string{"cache-size=1024,buffer-size=300", "subdir=${.pvc.name}"},
+		},
+		{
+			name:    "typo of juicefs key",
+			volCtx:  map[string]string{"juicefs/mount-cache-pvcs": "cache"},
+			wantErr: `unknown key "juicefs/mount-cache-pvcs", do you mean "juicefs/mount-cache-pvc"?`,
+		},
+		{
+			name:         "unknown key",
+			volCtx:       map[string]string{"pathpattern": "${.pvc.name}"},
+			wantWarnings: []string{`unknown key "pathpattern", do you mean "pathPattern"?`},
+		},
+		{
+			name:    "invalid resource",
+			volCtx:  map[string]string{common.MountPodCpuLimitKey: "2 cores"},
+			wantErr: "invalid mount pod resources",
+		},
+		{
+			name:    "invalid label",
+			volCtx:  map[string]string{common.MountPodLabelKey: "team: data science"},
+			wantErr: common.MountPodLabelKey,
+		},
+		{
+			name:    "invalid delete delay",
+			volCtx:  map[string]string{common.DeleteDelay: "10"},
+			wantErr: common.DeleteDelay,
+		},
+		{
+			name:    "invalid mount privileged",
+			volCtx:  map[string]string{common.MountPodPrivilegedKey: "no"},
+			wantErr: common.MountPodPrivilegedKey,
+		},
+		{
+			name:    "invalid emptyDir size",
+			volCtx:  map[string]string{common.CacheEmptyDir: "Memory:1G1"},
+			wantErr: "invalid cache dirs",
+		},
+		{
+			name:    "invalid inline volume",
+			volCtx:  map[string]string{common.CacheInlineVolume: "{driver: csi}"},
+			wantErr: "invalid cache dirs",
+		},
+		{
+			name:    "invalid mount options in volume context",
+			volCtx:  map[string]string{"mountOptions": "cache-size=1=2"},
+			wantErr: "invalid mount option",
+		},
+		{
+			name:         "buffer size over memory limit",
+			volCtx:       map[string]string{common.MountPodMemLimitKey: "100Mi"},
+			mountOptions: []string{"buffer-size=300"},
+			wantErr:      "buffer-size",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			warnings, err := ValidateVolumeContext(tc.volCtx, tc.mountOptions)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.True(t, strings.Contains(err.Error(), tc.wantErr), "error %q should contain %q", err.Error(), tc.wantErr)
+			}
+			assert.Equal(t, tc.wantWarnings, warnings)
+		})
+	}
+}
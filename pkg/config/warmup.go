@@ -0,0 +1,73 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+)
+
+const DefaultWarmupTimeout = 10 * time.Minute
+
+// WarmupSetting is the cache warmup after the volume is mounted, which is best-effort:
+// failures and timeout do not fail the mount
+type WarmupSetting struct {
+	// Paths are relative to the root of the volume
+	Paths   []string
+	Timeout time.Duration
+}
+
+// ParseWarmupSetting parses juicefs/warmup-paths and juicefs/warmup-timeout, values in later maps override earlier ones.
+// nil is returned if no path is set.
+func ParseWarmupSetting(values ...map[string]string) (*WarmupSetting, error) {
+	var pathsStr, timeoutStr string
+	for _, m := range values {
+		if v, ok := m[common.WarmupPathsKey]; ok {
+			pathsStr = v
+		}
+		if v, ok := m[common.WarmupTimeoutKey]; ok {
+			timeoutStr = v
+		}
+	}
+	warmup := &WarmupSetting{Timeout: DefaultWarmupTimeout}
+	for _, p := range strings.Split(pathsStr, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if strings.ContainsAny(p, " \t\n'\"\\") {
+			return nil, fmt.Errorf("%s: unsupported characters in path %q", common.WarmupPathsKey, p)
+		}
+		// paths can not escape from the volume
+		warmup.Paths = append(warmup.Paths, strings.TrimPrefix(path.Join("/", p), "/"))
+	}
+	if timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("%s: invalid duration %q", common.WarmupTimeoutKey, timeoutStr)
+		}
+		warmup.Timeout = timeout
+	}
+	if len(warmup.Paths) == 0 {
+		return nil, nil
+	}
+	return warmup, nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+)
+
+func TestParseWarmupSetting(t *testing.T) {
+	testCases := []struct {
+		name    string
+		values  []map[string]string
+		want    *WarmupSetting
+		wantErr bool
+	}{
+		{
+			name:   "no paths",
+			values: []map[string]string{{common.WarmupTimeoutKey: "1m"}},
+		},
+		{
+			name:   "default timeout",
+			values: []map[string]string{{common.WarmupPathsKey: "/dataset/train, val/,../../etc"}},
+			want:   &WarmupSetting{Paths: []string{"dataset/train", "val", "etc"}, Timeout: DefaultWarmupTimeout},
+		},
+		{
+			name: "override",
+			values: []map[string]string{
+				{common.WarmupPathsKey: "a", common.WarmupTimeoutKey: "1m"},
+				{common.WarmupPathsKey: "b"},
+			},
+			want: &WarmupSetting{Paths: []string{"b"}, Timeout: time.Minute},
+		},
+		{
+			name:    "invalid timeout",
+			values:  []map[string]string{{common.WarmupPathsKey: "a", common.WarmupTimeoutKey: "1x"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid path",
+			values:  []map[string]string{{common.WarmupPathsKey: "a b"}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseWarmupSetting(tc.values...)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -18,6 +18,9 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,6 +36,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
 	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
 	"github.com/juicedata/juicefs-csi-driver/pkg/util"
 )
@@ -41,6 +45,11 @@ var (
 	appCtrlLog = klog.NewKlogr().WithName("app-controller")
 )
 
+const (
+	reasonSidecarExit        = "SidecarExit"
+	uploadFlushCheckInterval = 10 * time.Second
+)
+
 type AppController struct {
 	*k8sclient.K8sClient
 }
@@ -67,7 +76,6 @@ func (a *AppController) Reconcile(ctx context.Context, request reconcile.Request
 	}
 
 	// get a last terminated container finsh time
-	// if the time is more than 5 minutes, kill the fuse process
 	var appContainerExitedTime time.Time
 	for _, containerStatus := range pod.Status.ContainerStatuses {
 		if !strings.Contains(containerStatus.Name, common.MountContainerName) {
@@ -78,14 +86,39 @@ func (a *AppController) Reconcile(ctx context.Context, request reconcile.Request
 			}
 		}
 	}
+	exitedFor := time.Since(appContainerExitedTime)
+
+	policy, gracePeriod, err := config.GlobalConfig.SidecarExitPolicy.Resolve(pod.Annotations)
+	if err != nil {
+		appCtrlLog.Error(err, "invalid sidecar exit policy in annotations of pod, ignore it", "name", request.Name)
+	}
+	switch policy {
+	case config.SidecarExitImmediate:
+		return reconcile.Result{}, a.terminateFuseSidecars(ctx, pod, fmt.Sprintf("app containers exited, sidecar exit policy is %s", policy))
+	case config.SidecarExitWaitForUploadFlush:
+		if exitedFor < gracePeriod {
+			pending, err := a.pendingUploadBlocks(ctx, pod)
+			if err != nil {
+				appCtrlLog.Error(err, "check upload of sidecars error", "name", request.Name)
+			}
+			if err != nil || pending > 0 {
+				appCtrlLog.V(1).Info("wait for upload of sidecars", "name", request.Name, "pendingBlocks", pending)
+				return reconcile.Result{RequeueAfter: min(uploadFlushCheckInterval, gracePeriod-exitedFor)}, nil
+			}
+			return reconcile.Result{}, a.terminateFuseSidecars(ctx, pod, "data in write-back staging area is uploaded after app containers exited")
+		}
+		return reconcile.Result{}, a.terminateFuseSidecars(ctx, pod, fmt.Sprintf("data in write-back staging area is not uploaded in %s after app containers exited", gracePeriod))
+	}
 
-	if !appContainerExitedTime.IsZero() && time.Since(appContainerExitedTime) > 5*time.Minute {
-		appCtrlLog.V(1).Info("app container exited more than 5 minutes, kill the mount process, app pod will enter an error phase")
+	// kill the fuse process if it does not exit in grace period after umount
+	if exitedFor > gracePeriod {
+		appCtrlLog.V(1).Info("app container exited more than grace period, kill the mount process, app pod will enter an error phase", "gracePeriod", gracePeriod)
 		err = a.killFuseProcesss(ctx, pod)
 		if err != nil {
 			appCtrlLog.Error(err, "kill fuse process error", "name", request.Name)
 			return reconcile.Result{}, err
 		}
+		a.sidecarExitEvent(ctx, pod, fmt.Sprintf("JuiceFS sidecars are killed, since they are still running %s after app containers exited", gracePeriod))
 		return reconcile.Result{}, nil
 	}
 
@@ -94,7 +127,62 @@ func (a *AppController) Reconcile(ctx context.Context, request reconcile.Request
 	if err != nil {
 		return reconcile.Result{}, err
 	}
-	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+	return reconcile.Result{RequeueAfter: gracePeriod - exitedFor}, nil
+}
+
+// terminateFuseSidecars umounts and kills fuse sidecars at once
+func (a *AppController) terminateFuseSidecars(ctx context.Context, pod *corev1.Pod, reason string) error {
+	appCtrlLog.Info("terminate fuse sidecars", "name", pod.Name, "namespace", pod.Namespace, "reason", reason)
+	if err := a.umountFuseSidecars(ctx, pod); err != nil {
+		return err
+	}
+	if err := a.killFuseProcesss(ctx, pod); err != nil {
+		appCtrlLog.Error(err, "kill fuse process error", "name", pod.Name)
+		return err
+	}
+	a.sidecarExitEvent(ctx, pod, fmt.Sprintf("JuiceFS sidecars are terminated: %s", reason))
+	return nil
+}
+
+func (a *AppController) sidecarExitEvent(ctx context.Context, pod *corev1.Pod, msg string) {
+	if err := a.K8sClient.CreateEvent(ctx, *pod, corev1.EventTypeNormal, reasonSidecarExit, msg); err != nil {
+		appCtrlLog.Error(err, "fail to create event", "name", pod.Name, "namespace", pod.Namespace)
+	}
+}
+
+// pendingUploadBlocks sums blocks in write-back staging area of all fuse sidecars, which are not uploaded yet
+func (a *AppController) pendingUploadBlocks(ctx context.Context, pod *corev1.Pod) (int64, error) {
+	var pending int64
+	for _, cn := range pod.Spec.Containers {
+		if !strings.Contains(cn.Name, common.MountContainerName) {
+			continue
+		}
+		mntPath, _, err := util.GetMountPathOfSidecar(*pod, cn.Name)
+		if err != nil {
+			return 0, err
+		}
+		stdout, stderr, err := a.K8sClient.ExecuteInContainer(ctx, pod.Name, pod.Namespace, cn.Name, []string{"cat", path.Join(mntPath, ".stats")})
+		if err != nil {
+			return 0, fmt.Errorf("read stats of %s error: %v, stderr: %s", cn.Name, err, stderr)
+		}
+		pending += parseStagingBlocks(stdout)
+	}
+	return pending, nil
+}
+
+// parseStagingBlocks gets the number of blocks in staging area from .stats of the mount point
+func parseStagingBlocks(stats string) int64 {
+	var blocks int64
+	for _, line := range strings.Split(stats, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.Contains(fields[0], "staging_blocks") {
+			continue
+		}
+		if v, err := strconv.ParseFloat(fields[1], 64); err == nil {
+			blocks += int64(v)
+		}
+	}
+	return blocks
 }
 
 func (a *AppController) umountFuseSidecars(ctx context.Context, pod *corev1.Pod) (err error) {
@@ -23,6 +23,7 @@ import (
 	ctx "context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -30,9 +31,13 @@ import (
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
 	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
 )
 
@@ -472,3 +477,83 @@ func TestAppController_umountFuseSidecars_error(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseStagingBlocks(t *testing.T) {
+	stats := "juicefs_fuse_ops_total 100\njuicefs_staging_blocks 3\njuicefs_staging_block_bytes 12582912\n"
+	if got := parseStagingBlocks(stats); got != 3 {
+		t.Errorf("parseStagingBlocks() = %d, want 3", got)
+	}
+	if got := parseStagingBlocks(""); got != 0 {
+		t.Errorf("parseStagingBlocks() = %d, want 0", got)
+	}
+}
+
+func TestAppController_Reconcile_exitPolicy(t *testing.T) {
+	defer func(policy *config.SidecarExitPolicy) { config.GlobalConfig.SidecarExitPolicy = policy }(config.GlobalConfig.SidecarExitPolicy)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", Labels: map[string]string{common.InjectSidecarDone: common.True}},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{Name: "app"},
+				{
+					Name:      common.MountContainerName + "-0",
+					Command:   []string{"sh", "-c", "exec /sbin/mount.juicefs ${metaurl} /jfs/abcdef -o metrics=0.0.0.0:9567"},
+					Lifecycle: &corev1.Lifecycle{PreStop: &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: []string{"umount"}}}},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(time.Now().Add(-time.Minute))}}},
+				{Name: common.MountContainerName + "-0", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+	client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset(pod)}
+	var cmds []string
+	stagingBlocks := "1"
+	patch := ApplyMethod(reflect.TypeOf(client), "ExecuteInContainer", func(_ *k8sclient.K8sClient, c ctx.Context, podName, namespace, containerName string, cmd []string) (stdout string, stderr string, err error) {
+		cmds = append(cmds, strings.Join(cmd, " "))
+		return "juicefs_staging_blocks " + stagingBlocks, "", nil
+	})
+	defer patch.Reset()
+	a := NewAppController(client)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "app", Namespace: "default"}}
+
+	// grace: umount and wait for the rest of the grace period
+	config.GlobalConfig.SidecarExitPolicy = nil
+	result, err := a.Reconcile(ctx.TODO(), request)
+	if err != nil || result.RequeueAfter <= 3*time.Minute || result.RequeueAfter > 4*time.Minute || len(cmds) != 1 || cmds[0] != "umount" {
+		t.Fatalf("grace: Reconcile() = %v, %v, cmds %v", result, err, cmds)
+	}
+
+	// wait-for-upload-flush: wait until staging blocks are uploaded
+	cmds = nil
+	config.GlobalConfig.SidecarExitPolicy = &config.SidecarExitPolicy{Policy: config.SidecarExitWaitForUploadFlush}
+	result, err = a.Reconcile(ctx.TODO(), request)
+	if err != nil || result.RequeueAfter != uploadFlushCheckInterval || len(cmds) != 1 || cmds[0] != "cat /jfs/abcdef/.stats" {
+		t.Fatalf("wait-for-upload-flush: Reconcile() = %v, %v, cmds %v", result, err, cmds)
+	}
+	cmds = nil
+	stagingBlocks = "0"
+	if _, err = a.Reconcile(ctx.TODO(), request); err != nil || len(cmds) != 3 {
+		t.Fatalf("wait-for-upload-flush: Reconcile() error %v, cmds %v", err, cmds)
+	}
+
+	// immediate: umount and kill at once, annotation overrides config
+	cmds = nil
+	pod.Annotations = map[string]string{common.SidecarExitPolicyKey: config.SidecarExitImmediate}
+	if _, err := client.CoreV1().Pods("default").Update(ctx.TODO(), pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = a.Reconcile(ctx.TODO(), request); err != nil || len(cmds) != 2 || cmds[1] != "sh -c pkill -fe juicefs" {
+		t.Fatalf("immediate: Reconcile() error %v, cmds %v", err, cmds)
+	}
+	events, _ := client.CoreV1().Events("default").List(ctx.TODO(), metav1.ListOptions{})
+	if len(events.Items) != 2 || events.Items[0].Reason != reasonSidecarExit {
+		t.Errorf("events = %v, want 2 events of %s", events.Items, reasonSidecarExit)
+	}
+}
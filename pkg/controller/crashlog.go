@@ -0,0 +1,168 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/crashlog"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+)
+
+const (
+	crashLogReadTimeout    = 2 * time.Second
+	crashLogArchiveTimeout = time.Minute
+	crashLogKeyTTL         = 24 * time.Hour
+)
+
+// archivedCrashLogs records the pods whose logs are archived, key: <pod uid>/<restart count>
+var archivedCrashLogs = struct {
+	sync.Mutex
+	keys map[string]time.Time
+}{keys: make(map[string]time.Time)}
+
+// shouldArchiveCrashLog checks if logs of the pod in its current restart are not archived yet, and marks it
+func shouldArchiveCrashLog(pod *corev1.Pod) bool {
+	var restarts int32
+	for _, cs := range pod.Status.ContainerStatuses {
+		restarts += cs.RestartCount
+	}
+	key := fmt.Sprintf("%s/%d", pod.UID, restarts)
+	archivedCrashLogs.Lock()
+	defer archivedCrashLogs.Unlock()
+	now := time.Now()
+	for k, t := range archivedCrashLogs.keys {
+		if now.Sub(t) > crashLogKeyTTL {
+			delete(archivedCrashLogs.keys, k)
+		}
+	}
+	if _, ok := archivedCrashLogs.keys[key]; ok {
+		return false
+	}
+	archivedCrashLogs.keys[key] = now
+	return true
+}
+
+// archiveCrashLog saves pod yaml, container logs and .stats/.accesslog of failed or deleted mount pod in background,
+// so that reading logs does not block the handling of mount pod under its lock
+func (p *PodDriver) archiveCrashLog(ctx context.Context, pod *corev1.Pod) {
+	cfg := config.GlobalConfig.CrashLogArchive
+	if !cfg.IsEnabled() || !shouldArchiveCrashLog(pod) {
+		return
+	}
+	// the reconcile context is canceled once the handler returns
+	archiveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), crashLogArchiveTimeout)
+	go func(pod *corev1.Pod) {
+		defer cancel()
+		p.writeCrashLog(archiveCtx, pod, cfg)
+	}(pod.DeepCopy())
+}
+
+func (p *PodDriver) writeCrashLog(ctx context.Context, pod *corev1.Pod, cfg *config.CrashLogArchive) {
+	log := util.GenLog(ctx, podDriverLog, "archiveCrashLog")
+	maxLogSize := cfg.GetMaxLogSize()
+	entries := []crashlog.Entry{}
+	if data, err := yaml.Marshal(pod); err == nil {
+		entries = append(entries, crashlog.Entry{Name: "pod.yaml", Content: data})
+	}
+	for _, cs := range append(util.CopySlice(pod.Status.InitContainerStatuses), pod.Status.ContainerStatuses...) {
+		if data, err := p.Client.GetPodLogTail(ctx, pod.Name, pod.Namespace, cs.Name, false, maxLogSize); err == nil {
+			entries = append(entries, crashlog.Entry{Name: cs.Name + ".log", Content: data})
+		} else {
+			log.V(1).Info("get container log error", "container", cs.Name, "error", err)
+		}
+		if cs.RestartCount > 0 {
+			if data, err := p.Client.GetPodLogTail(ctx, pod.Name, pod.Namespace, cs.Name, true, maxLogSize); err == nil {
+				entries = append(entries, crashlog.Entry{Name: cs.Name + ".previous.log", Content: data})
+			}
+		}
+	}
+	if mntPath, _, err := util.GetMountPathOfPod(*pod); err == nil {
+		for _, name := range []string{".stats", ".accesslog"} {
+			fileName := util.GetJfsInternalFileName(pod, name)
+			if data := readInternalFile(ctx, filepath.Join(mntPath, fileName), maxLogSize, crashLogReadTimeout); len(data) > 0 {
+				entries = append(entries, crashlog.Entry{Name: fileName, Content: data})
+			}
+		}
+	}
+
+	path, err := crashlog.Write(cfg.GetPath(), resource.GetUniqueId(*pod), pod.Name, time.Now(), entries)
+	if err != nil {
+		log.Error(err, "write crash log archive error")
+		return
+	}
+	log.Info("mount pod logs archived", "path", path)
+	if err := crashlog.Rotate(cfg.GetPath(), cfg.GetMaxSize()); err != nil {
+		log.Error(err, "rotate crash log archives error")
+	}
+}
+
+// readInternalFile reads at most limit bytes of JuiceFS internal file within timeout.
+// .accesslog is a stream that blocks when there is no more access, so the file is closed after timeout.
+func readInternalFile(ctx context.Context, path string, limit int64, timeout time.Duration) []byte {
+	var (
+		lock sync.Mutex
+		data []byte
+		file *os.File
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		lock.Lock()
+		file = f
+		lock.Unlock()
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := f.Read(buf)
+			lock.Lock()
+			data = append(data, buf[:n]...)
+			full := int64(len(data)) >= limit
+			lock.Unlock()
+			if err != nil || full {
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	case <-ctx.Done():
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if file != nil {
+		_ = file.Close()
+	}
+	if int64(len(data)) > limit {
+		data = data[:limit]
+	}
+	return append([]byte{}, data...)
+}
@@ -0,0 +1,140 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+var ephemeralCtrlLog = klog.NewKlogr().WithName("ephemeral-controller")
+
+const (
+	// PVC of generic ephemeral volume is deleted if its pod is not created in this duration,
+	// e.g. the pod is rejected by other admission webhooks
+	ephemeralPodWaitTimeout = 30 * time.Minute
+	ephemeralRequeueAfter   = 10 * time.Second
+)
+
+// EphemeralVolumeController sets the pod as owner of PVC of generic ephemeral volume created by webhook in sidecar mode,
+// so that the PVC is deleted with the pod as kubernetes does.
+type EphemeralVolumeController struct {
+	*k8sclient.K8sClient
+}
+
+func NewEphemeralVolumeController(client *k8sclient.K8sClient) *EphemeralVolumeController {
+	return &EphemeralVolumeController{client}
+}
+
+func (m *EphemeralVolumeController) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	ephemeralCtrlLog.V(1).Info("Receive pvc", "name", request.Name, "namespace", request.Namespace)
+	pvc, err := m.GetPersistentVolumeClaim(ctx, request.Name, request.Namespace)
+	if err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return reconcile.Result{}, nil
+		}
+		ephemeralCtrlLog.Error(err, "Failed to get pvc", "name", request.Name, "namespace", request.Namespace)
+		return reconcile.Result{}, err
+	}
+	if !shouldEphemeralPVCInQueue(pvc) {
+		return reconcile.Result{}, nil
+	}
+
+	podName := pvc.Annotations[common.EphemeralPodAnnotationKey]
+	pod, err := m.GetPod(ctx, podName, pvc.Namespace)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			ephemeralCtrlLog.Error(err, "Failed to get pod", "name", podName, "namespace", pvc.Namespace)
+			return reconcile.Result{}, err
+		}
+		if time.Since(pvc.CreationTimestamp.Time) < ephemeralPodWaitTimeout {
+			return reconcile.Result{RequeueAfter: ephemeralRequeueAfter}, nil
+		}
+		ephemeralCtrlLog.Info("pod of generic ephemeral volume is not created, delete pvc", "pod", podName, "pvc", pvc.Name, "namespace", pvc.Namespace)
+		if err := m.DeletePersistentVolumeClaim(ctx, pvc.Name, pvc.Namespace); client.IgnoreNotFound(err) != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+	if pod.CreationTimestamp.Before(&pvc.CreationTimestamp) {
+		// pod with the same name existed before the PVC, which is not the one the PVC is created for
+		ephemeralCtrlLog.Info("pod is older than pvc of generic ephemeral volume, wait for it to be recreated", "pod", podName, "pvc", pvc.Name)
+		return reconcile.Result{RequeueAfter: ephemeralRequeueAfter}, nil
+	}
+
+	pvc.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Name:       pod.Name,
+		UID:        pod.UID,
+		Controller: ptr.To(true),
+	}}
+	ephemeralCtrlLog.Info("set pod as owner of pvc of generic ephemeral volume", "pod", pod.Name, "pvc", pvc.Name, "namespace", pvc.Namespace)
+	if err := m.UpdatePersistentVolumeClaim(ctx, pvc); err != nil {
+		ephemeralCtrlLog.Error(err, "Failed to update pvc", "name", pvc.Name, "namespace", pvc.Namespace)
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// shouldEphemeralPVCInQueue checks if PVC is created by webhook for generic ephemeral volume and not owned by its pod yet
+func shouldEphemeralPVCInQueue(pvc *corev1.PersistentVolumeClaim) bool {
+	if pvc.DeletionTimestamp != nil || len(pvc.OwnerReferences) != 0 {
+		return false
+	}
+	return pvc.Annotations[common.EphemeralPodAnnotationKey] != ""
+}
+
+func (m *EphemeralVolumeController) SetupWithManager(mgr ctrl.Manager) error {
+	ephemeralCtrlLog.V(1).Info("SetupWithManager", "name", "ephemeral-controller")
+	c, err := controller.New("ephemeral", mgr, controller.Options{Reconciler: m})
+	if err != nil {
+		return err
+	}
+
+	return c.Watch(source.Kind(mgr.GetCache(), &corev1.PersistentVolumeClaim{}, &handler.TypedEnqueueRequestForObject[*corev1.PersistentVolumeClaim]{}, predicate.TypedFuncs[*corev1.PersistentVolumeClaim]{
+		CreateFunc: func(event event.TypedCreateEvent[*corev1.PersistentVolumeClaim]) bool {
+			return shouldEphemeralPVCInQueue(event.Object)
+		},
+		UpdateFunc: func(updateEvent event.TypedUpdateEvent[*corev1.PersistentVolumeClaim]) bool {
+			pvcNew, pvcOld := updateEvent.ObjectNew, updateEvent.ObjectOld
+			if pvcNew.GetResourceVersion() == pvcOld.GetResourceVersion() {
+				return false
+			}
+			return shouldEphemeralPVCInQueue(pvcNew)
+		},
+		DeleteFunc: func(deleteEvent event.TypedDeleteEvent[*corev1.PersistentVolumeClaim]) bool {
+			return false
+		},
+	}))
+}
@@ -0,0 +1,78 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+func TestEphemeralVolumeController_Reconcile(t *testing.T) {
+	now := time.Now()
+	pvc := func(name, pod string, created time.Time) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(created),
+			Annotations:       map[string]string{common.EphemeralPodAnnotationKey: pod},
+		}}
+	}
+	client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "uid-app", CreationTimestamp: metav1.NewTime(now)}},
+		pvc("app-data", "app", now),
+		pvc("pending-data", "pending", now),
+		pvc("orphan-data", "orphan", now.Add(-time.Hour)),
+	)}
+	ctx := context.TODO()
+	c := NewEphemeralVolumeController(client)
+	reconcileOf := func(name string) reconcile.Result {
+		result, err := c.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}})
+		if err != nil {
+			t.Fatalf("Reconcile(%s) error = %v", name, err)
+		}
+		return result
+	}
+
+	reconcileOf("app-data")
+	got, err := client.GetPersistentVolumeClaim(ctx, "app-data", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.OwnerReferences) != 1 || got.OwnerReferences[0].UID != "uid-app" {
+		t.Errorf("owner of app-data = %v, want pod app", got.OwnerReferences)
+	}
+
+	if result := reconcileOf("pending-data"); result.RequeueAfter == 0 {
+		t.Errorf("pending-data is not requeued before pod is created")
+	}
+
+	reconcileOf("orphan-data")
+	if _, err := client.GetPersistentVolumeClaim(ctx, "orphan-data", "default"); !k8serrors.IsNotFound(err) {
+		t.Errorf("orphan-data is not deleted, error = %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+)
+
+const (
+	oomEventComponent = "juicefs-csi-node"
+	reasonOOMRecover  = "MountPodOOMRecover"
+	reasonOOMCeiling  = "MountPodOOMCeiling"
+)
+
+// oomRecover recreates OOMKilled mount pod with a higher memory limit, up to the configured ceiling
+func (p *PodDriver) oomRecover(ctx context.Context, pod *corev1.Pod) (Result, error) {
+	log := util.GenLog(ctx, podDriverLog, "oomRecover")
+	if len(pod.Spec.Containers) == 0 {
+		return Result{}, nil
+	}
+	current, ok := pod.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory]
+	if !ok || current.IsZero() {
+		log.Info("mount pod has no memory limit, skip raising it")
+		return Result{}, nil
+	}
+	next, raised := config.GlobalConfig.OOMRecovery.NextMemoryLimit(current)
+	if raised {
+		next, raised = p.budgetMemoryLimit(ctx, pod, current, next)
+	}
+	if !raised {
+		if pod.Annotations[common.OOMCeilingReportedKey] != current.String() {
+			msg := fmt.Sprintf("Mount pod %s is OOMKilled with memory limit %s, which has reached the ceiling or the resource budget, please check the cache settings of the volume", pod.Name, current.String())
+			log.Info(msg)
+			p.recordOOMEvent(ctx, pod, corev1.EventTypeWarning, reasonOOMCeiling, msg)
+			// record it to avoid duplicated events
+			if err := resource.AddPodAnnotation(ctx, p.Client, pod.Name, pod.Namespace, map[string]string{common.OOMCeilingReportedKey: current.String()}); err != nil {
+				log.Error(err, "add oom ceiling reported annotation error")
+			}
+		}
+		return Result{}, nil
+	}
+	msg := fmt.Sprintf("Mount pod %s is OOMKilled, recreate it with memory limit raised from %s to %s, please check the cache settings of the volume", pod.Name, current.String(), next.String())
+	log.Info(msg)
+	p.recordOOMEvent(ctx, pod, corev1.EventTypeWarning, reasonOOMRecover, msg)
+	return p.recreatePod(ctx, pod, func(newPod *corev1.Pod) {
+		setMemoryLimit(newPod, next)
+		if newPod.Annotations == nil {
+			newPod.Annotations = make(map[string]string)
+		}
+		newPod.Annotations[common.OOMMemoryLimitKey] = next.String()
+	})
+}
+
+// budgetMemoryLimit lowers the raised memory limit to the resource budget of the pvc namespace,
+// false if the limit can not be raised within the budget
+func (p *PodDriver) budgetMemoryLimit(ctx context.Context, pod *corev1.Pod, current, next k8sresource.Quantity) (k8sresource.Quantity, bool) {
+	log := util.GenLog(ctx, podDriverLog, "oomRecover")
+	candidate := pod.DeepCopy()
+	setMemoryLimit(candidate, next)
+	if err := p.applyResourceBudget(ctx, candidate, pod.Name); err != nil {
+		log.Info("memory limit can not be raised within the resource budget", "error", err.Error())
+		return current, false
+	}
+	limit := candidate.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory]
+	return limit, limit.Cmp(current) > 0
+}
+
+// recordOOMEvent emits event on pvcs and app pods which use the mount pod
+func (p *PodDriver) recordOOMEvent(ctx context.Context, pod *corev1.Pod, evtType, reason, msg string) {
+	log := util.GenLog(ctx, podDriverLog, "recordOOMEvent")
+	appPodUids := make(map[string]bool)
+	pvcsByNamespace := make(map[string]map[string]bool)
+	for k, target := range pod.Annotations {
+		if k != util.GetReferenceKey(target) {
+			continue
+		}
+		appPodUids[getPodUid(target)] = true
+		pvName := getPVName(target)
+		if pvName == "" {
+			continue
+		}
+		pv, err := p.Client.GetPersistentVolume(ctx, pvName)
+		if err != nil || pv.Spec.ClaimRef == nil {
+			log.V(1).Info("get pvc of pv error", "pv", pvName, "error", err)
+			continue
+		}
+		if pvcsByNamespace[pv.Spec.ClaimRef.Namespace] == nil {
+			pvcsByNamespace[pv.Spec.ClaimRef.Namespace] = make(map[string]bool)
+		}
+		pvcsByNamespace[pv.Spec.ClaimRef.Namespace][pv.Spec.ClaimRef.Name] = true
+	}
+	for namespace, pvcs := range pvcsByNamespace {
+		for pvcName := range pvcs {
+			ref := corev1.ObjectReference{Kind: "PersistentVolumeClaim", APIVersion: "v1", Namespace: namespace, Name: pvcName}
+			if err := p.Client.CreateObjectEvent(ctx, ref, oomEventComponent, evtType, reason, msg); err != nil {
+				log.Error(err, "create event of pvc error", "namespace", namespace, "name", pvcName)
+			}
+		}
+		appPods, err := p.Client.ListPod(ctx, namespace, nil, &fields.Set{"spec.nodeName": config.NodeName})
+		if err != nil {
+			log.Error(err, "list app pods error", "namespace", namespace)
+			continue
+		}
+		for _, appPod := range appPods {
+			if !appPodUids[string(appPod.UID)] {
+				continue
+			}
+			ref := corev1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: appPod.Namespace, Name: appPod.Name, UID: appPod.UID}
+			if err := p.Client.CreateObjectEvent(ctx, ref, oomEventComponent, evtType, reason, msg); err != nil {
+				log.Error(err, "create event of app pod error", "namespace", appPod.Namespace, "name", appPod.Name)
+			}
+		}
+	}
+}
+
+func setMemoryLimit(pod *corev1.Pod, limit k8sresource.Quantity) {
+	res := &pod.Spec.Containers[0].Resources
+	if res.Limits == nil {
+		res.Limits = corev1.ResourceList{}
+	}
+	res.Limits[corev1.ResourceMemory] = limit
+}
+
+// applyOOMMemoryLimit keeps the memory limit raised after OOMKilled when mount pod is regenerated
+func applyOOMMemoryLimit(pod *corev1.Pod, annotations map[string]string) {
+	v, ok := annotations[common.OOMMemoryLimitKey]
+	if !ok || len(pod.Spec.Containers) == 0 {
+		return
+	}
+	raised, err := k8sresource.ParseQuantity(v)
+	if err != nil {
+		return
+	}
+	current, ok := pod.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory]
+	if !ok || current.IsZero() || current.Cmp(raised) >= 0 {
+		return
+	}
+	setMemoryLimit(pod, raised)
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[common.OOMMemoryLimitKey] = v
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+)
+
+func Test_applyOOMMemoryLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       string
+		annotations map[string]string
+		want        string
+	}{
+		{
+			name:        "raised-limit-kept",
+			limit:       "1Gi",
+			annotations: map[string]string{common.OOMMemoryLimitKey: "2Gi"},
+			want:        "2Gi",
+		},
+		{
+			name:        "new-limit-higher",
+			limit:       "4Gi",
+			annotations: map[string]string{common.OOMMemoryLimitKey: "2Gi"},
+			want:        "4Gi",
+		},
+		{
+			name:        "no-annotation",
+			limit:       "1Gi",
+			annotations: map[string]string{},
+			want:        "1Gi",
+		},
+		{
+			name:        "invalid-annotation",
+			limit:       "1Gi",
+			annotations: map[string]string{common.OOMMemoryLimitKey: "invalid"},
+			want:        "1Gi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Resources: corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(tt.limit)},
+						},
+					}},
+				},
+			}
+			applyOOMMemoryLimit(pod, tt.annotations)
+			if got := pod.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory]; got.Cmp(resource.MustParse(tt.want)) != 0 {
+				t.Errorf("applyOOMMemoryLimit() = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestOOMRecover(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	maxLimit := resource.MustParse("2Gi")
+	config.GlobalConfig.OOMRecovery = &config.OOMRecovery{Enable: true, StepPercent: 50, MaxMemoryLimit: &maxLimit}
+
+	target := "/var/lib/kubelet/pods/app-uid/volumes/kubernetes.io~csi/pv-test/mount"
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-test"},
+		Spec: corev1.PersistentVolumeSpec{
+			ClaimRef: &corev1.ObjectReference{Namespace: "default", Name: "pvc-test"},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "juicefs-test",
+			Namespace:   "kube-system",
+			Annotations: map[string]string{util.GetReferenceKey(target): target},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "jfs-mount",
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+		}}},
+	}
+	client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset(pv, pod)}
+	p := &PodDriver{Client: client}
+
+	// 1Gi -> 1536Mi -> 2Gi, then OOMKilled at the ceiling several times
+	wantLimits := []string{"1536Mi", "2Gi", "2Gi", "2Gi", "2Gi"}
+	for i, want := range wantLimits {
+		current, err := client.GetPod(context.TODO(), pod.Name, pod.Namespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.oomRecover(context.TODO(), current); err != nil {
+			t.Fatal(err)
+		}
+		got, err := client.GetPod(context.TODO(), pod.Name, pod.Namespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		limit := got.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory]
+		if limit.String() != want {
+			t.Errorf("round %d: memory limit = %s, want %s", i, limit.String(), want)
+		}
+	}
+
+	events, err := client.CoreV1().Events("default").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reasons := make(map[string]int)
+	for _, e := range events.Items {
+		reasons[e.Reason]++
+	}
+	if reasons[reasonOOMRecover] != 2 {
+		t.Errorf("got %d %s events, want 2", reasons[reasonOOMRecover], reasonOOMRecover)
+	}
+	if reasons[reasonOOMCeiling] != 1 {
+		t.Errorf("got %d %s events, want 1", reasons[reasonOOMCeiling], reasonOOMCeiling)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -363,69 +364,92 @@ func (p *PodDriver) podErrorHandler(ctx context.Context, pod *corev1.Pod) (Resul
 	}
 	defer unlock()
 
+	p.archiveCrashLog(ctx, pod)
+
 	// check resource err
 	if resource.IsPodResourceError(pod) {
 		log.Info("Pod failed because of resource.")
 		if resource.IsPodHasResource(*pod) {
 			// if pod is failed because of resource, delete resource and deploy pod again.
-			_ = resource.RemoveFinalizer(ctx, p.Client, pod, common.Finalizer)
 			log.Info("Delete it and deploy again with no resource.")
-			if err := p.Client.DeletePod(ctx, pod); err != nil {
-				log.Error(err, "delete pod err")
-				return Result{}, nil
-			}
-			isDeleted := false
-			// wait pod delete for 1min
-			for {
-				_, err := p.Client.GetPod(ctx, pod.Name, pod.Namespace)
-				if err == nil {
-					log.V(1).Info("pod still exists wait.")
-					time.Sleep(time.Microsecond * 500)
-					continue
-				}
-				if apierrors.IsNotFound(err) {
-					isDeleted = true
-					break
-				}
-				if apierrors.IsTimeout(err) {
-					break
-				}
-				if ctx.Err() == context.Canceled || ctx.Err() == context.DeadlineExceeded {
-					break
-				}
-				log.Error(err, "get mountPod err")
-			}
-			if !isDeleted {
-				log.Info("Old pod deleting timeout")
-				return Result{RequeueImmediately: true}, nil
-			}
-			var newPod = &corev1.Pod{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:        pod.Name,
-					Namespace:   pod.Namespace,
-					Labels:      pod.Labels,
-					Annotations: pod.Annotations,
-				},
-				Spec: pod.Spec,
-			}
-			controllerutil.AddFinalizer(newPod, common.Finalizer)
-			resource.SetRequestToZeroOfPod(newPod)
-			err := mkrMp(ctx, *newPod)
-			if err != nil {
-				log.Error(err, "mkdir mount point of pod")
-			}
-			_, err = p.Client.CreatePod(ctx, newPod)
-			if err != nil {
-				log.Error(err, "create pod")
-			}
+			return p.recreatePod(ctx, pod, resource.SetRequestToZeroOfPod)
 		} else {
 			log.Info("mountPod PodResourceError, but pod no resource, do nothing.")
 		}
 	}
+	// check oom killed
+	if config.GlobalConfig.OOMRecovery.IsEnabled() && resource.IsPodOOMKilled(pod) {
+		log.Info("Pod is OOMKilled.")
+		return p.oomRecover(ctx, pod)
+	}
 	log.Info("Pod is error", "reason", pod.Status.Reason, "message", pod.Status.Message)
 	return Result{}, nil
 }
 
+// recreatePod deletes the mount pod, waits until it is deleted and creates it again with mutated spec
+func (p *PodDriver) recreatePod(ctx context.Context, pod *corev1.Pod, mutate func(newPod *corev1.Pod)) (Result, error) {
+	log := util.GenLog(ctx, podDriverLog, "recreatePod")
+	_ = resource.RemoveFinalizer(ctx, p.Client, pod, common.Finalizer)
+	if err := p.Client.DeletePod(ctx, pod); err != nil {
+		log.Error(err, "delete pod err")
+		return Result{}, nil
+	}
+	isDeleted := false
+	// wait pod delete for 1min
+	for {
+		_, err := p.Client.GetPod(ctx, pod.Name, pod.Namespace)
+		if err == nil {
+			log.V(1).Info("pod still exists wait.")
+			time.Sleep(time.Microsecond * 500)
+			continue
+		}
+		if apierrors.IsNotFound(err) {
+			isDeleted = true
+			break
+		}
+		if apierrors.IsTimeout(err) {
+			break
+		}
+		if ctx.Err() == context.Canceled || ctx.Err() == context.DeadlineExceeded {
+			break
+		}
+		log.Error(err, "get mountPod err")
+	}
+	if !isDeleted {
+		log.Info("Old pod deleting timeout")
+		return Result{RequeueImmediately: true}, nil
+	}
+	var newPod = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pod.Name,
+			Namespace:   pod.Namespace,
+			Labels:      pod.Labels,
+			Annotations: pod.Annotations,
+		},
+		Spec: pod.Spec,
+	}
+	controllerutil.AddFinalizer(newPod, common.Finalizer)
+	var oldResources corev1.ResourceRequirements
+	if len(pod.Spec.Containers) > 0 {
+		oldResources = *pod.Spec.Containers[0].Resources.DeepCopy()
+	}
+	mutate(newPod)
+	if err := p.applyResourceBudget(ctx, newPod, pod.Name); err != nil {
+		// the old pod is deleted already, recreate it as it was
+		log.Error(err, "apply resource budget error, keep resources of the old pod")
+		newPod.Spec.Containers[0].Resources = oldResources
+	}
+	err := mkrMp(ctx, *newPod)
+	if err != nil {
+		log.Error(err, "mkdir mount point of pod")
+	}
+	_, err = p.Client.CreatePod(ctx, newPod)
+	if err != nil {
+		log.Error(err, "create pod")
+	}
+	return Result{}, nil
+}
+
 // podDeletedHandler handles mount pod that will be deleted
 func (p *PodDriver) podDeletedHandler(ctx context.Context, pod *corev1.Pod) (Result, error) {
 	if pod == nil {
@@ -442,6 +466,7 @@ func (p *PodDriver) podDeletedHandler(ctx context.Context, pod *corev1.Pod) (Res
 		return Result{}, nil
 	}
 
+	p.archiveCrashLog(ctx, pod)
 	go p.checkMountPodStuck(pod)
 
 	// pod with resource error
@@ -936,8 +961,15 @@ func (p *PodDriver) applyConfigPatch(ctx context.Context, pod *corev1.Pod) error
 		return err
 	}
 	podBuilder := builder.NewPodBuilder(setting, 0)
-	setting.SecretName = fmt.Sprintf("juicefs-%s-secret", pod.Labels[common.PodUniqueIdLabelKey])
+	setting.SecretName = config.MountSecretName(pod.Labels[common.PodUniqueIdLabelKey], setting.ReadOnly)
+	if setting.JuiceFSSecret == nil {
+		// the pod spec is kept, so is the secret it refers to
+		setting.SecretName = config.MountPodSecretName(pod)
+	}
 	secret := podBuilder.NewSecret()
+	if err := builder.EncryptSecret(&secret); err != nil {
+		return err
+	}
 	if setting.JuiceFSSecret != nil {
 		// regenerate pod spec
 		newPod, err := podBuilder.NewMountPod(pod.Name)
@@ -992,6 +1024,18 @@ func (p *PodDriver) applyConfigPatch(ctx context.Context, pod *corev1.Pod) error
 	resource.MergeEnvs(newPod, attr.Env)
 	resource.MergeMountOptions(newPod, setting)
 	resource.MergeVolumes(newPod, setting)
+	// patches are applied to the old pod spec again, skip the ones which are not idempotent
+	patches := make([]config.PodPatch, 0, len(attr.PodPatches))
+	for _, patch := range attr.PodPatches {
+		if err := patch.CheckIdempotent(); err != nil {
+			log.Error(err, "skip pod patch which can not be applied to the old pod spec again")
+			continue
+		}
+		patches = append(patches, patch)
+	}
+	if err := builder.ApplyPodPatches(newPod, patches); err != nil {
+		log.Error(err, "apply pod patches error, ignore them")
+	}
 	if setting.CustomerSecret != nil {
 		if err := resource.CreateOrUpdateSecret(ctx, p.Client, &secret); err != nil {
 			return err
@@ -1185,6 +1229,19 @@ func (p *PodDriver) getUniqueMountPod(ctx context.Context, uniqueId string) bool
 	return false
 }
 
+// applyResourceBudget checks limits of the mount pod which replaces the old one against the total budget of its pvc namespace
+func (p *PodDriver) applyResourceBudget(ctx context.Context, pod *corev1.Pod, replaced string) error {
+	name := pod.Labels[common.MountPodPVCNamespaceLabelKey]
+	if name == "" {
+		return nil
+	}
+	namespace, err := p.Client.GetNamespaceByCache(ctx, name)
+	if err != nil {
+		namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	return resource.ApplyResourceBudget(ctx, p.Client, pod, namespace, replaced)
+}
+
 func (p *PodDriver) newMountPod(ctx context.Context, pod *corev1.Pod, newPodName string) (*corev1.Pod, error) {
 	log := util.GenLog(ctx, podDriverLog, "newMountPod")
 	upgradeUUID := resource.GetUpgradeUUID(pod)
@@ -1208,6 +1265,26 @@ func (p *PodDriver) newMountPod(ctx context.Context, pod *corev1.Pod, newPodName
 	if err := p.applyConfigPatch(ctx, newPod); err != nil {
 		log.Error(err, "apply config patch error, will ignore")
 	}
+	if config.GlobalConfig.ResourceRecommender.ShouldApply() {
+		log.Info("apply recommended resources")
+		applyRecommendedResources(newPod, pod.Annotations)
+	}
+	if config.GlobalConfig.OOMRecovery.IsEnabled() {
+		applyOOMMemoryLimit(newPod, pod.Annotations)
+	}
+	if config.GlobalConfig.MountPodMetrics.IsEnabled() {
+		// keep the metrics port allocated in node
+		if port, err := strconv.ParseInt(pod.Annotations[common.MetricsPortKey], 10, 32); err == nil {
+			resource.SetMetricsPort(newPod, int32(port))
+		}
+		if config.GlobalConfig.MountPodMetrics.ScrapeAnnotations {
+			resource.SetMetricsScrapeAnnotations(newPod)
+		}
+	}
+	if err := p.applyResourceBudget(ctx, newPod, pod.Name); err != nil {
+		log.Error(err, "apply resource budget error")
+		return nil, err
+	}
 	newSupportFusePass := config.SupportFusePass(newPod)
 	if !newSupportFusePass {
 		if oldSupportFusePass {
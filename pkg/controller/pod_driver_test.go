@@ -36,12 +36,14 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
 	k8sexec "k8s.io/utils/exec"
 	"k8s.io/utils/mount"
 
 	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
 	"github.com/juicedata/juicefs-csi-driver/pkg/driver/mocks"
 	"github.com/juicedata/juicefs-csi-driver/pkg/fuse/passfd"
 	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
@@ -1182,3 +1184,43 @@ var _ = Describe("pod handler", Ordered, func() {
 		})
 	})
 })
+
+func TestPodDriver_applyConfigPatch_reapply(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	config.GlobalConfig.MountPodPatch = []config.MountPodPatch{
+		{PodPatch: config.PodPatch{JSONPatch: &runtime.RawExtension{Raw: []byte(`[{"op":"add","path":"/metadata/labels/team","value":"a"}]`)}}},
+		{PodPatch: config.PodPatch{JSONPatch: &runtime.RawExtension{Raw: []byte(`[{"op":"add","path":"/spec/tolerations/-","value":{"key":"extra","operator":"Exists"}}]`)}}},
+	}
+	// mount pod whose secret has no jfsSettings, the patches are applied to its old spec
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "juicefs-node-a-unique-id",
+			Namespace:   "kube-system",
+			Labels:      map[string]string{common.PodUniqueIdLabelKey: "unique-id"},
+			Annotations: map[string]string{common.UniqueId: "unique-id"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:    "jfs-mount",
+				Image:   "juicedata/mount:ee-nightly",
+				Command: []string{"sh", "-c", "exec /sbin/mount.juicefs test /jfs/unique-id -o foreground"},
+			}},
+			Tolerations: []corev1.Toleration{{Key: "a", Operator: corev1.TolerationOpExists}},
+		},
+	}
+	client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "juicefs-unique-id-secret", Namespace: "kube-system"}},
+	)}
+	p := &PodDriver{Client: client}
+	for i := 0; i < 2; i++ {
+		if err := p.applyConfigPatch(context.TODO(), pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pod.Labels["team"] != "a" {
+		t.Errorf("idempotent patch is not applied, labels: %v", pod.Labels)
+	}
+	if len(pod.Spec.Tolerations) != 1 {
+		t.Errorf("patch appending to list should not be applied to the old pod spec, tolerations: %v", pod.Spec.Tolerations)
+	}
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	resourceutil "github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+)
+
+const (
+	recommendPercentile = 90
+	minRecommendCpu     = 10               // millicores
+	minRecommendMemory  = 32 * 1024 * 1024 // bytes
+)
+
+var (
+	recommenderLog = klog.NewKlogr().WithName("resource-recommender")
+)
+
+// resourceRecommender records cpu and memory usage of mount pods on the node,
+// and publishes the recommended requests/limits as mount pod annotations.
+type resourceRecommender struct {
+	client       *k8sclient.K8sClient
+	kc           *k8sclient.KubeletClient
+	usages       map[string]*podUsage // key: pod uid
+	lastSampleAt time.Time
+}
+
+type podUsage struct {
+	cpu    []int64 // millicores
+	memory []int64 // bytes
+}
+
+func newResourceRecommender(client *k8sclient.K8sClient, kc *k8sclient.KubeletClient) *resourceRecommender {
+	return &resourceRecommender{
+		client: client,
+		kc:     kc,
+		usages: make(map[string]*podUsage),
+	}
+}
+
+// run samples usage of mount pods once per sample interval and publishes recommendations.
+// It is called in every loop of node reconciler.
+func (r *resourceRecommender) run(ctx context.Context, podList *corev1.PodList) {
+	cfg := config.GlobalConfig.ResourceRecommender
+	if !cfg.IsEnabled() || podList == nil {
+		r.usages = make(map[string]*podUsage)
+		return
+	}
+	if time.Since(r.lastSampleAt) < cfg.GetSampleInterval() {
+		return
+	}
+	r.lastSampleAt = time.Now()
+
+	summary, err := r.kc.GetStatsSummary()
+	if err != nil {
+		recommenderLog.Error(err, "get stats summary from kubelet error")
+		return
+	}
+	mountPods := make(map[string]*corev1.Pod)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Namespace != config.Namespace || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if value, ok := pod.Labels[common.PodTypeKey]; !ok || value != common.PodTypeValue {
+			continue
+		}
+		mountPods[string(pod.UID)] = pod
+	}
+	r.record(summary, mountPods, cfg.GetMaxSamples())
+
+	for uid, pod := range mountPods {
+		recommendation := r.recommend(uid, cfg.GetMinSamples(), cfg.GetMarginPercent())
+		if recommendation == nil {
+			continue
+		}
+		changed := false
+		for k, v := range recommendation {
+			if pod.Annotations[k] != v {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			continue
+		}
+		recommenderLog.V(1).Info("publish recommended resources", "name", pod.Name, "recommendation", recommendation)
+		if err := resourceutil.AddPodAnnotation(ctx, r.client, pod.Name, pod.Namespace, recommendation); err != nil {
+			recommenderLog.Error(err, "add recommended resources annotation error", "name", pod.Name)
+		}
+	}
+}
+
+// record appends usage of mount container of each mount pod, and drops history of pods that are gone
+func (r *resourceRecommender) record(summary *k8sclient.StatsSummary, mountPods map[string]*corev1.Pod, maxSamples int) {
+	for uid := range r.usages {
+		if _, ok := mountPods[uid]; !ok {
+			delete(r.usages, uid)
+		}
+	}
+	for _, ps := range summary.Pods {
+		if _, ok := mountPods[ps.PodRef.UID]; !ok {
+			continue
+		}
+		for _, cs := range ps.Containers {
+			if cs.Name != common.MountContainerName || cs.CPU == nil || cs.Memory == nil ||
+				cs.CPU.UsageNanoCores == nil || cs.Memory.WorkingSetBytes == nil {
+				continue
+			}
+			usage, ok := r.usages[ps.PodRef.UID]
+			if !ok {
+				usage = &podUsage{}
+				r.usages[ps.PodRef.UID] = usage
+			}
+			usage.cpu = appendSample(usage.cpu, int64(*cs.CPU.UsageNanoCores/1000000), maxSamples)
+			usage.memory = appendSample(usage.memory, int64(*cs.Memory.WorkingSetBytes), maxSamples)
+		}
+	}
+}
+
+// recommend returns the recommended resources as annotations, nil if there are not enough samples.
+// requests are based on the 90th percentile of usage, and limits are based on the peak usage.
+func (r *resourceRecommender) recommend(uid string, minSamples int, marginPercent int) map[string]string {
+	usage, ok := r.usages[uid]
+	if !ok || len(usage.cpu) < minSamples {
+		return nil
+	}
+	withMargin := func(v int64, min int64) int64 {
+		v = v * int64(100+marginPercent) / 100
+		if v < min {
+			return min
+		}
+		return v
+	}
+	roundUpMi := func(v int64) int64 {
+		const mi = 1024 * 1024
+		return (v + mi - 1) / mi * mi
+	}
+	cpuRequest := withMargin(percentile(usage.cpu, recommendPercentile), minRecommendCpu)
+	cpuLimit := withMargin(percentile(usage.cpu, 100), minRecommendCpu)
+	memRequest := roundUpMi(withMargin(percentile(usage.memory, recommendPercentile), minRecommendMemory))
+	memLimit := roundUpMi(withMargin(percentile(usage.memory, 100), minRecommendMemory))
+	return map[string]string{
+		common.RecommendedCpuRequestKey: resource.NewMilliQuantity(cpuRequest, resource.DecimalSI).String(),
+		common.RecommendedCpuLimitKey:   resource.NewMilliQuantity(cpuLimit, resource.DecimalSI).String(),
+		common.RecommendedMemRequestKey: resource.NewQuantity(memRequest, resource.BinarySI).String(),
+		common.RecommendedMemLimitKey:   resource.NewQuantity(memLimit, resource.BinarySI).String(),
+	}
+}
+
+func appendSample(samples []int64, v int64, maxSamples int) []int64 {
+	samples = append(samples, v)
+	if len(samples) > maxSamples {
+		samples = samples[len(samples)-maxSamples:]
+	}
+	return samples
+}
+
+// percentile returns the p-th percentile (nearest-rank) of samples
+func percentile(samples []int64, p int) int64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]int64, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// applyRecommendedResources sets the recommended resources recorded in annotations to mount container
+func applyRecommendedResources(pod *corev1.Pod, annotations map[string]string) {
+	if len(pod.Spec.Containers) == 0 {
+		return
+	}
+	res := &pod.Spec.Containers[0].Resources
+	set := func(list *corev1.ResourceList, name corev1.ResourceName, key string) {
+		v, ok := annotations[key]
+		if !ok {
+			return
+		}
+		q, err := resource.ParseQuantity(v)
+		if err != nil {
+			recommenderLog.Error(err, "parse recommended resource error", "key", key, "value", v)
+			return
+		}
+		if *list == nil {
+			*list = corev1.ResourceList{}
+		}
+		(*list)[name] = q
+	}
+	set(&res.Requests, corev1.ResourceCPU, common.RecommendedCpuRequestKey)
+	set(&res.Requests, corev1.ResourceMemory, common.RecommendedMemRequestKey)
+	set(&res.Limits, corev1.ResourceCPU, common.RecommendedCpuLimitKey)
+	set(&res.Limits, corev1.ResourceMemory, common.RecommendedMemLimitKey)
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		request, hasRequest := res.Requests[name]
+		limit, hasLimit := res.Limits[name]
+		if hasRequest && hasLimit && request.Cmp(limit) > 0 {
+			res.Requests[name] = limit
+		}
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+func genSummary(uid string, cpuMilli, memBytes uint64) *k8sclient.StatsSummary {
+	cpu := cpuMilli * 1000000
+	return &k8sclient.StatsSummary{
+		Pods: []k8sclient.PodStats{{
+			PodRef: k8sclient.PodReference{UID: uid},
+			Containers: []k8sclient.ContainerStats{{
+				Name:   common.MountContainerName,
+				CPU:    &k8sclient.CPUStats{UsageNanoCores: &cpu},
+				Memory: &k8sclient.MemoryStats{WorkingSetBytes: &memBytes},
+			}},
+		}},
+	}
+}
+
+func TestResourceRecommender_recommend(t *testing.T) {
+	r := newResourceRecommender(nil, nil)
+	mountPods := map[string]*corev1.Pod{"uid-1": {ObjectMeta: metav1.ObjectMeta{UID: "uid-1"}}}
+	for i := 1; i <= 10; i++ {
+		r.record(genSummary("uid-1", uint64(i*100), uint64(i)*100*1024*1024), mountPods, 10)
+	}
+	tests := []struct {
+		name          string
+		minSamples    int
+		marginPercent int
+		want          map[string]string
+	}{
+		{
+			name:       "not-enough-samples",
+			minSamples: 11,
+			want:       nil,
+		},
+		{
+			name:          "no-margin",
+			minSamples:    5,
+			marginPercent: 0,
+			want: map[string]string{
+				common.RecommendedCpuRequestKey: "900m",
+				common.RecommendedCpuLimitKey:   "1",
+				common.RecommendedMemRequestKey: "900Mi",
+				common.RecommendedMemLimitKey:   "1000Mi",
+			},
+		},
+		{
+			name:          "with-margin",
+			minSamples:    5,
+			marginPercent: 20,
+			want: map[string]string{
+				common.RecommendedCpuRequestKey: "1080m",
+				common.RecommendedCpuLimitKey:   "1200m",
+				common.RecommendedMemRequestKey: "1080Mi",
+				common.RecommendedMemLimitKey:   "1200Mi",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.recommend("uid-1", tt.minSamples, tt.marginPercent); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recommend() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourceRecommender_record(t *testing.T) {
+	r := newResourceRecommender(nil, nil)
+	mountPods := map[string]*corev1.Pod{"uid-1": {}}
+	for i := 0; i < 5; i++ {
+		r.record(genSummary("uid-1", uint64(i), uint64(i)), mountPods, 3)
+	}
+	r.record(genSummary("uid-2", 1, 1), mountPods, 3)
+	if len(r.usages) != 1 {
+		t.Fatalf("record() usages = %v, want only uid-1", r.usages)
+	}
+	if want := []int64{2, 3, 4}; !reflect.DeepEqual(r.usages["uid-1"].cpu, want) {
+		t.Errorf("record() cpu = %v, want %v", r.usages["uid-1"].cpu, want)
+	}
+	r.record(genSummary("uid-1", 1, 1), map[string]*corev1.Pod{}, 3)
+	if len(r.usages) != 0 {
+		t.Errorf("record() usages of deleted pods = %v, want empty", r.usages)
+	}
+}
+
+func Test_applyRecommendedResources(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("500m"),
+						corev1.ResourceMemory: resource.MustParse("5Gi"),
+					},
+				},
+			}},
+		},
+	}
+	applyRecommendedResources(pod, map[string]string{
+		common.RecommendedCpuRequestKey: "800m",
+		common.RecommendedMemRequestKey: "1Gi",
+		common.RecommendedMemLimitKey:   "2Gi",
+		common.RecommendedCpuLimitKey:   "invalid",
+	})
+	res := pod.Spec.Containers[0].Resources
+	if q := res.Requests[corev1.ResourceCPU]; q.String() != "500m" {
+		t.Errorf("cpu request = %s, want 500m capped by limit", q.String())
+	}
+	if q := res.Requests[corev1.ResourceMemory]; q.String() != "1Gi" {
+		t.Errorf("memory request = %s, want 1Gi", q.String())
+	}
+	if q := res.Limits[corev1.ResourceMemory]; q.String() != "2Gi" {
+		t.Errorf("memory limit = %s, want 2Gi", q.String())
+	}
+}
@@ -92,6 +92,7 @@ func doReconcile(ks *k8sclient.K8sClient, kc *k8sclient.KubeletClient) {
 		Interface: mount.New(""),
 		Exec:      k8sexec.New(),
 	}
+	recommender := newResourceRecommender(ks, kc)
 	for {
 		ctx, cancel := context.WithTimeout(context.Background(), config.ReconcileTimeout)
 		g := errgroup.Group{}
@@ -189,6 +190,7 @@ func doReconcile(ks *k8sclient.K8sClient, kc *k8sclient.KubeletClient) {
 		}
 		backOff.GC()
 		_ = g.Wait()
+		recommender.run(ctx, podList)
 		podList = nil
 
 		cancel()
@@ -30,6 +30,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -44,14 +45,24 @@ import (
 	"github.com/juicedata/juicefs-csi-driver/pkg/config"
 	"github.com/juicedata/juicefs-csi-driver/pkg/juicefs"
 	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/credential"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
 )
 
 var (
 	secretCtrlLog                   = klog.NewKlogr().WithName("secret-controller")
 	secretLastUpdateAtAnnotationKey = "juicefs/last-update-at"
 	secretFieldsHashAnnotationKey   = "juicefs/secret-fields-hash"
+	ceSecretFieldsHashAnnotationKey = "juicefs/ce-secret-fields-hash"
+
+	// eeSecretFields are fields of EE volume secret, initconfig is refreshed when any of them changes
+	eeSecretFields = []string{"token", "name", "access-key", "secret-key", "access-key2", "secret-key2", "bucket", "envs"}
+	// ceSecretFields are fields of CE volume secret, credentials in mount pods are rotated when any of them changes
+	ceSecretFields = []string{"metaurl", "storage", "bucket", "access-key", "secret-key", "envs"}
 )
 
+const reasonCredentialRotation = "CredentialRotation"
+
 type SecretController struct {
 	*k8sclient.K8sClient
 }
@@ -111,8 +122,21 @@ func refreshSecretInitConfig(ctx context.Context, client *k8sclient.K8sClient, n
 		return err
 	}
 
-	if metaurl, found := secrets.Data["metaurl"]; found && len(metaurl) > 0 {
-		secretCtrlLog.V(1).Info("metaurl found in secret, ce volume, ignore", "namespace", namespace, "name", name)
+	secretsMap := make(map[string]string)
+	for k, v := range secrets.Data {
+		secretsMap[k] = string(v[:])
+	}
+	// credentials from the external provider are fetched through the credential cache, and rotated as the ones in secret
+	resolved, _, err := config.ResolveCredentials(ctx, secretsMap)
+	if err != nil {
+		return err
+	}
+	if resolved["metaurl"] != "" {
+		secretCtrlLog.V(1).Info("metaurl found in secret, ce volume, check credential changes", "namespace", namespace, "name", name)
+		return rotateCECredentials(ctx, client, secrets, resolved)
+	}
+	if !config.CacheClientConf {
+		// the secret is only watched for credential rotation of ce volumes
 		return nil
 	}
 
@@ -126,10 +150,6 @@ func refreshSecretInitConfig(ctx context.Context, client *k8sclient.K8sClient, n
 	}
 
 	jfs := juicefs.NewJfsProvider(nil, client)
-	secretsMap := make(map[string]string)
-	for k, v := range secrets.Data {
-		secretsMap[k] = string(v[:])
-	}
 	jfsSetting, err := jfs.Settings(ctx, "", "", "", secretsMap, nil, nil)
 	if err != nil {
 		return err
@@ -138,19 +158,7 @@ func refreshSecretInitConfig(ctx context.Context, client *k8sclient.K8sClient, n
 		secretCtrlLog.V(1).Info("ce volume, no need to refresh initconfig", "namespace", namespace, "name", name)
 		return nil
 	}
-	hashSecretsMap := make(map[string]string)
-	maps.Copy(hashSecretsMap, secretsMap)
-	config.KeysCompatible(hashSecretsMap)
-	hashFields := []string{"token", "name", "access-key", "secret-key", "access-key2", "secret-key2", "bucket", "envs"}
-	var hashParts []string
-	for _, field := range hashFields {
-		if v, ok := hashSecretsMap[field]; ok {
-			hashParts = append(hashParts, field+"="+v)
-		}
-	}
-	sort.Strings(hashParts)
-	h := sha256.Sum256([]byte(strings.Join(hashParts, ";")))
-	currentHash := hex.EncodeToString(h[:])
+	currentHash := hashSecretFields(secretsMap, eeSecretFields)
 
 	storedHash := ""
 	if secrets.Annotations != nil {
@@ -274,6 +282,107 @@ func refreshSecretInitConfig(ctx context.Context, client *k8sclient.K8sClient, n
 	return nil
 }
 
+// hashSecretFields returns the hash of fields in secret
+func hashSecretFields(secretsMap map[string]string, fields []string) string {
+	hashSecretsMap := make(map[string]string)
+	maps.Copy(hashSecretsMap, secretsMap)
+	config.KeysCompatible(hashSecretsMap)
+	var hashParts []string
+	for _, field := range fields {
+		if v, ok := hashSecretsMap[field]; ok {
+			hashParts = append(hashParts, field+"="+v)
+		}
+	}
+	sort.Strings(hashParts)
+	h := sha256.Sum256([]byte(strings.Join(hashParts, ";")))
+	return hex.EncodeToString(h[:])
+}
+
+// rotateCECredentials marks mount pods using the secret of CE volume to rotate credentials when they are changed,
+// CSI Node upgrades the marked mount pods with recreating, see grace.StartCredentialRotator.
+// secretsMap is the data of secrets with credentials resolved from the external provider.
+func rotateCECredentials(ctx context.Context, client *k8sclient.K8sClient, secrets *corev1.Secret, secretsMap map[string]string) error {
+	currentHash := hashSecretFields(secretsMap, ceSecretFields)
+	storedHash := secrets.Annotations[ceSecretFieldsHashAnnotationKey]
+	if currentHash == storedHash {
+		return nil
+	}
+	// the first time the secret is seen, mount pods are created with it already
+	if storedHash != "" {
+		pods, err := listMountPodsOfSecret(ctx, client, secrets.Namespace, secrets.Name)
+		if err != nil {
+			return err
+		}
+		secretCtrlLog.Info("credentials of ce volume changed, rotate them in mount pods", "namespace", secrets.Namespace, "name", secrets.Name, "pods", len(pods))
+		for _, pod := range pods {
+			// the new mount pod uses the latest secret
+			if pod.Annotations[common.CredentialRotationStatusKey] == common.CredentialRotationRunning {
+				continue
+			}
+			if err := resource.AddPodAnnotation(ctx, client, pod.Name, pod.Namespace, map[string]string{
+				common.CredentialRotationHashKey:   currentHash,
+				common.CredentialRotationStatusKey: common.CredentialRotationPending,
+			}); err != nil {
+				secretCtrlLog.Error(err, "mark mount pod to rotate credentials error", "pod", pod.Name)
+				return err
+			}
+			msg := fmt.Sprintf("Credentials in secret %s/%s are changed, mount pod is waiting for rotation", secrets.Namespace, secrets.Name)
+			if err := client.CreateEvent(ctx, pod, corev1.EventTypeNormal, reasonCredentialRotation, msg); err != nil {
+				secretCtrlLog.Error(err, "fail to create event", "pod", pod.Name)
+			}
+		}
+	}
+	if secrets.Annotations == nil {
+		secrets.Annotations = make(map[string]string)
+	}
+	secrets.Annotations[ceSecretFieldsHashAnnotationKey] = currentHash
+	return client.UpdateSecret(ctx, secrets)
+}
+
+// listMountPodsOfSecret lists mount pods of PVs which use the secret
+func listMountPodsOfSecret(ctx context.Context, client *k8sclient.K8sClient, namespace, name string) ([]corev1.Pod, error) {
+	pvs, err := client.ListPersistentVolumes(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	pvNames := make(map[string]bool)
+	for _, pv := range pvs {
+		if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != config.DriverName || pv.Spec.CSI.NodePublishSecretRef == nil {
+			continue
+		}
+		ref := pv.Spec.CSI.NodePublishSecretRef
+		if ref.Name == name && ref.Namespace == namespace {
+			pvNames[pv.Name] = true
+		}
+	}
+	if len(pvNames) == 0 {
+		return nil, nil
+	}
+	pods, err := client.ListPod(ctx, config.Namespace, &metav1.LabelSelector{
+		MatchLabels: map[string]string{common.PodTypeKey: common.PodTypeValue},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []corev1.Pod
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		used := pvNames[pod.Annotations[common.UniqueId]]
+		// mount pods shared by storage class or file system
+		for _, target := range resource.GetAllRefKeys(pod) {
+			if pvNames[config.GetPVNameFromTarget(target)] {
+				used = true
+			}
+		}
+		if used {
+			result = append(result, pod)
+		}
+	}
+	return result, nil
+}
+
 func (m *SecretController) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	name := request.Name
 	namespace := request.Namespace
@@ -297,6 +406,13 @@ func (m *SecretController) Reconcile(ctx context.Context, request reconcile.Requ
 		secretCtrlLog.Error(err, "refresh secret initconfig error", "namespace", namespace, "name", name)
 		return reconcile.Result{}, err
 	}
+	if _, ok := secrets.Data[credential.ProviderKey]; ok {
+		// credentials rotated in the external provider do not change the secret, poll them after the cache expires
+		return reconcile.Result{RequeueAfter: credential.DefaultCache.TTL()}, nil
+	}
+	if !config.CacheClientConf {
+		return reconcile.Result{}, nil
+	}
 	// requeue after to make sure the initconfig is always up-to-date
 	return reconcile.Result{Requeue: true, RequeueAfter: config.SecretReconcilerInterval}, nil
 }
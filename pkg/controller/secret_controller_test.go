@@ -0,0 +1,172 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/credential"
+)
+
+func TestRotateCECredentials(t *testing.T) {
+	defer func(ns string) { config.Namespace = ns }(config.Namespace)
+	config.Namespace = "kube-system"
+
+	pv := func(name, secret string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+				Driver:               config.DriverName,
+				NodePublishSecretRef: &corev1.SecretReference{Name: secret, Namespace: "default"},
+			}}},
+		}
+	}
+	mountPod := func(name string, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   config.Namespace,
+			Labels:      map[string]string{common.PodTypeKey: common.PodTypeValue},
+			Annotations: annotations,
+		}}
+	}
+	target := "/var/lib/kubelet/pods/uid/volumes/kubernetes.io~csi/pv-shared/mount"
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "juicefs-secret", Namespace: "default"},
+		Data:       map[string][]byte{"name": []byte("test"), "metaurl": []byte("redis://:old@redis/1")},
+	}
+	client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset(
+		secret,
+		pv("pv-1", "juicefs-secret"),
+		pv("pv-shared", "juicefs-secret"),
+		pv("pv-other", "other-secret"),
+		mountPod("mount-1", map[string]string{common.UniqueId: "pv-1"}),
+		mountPod("mount-shared", map[string]string{common.UniqueId: "sc", util.GetReferenceKey(target): target}),
+		mountPod("mount-other", map[string]string{common.UniqueId: "pv-other"}),
+	)}
+	ctx := context.TODO()
+	rotate := func() {
+		if err := refreshSecretInitConfig(ctx, client, secret.Name, secret.Namespace); err != nil {
+			t.Fatalf("refreshSecretInitConfig() error = %v", err)
+		}
+	}
+	annotation := func(name, key string) string {
+		pod, err := client.GetPod(ctx, name, config.Namespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pod.Annotations[key]
+	}
+	status := func(name string) string {
+		return annotation(name, common.CredentialRotationStatusKey)
+	}
+	updateSecret := func(data map[string][]byte) {
+		s, err := client.GetSecret(ctx, secret.Name, secret.Namespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Data = data
+		if err := client.UpdateSecret(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the first time only records the hash
+	rotate()
+	if s := status("mount-1"); s != "" {
+		t.Errorf("status of mount-1 = %q, want empty at first time", s)
+	}
+	s, _ := client.GetSecret(ctx, secret.Name, secret.Namespace)
+	if s.Annotations[ceSecretFieldsHashAnnotationKey] == "" || s.Annotations[secretFieldsHashAnnotationKey] != "" {
+		t.Errorf("annotations of secret = %v, want only %s", s.Annotations, ceSecretFieldsHashAnnotationKey)
+	}
+
+	updateSecret(map[string][]byte{"name": []byte("test"), "metaurl": []byte("redis://:new@redis/1")})
+	rotate()
+	for name, want := range map[string]string{
+		"mount-1":      common.CredentialRotationPending,
+		"mount-shared": common.CredentialRotationPending,
+		"mount-other":  "",
+	} {
+		if got := status(name); got != want {
+			t.Errorf("status of %s = %q, want %q", name, got, want)
+		}
+	}
+
+	// credentials from the external provider are rotated when they are changed in the provider
+	defer func(c *credential.Cache) { credential.DefaultCache = c }(credential.DefaultCache)
+	credential.DefaultCache = credential.NewCache(0)
+	dir := t.TempDir()
+	defer config.GlobalConfig.Reset()
+	config.GlobalConfig.CredentialProviders = &config.CredentialProviders{AllowedDirs: []string{dir}}
+	writeMetaurl := func(metaurl string) {
+		if err := os.WriteFile(filepath.Join(dir, "metaurl"), []byte(metaurl), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeMetaurl("redis://:new@redis/1")
+	updateSecret(map[string][]byte{
+		"name":                 []byte("test"),
+		credential.ProviderKey: []byte("file"),
+		credential.PathKey:     []byte(dir),
+	})
+	hash := annotation("mount-1", common.CredentialRotationHashKey)
+	rotate()
+	if got := annotation("mount-1", common.CredentialRotationHashKey); got != hash {
+		t.Errorf("mount-1 should not be marked again if credentials are not changed")
+	}
+	writeMetaurl("redis://:newer@redis/1")
+	rotate()
+	if got := annotation("mount-1", common.CredentialRotationHashKey); got == hash {
+		t.Errorf("mount-1 should be marked when credentials are changed in the provider")
+	}
+	if got := status("mount-other"); got != "" {
+		t.Errorf("status of mount-other = %q, want empty", got)
+	}
+}
+
+func TestRefreshSecretInitConfig_withoutCacheClientConf(t *testing.T) {
+	defer func(c bool) { config.CacheClientConf = c }(config.CacheClientConf)
+	config.CacheClientConf = false
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "juicefs-secret", Namespace: "default", ResourceVersion: "1"},
+		Data:       map[string][]byte{"name": []byte("test"), "token": []byte("token")},
+	}
+	client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset(secret)}
+	ctx := context.TODO()
+	if err := refreshSecretInitConfig(ctx, client, secret.Name, secret.Namespace); err != nil {
+		t.Fatalf("refreshSecretInitConfig() error = %v", err)
+	}
+	s, err := client.GetSecret(ctx, secret.Name, secret.Namespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Annotations) != 0 || s.Data["initconfig"] != nil {
+		t.Errorf("secret of ee volume should not be refreshed without CacheClientConf, got annotations %v", s.Annotations)
+	}
+}
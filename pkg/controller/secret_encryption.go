@@ -0,0 +1,103 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/encryption"
+)
+
+var reEncryptLog = klog.NewKlogr().WithName("secret-reencryptor")
+
+const secretReEncryptInterval = 5 * time.Minute
+
+// StartSecretReEncryptor wraps data keys of encrypted secrets generated for mount pods with the primary key
+// periodically, so that the old key can be removed from secretEncryption.keyFiles after rotation
+func StartSecretReEncryptor(ctx context.Context, client *k8sclient.K8sClient) {
+	reEncryptLog.Info("secret re-encryptor started")
+	for {
+		reEncryptSecrets(ctx, client)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(secretReEncryptInterval):
+		}
+	}
+}
+
+func reEncryptSecrets(ctx context.Context, client *k8sclient.K8sClient) {
+	keyring, err := config.SecretKeyring()
+	if err != nil {
+		reEncryptLog.Error(err, "load keys of secret encryption error")
+		return
+	}
+	if keyring == nil {
+		return
+	}
+	secrets, err := client.ListSecret(ctx, config.Namespace, &metav1.LabelSelector{
+		MatchLabels: map[string]string{common.JuicefsSecretLabelKey: common.True},
+	})
+	if err != nil {
+		reEncryptLog.Error(err, "list secrets error")
+		return
+	}
+	for i := range secrets {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := reEncryptSecret(ctx, client, keyring, &secrets[i]); err != nil {
+			reEncryptLog.Error(err, "re-encrypt secret error", "name", secrets[i].Name)
+		}
+	}
+}
+
+// reEncryptSecret wraps the data key of the secret with the primary key if it is wrapped by an old one
+func reEncryptSecret(ctx context.Context, client *k8sclient.K8sClient, keyring *encryption.Keyring, secret *corev1.Secret) error {
+	data := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	oldKeyID := encryption.KeyID(data)
+	dataKey, changed, err := keyring.Rewrap(data)
+	if err != nil || !changed {
+		return err
+	}
+	// values may be encrypted with a new data key meanwhile, fail on conflict and retry in the next round
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]string{"resourceVersion": secret.ResourceVersion},
+		"data":     map[string][]byte{encryption.DataKeyName: []byte(dataKey)},
+	})
+	if err != nil {
+		return err
+	}
+	if err := client.PatchSecret(ctx, secret, patch, types.MergePatchType); err != nil {
+		return err
+	}
+	reEncryptLog.Info("secret re-encrypted", "name", secret.Name, "oldKey", oldKeyID, "newKey", keyring.Primary())
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/encryption"
+)
+
+func TestReEncryptSecrets(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	defer func(ns string) { config.Namespace = ns }(config.Namespace)
+	config.Namespace = "kube-system"
+	dir := t.TempDir()
+	oldKey, newKey := filepath.Join(dir, "old"), filepath.Join(dir, "new")
+	if err := os.WriteFile(oldKey, []byte("MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE="), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newKey, []byte("YWJjZGVmZ2hpamtsbW5vcHFyc3R1dnd4eXphYmNkZWY="), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config.GlobalConfig.SecretEncryption = &config.SecretEncryption{KeyFiles: []string{oldKey}}
+	keyring, err := config.SecretKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := keyring.Encrypt(map[string]string{"metaurl": "redis://127.0.0.1:6379/0"}, "metaurl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "juicefs-test-secret",
+			Namespace: config.Namespace,
+			Labels:    map[string]string{common.JuicefsSecretLabelKey: common.True},
+		},
+		Data: map[string][]byte{},
+	}
+	for k, v := range encrypted {
+		secret.Data[k] = []byte(v)
+	}
+	client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset(secret)}
+
+	// rotate: the new key is primary, the old one is kept until secrets are re-encrypted
+	config.GlobalConfig.SecretEncryption = &config.SecretEncryption{KeyFiles: []string{newKey, oldKey}}
+	reEncryptSecrets(context.TODO(), client)
+
+	config.GlobalConfig.SecretEncryption = &config.SecretEncryption{KeyFiles: []string{newKey}}
+	rotated, err := client.GetSecret(context.TODO(), secret.Name, config.Namespace)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, secret.Data["metaurl"], rotated.Data["metaurl"], "values should not be changed")
+	data, err := config.DecryptSecretData(rotated)
+	assert.NoError(t, err)
+	assert.Equal(t, "redis://127.0.0.1:6379/0", data["metaurl"])
+	assert.NotEqual(t, encryption.KeyID(encrypted), string(rotated.Data[encryption.DataKeyName])[:8])
+}
@@ -0,0 +1,247 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+	"github.com/juicedata/juicefs-csi-driver/pkg/webhook/handler/mutate"
+)
+
+var sidecarDriftCtrlLog = klog.NewKlogr().WithName("sidecar-drift-controller")
+
+const (
+	reasonSidecarDrift        = "SidecarDrift"
+	reasonSidecarDriftRestart = "SidecarDriftRestart"
+	// retry interval of restart when the workload is not ready or eviction is not allowed by PodDisruptionBudgets
+	sidecarRestartRetryInterval = time.Minute
+)
+
+// SidecarDriftController checks pods injected with sidecars periodically, and labels the pod and records an event
+// if its sidecars differ from the ones webhook would inject now, e.g. the mount image, config or secret is changed.
+// Pods with native sidecars can be evicted to inject new sidecars if restart is enabled.
+type SidecarDriftController struct {
+	*k8sclient.K8sClient
+}
+
+func NewSidecarDriftController(client *k8sclient.K8sClient) *SidecarDriftController {
+	return &SidecarDriftController{client}
+}
+
+func (m *SidecarDriftController) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	cfg := config.GlobalConfig.SidecarDriftReconciler
+	interval := cfg.GetInterval()
+	if !cfg.IsEnabled() {
+		// check again later in case it is enabled
+		return reconcile.Result{RequeueAfter: interval}, nil
+	}
+	sidecarDriftCtrlLog.V(1).Info("Receive pod", "name", request.Name, "namespace", request.Namespace)
+	pod, err := m.GetPod(ctx, request.Name, request.Namespace)
+	if err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return reconcile.Result{}, nil
+		}
+		sidecarDriftCtrlLog.Error(err, "Failed to get pod", "name", request.Name, "namespace", request.Namespace)
+		return reconcile.Result{}, err
+	}
+	if !shouldSidecarDriftInQueue(pod) {
+		return reconcile.Result{}, nil
+	}
+	log := sidecarDriftCtrlLog.WithValues("pod", pod.Name, "namespace", pod.Namespace)
+
+	expected, err := m.expectedSidecarHash(ctx, pod)
+	if err != nil {
+		// PVCs may be deleted, or secrets are not available for now
+		log.Info("can not generate setting of sidecars, check later", "error", err)
+		return reconcile.Result{RequeueAfter: interval}, nil
+	}
+	drifted := pod.Labels[common.SidecarDriftedLabelKey] == common.True
+	if expected == pod.Annotations[common.SidecarHashAnnotationKey] {
+		if drifted {
+			// config is changed back
+			log.Info("sidecars no longer drift, remove label")
+			patch := fmt.Sprintf(`{"metadata":{"labels":{%q:null}}}`, common.SidecarDriftedLabelKey)
+			if err := m.PatchPod(ctx, pod.Name, pod.Namespace, []byte(patch), types.StrategicMergePatchType); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		return reconcile.Result{RequeueAfter: interval}, nil
+	}
+
+	if !drifted {
+		log.Info("sidecars drift from current setting", "hash", pod.Annotations[common.SidecarHashAnnotationKey], "expectedHash", expected)
+		msg := "Sidecars drift from the current config, secret or mount image, recreate the pod to inject new sidecars"
+		if err := m.CreateEvent(ctx, *pod, corev1.EventTypeNormal, reasonSidecarDrift, msg); err != nil {
+			log.Error(err, "fail to create event")
+		}
+		if err := resource.AddPodLabel(ctx, m.K8sClient, pod.Name, pod.Namespace, map[string]string{common.SidecarDriftedLabelKey: common.True}); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if !cfg.CanRestart(time.Now()) || !hasNativeSidecar(pod) {
+		return reconcile.Result{RequeueAfter: interval}, nil
+	}
+	return m.restart(ctx, pod, interval)
+}
+
+// expectedSidecarHash generates hash of sidecars as webhook would inject into the pod now
+func (m *SidecarDriftController) expectedSidecarHash(ctx context.Context, pod *corev1.Pod) (string, error) {
+	pairs, err := resource.GetSidecarVolumes(ctx, m.K8sClient, pod.Namespace, strings.Split(pod.Annotations[common.SidecarPVCsAnnotationKey], ","))
+	if err != nil {
+		return "", err
+	}
+	// webhook generates setting before the pod is scheduled
+	origin := pod.DeepCopy()
+	origin.Spec.NodeName = ""
+	return mutate.GenSidecarHash(ctx, m.K8sClient, origin, pairs)
+}
+
+// restart evicts the pod so that its workload recreates it with new sidecars, only when all pods of the workload are ready,
+// so that pods of a workload are restarted one by one
+func (m *SidecarDriftController) restart(ctx context.Context, pod *corev1.Pod, interval time.Duration) (reconcile.Result, error) {
+	log := sidecarDriftCtrlLog.WithValues("pod", pod.Name, "namespace", pod.Namespace)
+	ready, err := m.isWorkloadReady(ctx, pod)
+	if err != nil {
+		log.V(1).Info("can not restart pod", "error", err)
+		return reconcile.Result{RequeueAfter: interval}, nil
+	}
+	if !ready {
+		log.V(1).Info("workload of pod is not ready, restart it later")
+		return reconcile.Result{RequeueAfter: sidecarRestartRetryInterval}, nil
+	}
+	if err := m.EvictPod(ctx, pod); err != nil {
+		if k8serrors.IsTooManyRequests(err) {
+			log.Info("eviction is not allowed by PodDisruptionBudget for now, restart it later")
+			return reconcile.Result{RequeueAfter: sidecarRestartRetryInterval}, nil
+		}
+		log.Error(err, "evict pod error")
+		return reconcile.Result{}, err
+	}
+	log.Info("pod with drifted sidecars is evicted")
+	if err := m.CreateEvent(ctx, *pod, corev1.EventTypeNormal, reasonSidecarDriftRestart, "Evict the pod to inject new sidecars"); err != nil {
+		log.Error(err, "fail to create event")
+	}
+	return reconcile.Result{}, nil
+}
+
+// isWorkloadReady checks if all pods of the ReplicaSet, StatefulSet or DaemonSet owning the pod are ready
+func (m *SidecarDriftController) isWorkloadReady(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return false, fmt.Errorf("pod is not owned by any workload")
+	}
+	var (
+		uid      types.UID
+		selector *metav1.LabelSelector
+		desired  int32
+	)
+	switch owner.Kind {
+	case "ReplicaSet":
+		rs, err := m.GetReplicaSet(ctx, owner.Name, pod.Namespace)
+		if err != nil {
+			return false, err
+		}
+		uid, selector, desired = rs.UID, rs.Spec.Selector, ptr.Deref(rs.Spec.Replicas, 1)
+	case "StatefulSet":
+		sts, err := m.GetStatefulSet(ctx, owner.Name, pod.Namespace)
+		if err != nil {
+			return false, err
+		}
+		uid, selector, desired = sts.UID, sts.Spec.Selector, ptr.Deref(sts.Spec.Replicas, 1)
+	case "DaemonSet":
+		ds, err := m.GetDaemonSet(ctx, owner.Name, pod.Namespace)
+		if err != nil {
+			return false, err
+		}
+		uid, selector, desired = ds.UID, ds.Spec.Selector, ds.Status.DesiredNumberScheduled
+	default:
+		return false, fmt.Errorf("pod owned by %s is not supported", owner.Kind)
+	}
+	// list pods from apiserver instead of status of the workload, which may not be updated after the last eviction
+	pods, err := m.ListPod(ctx, pod.Namespace, selector, nil)
+	if err != nil {
+		return false, err
+	}
+	var ready int32
+	for i := range pods {
+		p := &pods[i]
+		if p.DeletionTimestamp == nil && metav1.IsControlledBy(p, &metav1.ObjectMeta{UID: uid}) && resource.IsPodReady(p) {
+			ready++
+		}
+	}
+	return ready >= desired, nil
+}
+
+// shouldSidecarDriftInQueue checks if the pod is injected with sidecars whose hash is recorded
+func shouldSidecarDriftInQueue(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Labels[common.InjectSidecarDone] != common.True {
+		return false
+	}
+	return pod.Annotations[common.SidecarHashAnnotationKey] != "" && pod.Annotations[common.SidecarPVCsAnnotationKey] != ""
+}
+
+func hasNativeSidecar(pod *corev1.Pod) bool {
+	for _, cn := range pod.Spec.InitContainers {
+		if strings.Contains(cn.Name, common.MountContainerName) && cn.RestartPolicy != nil && *cn.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *SidecarDriftController) SetupWithManager(mgr ctrl.Manager) error {
+	sidecarDriftCtrlLog.V(1).Info("SetupWithManager", "name", "sidecar-drift-controller")
+	c, err := controller.New("sidecar-drift", mgr, controller.Options{Reconciler: m})
+	if err != nil {
+		return err
+	}
+
+	// pods are checked periodically after created, since changes of config and secrets are not events of pods
+	return c.Watch(source.Kind(mgr.GetCache(), &corev1.Pod{}, &handler.TypedEnqueueRequestForObject[*corev1.Pod]{}, predicate.TypedFuncs[*corev1.Pod]{
+		CreateFunc: func(event event.TypedCreateEvent[*corev1.Pod]) bool {
+			return shouldSidecarDriftInQueue(event.Object)
+		},
+		UpdateFunc: func(updateEvent event.TypedUpdateEvent[*corev1.Pod]) bool {
+			return false
+		},
+		DeleteFunc: func(deleteEvent event.TypedDeleteEvent[*corev1.Pod]) bool {
+			return false
+		},
+	}))
+}
@@ -0,0 +1,144 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+	"github.com/juicedata/juicefs-csi-driver/pkg/webhook/handler/mutate"
+)
+
+func TestSidecarDriftController_Reconcile(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	config.GlobalConfig.SidecarDriftReconciler = &config.SidecarDriftReconciler{Enable: true, Restart: true}
+	ctx := context.TODO()
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "demo"}}
+	clientset := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "juicefs-secret", Namespace: "default"},
+			Data:       map[string][]byte{"name": []byte("test"), "metaurl": []byte("redis://127.0.0.1:6379/0")},
+		},
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-data"},
+			Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+				Driver:               config.DriverName,
+				VolumeHandle:         "pv-data",
+				NodePublishSecretRef: &corev1.SecretReference{Name: "juicefs-secret", Namespace: "default"},
+			}}},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-data"},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default", UID: "uid-rs"},
+			Spec:       appsv1.ReplicaSetSpec{Replicas: ptr.To(int32(1)), Selector: selector},
+		},
+	)
+	client := &k8sclient.K8sClient{Interface: clientset}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "demo-abcde",
+			Namespace:       "default",
+			Labels:          map[string]string{"app": "demo", common.InjectSidecarDone: common.True},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "demo", UID: "uid-rs", Controller: ptr.To(true)}},
+		},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: common.MountContainerName, RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways)}},
+			Containers:     []corev1.Container{{Name: "app"}},
+		},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			{Type: corev1.ContainersReady, Status: corev1.ConditionTrue},
+		}},
+	}
+	pairs, err := resource.GetSidecarVolumes(ctx, client, "default", []string{"data"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := mutate.GenSidecarHash(ctx, client, pod, pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod.Annotations = map[string]string{common.SidecarHashAnnotationKey: hash, common.SidecarPVCsAnnotationKey: "data"}
+	if _, err := client.CoreV1().Pods("default").Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewSidecarDriftController(client)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}}
+	result, err := c.Reconcile(ctx, request)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Errorf("pod is not checked periodically")
+	}
+	got, _ := client.GetPod(ctx, pod.Name, pod.Namespace)
+	if got.Labels[common.SidecarDriftedLabelKey] != "" {
+		t.Errorf("pod without drift is labeled drifted")
+	}
+
+	// mount image is changed, and the pod is evicted
+	config.GlobalConfig.MountPodPatch = []config.MountPodPatch{{CEMountImage: "juicedata/mount:ce-v1.3.0"}}
+	if _, err := c.Reconcile(ctx, request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	events, _ := client.CoreV1().Events("default").List(ctx, metav1.ListOptions{})
+	reasons := map[string]bool{}
+	for _, e := range events.Items {
+		reasons[e.Reason] = true
+	}
+	if !reasons[reasonSidecarDrift] || !reasons[reasonSidecarDriftRestart] {
+		t.Errorf("events = %v, want %s and %s", reasons, reasonSidecarDrift, reasonSidecarDriftRestart)
+	}
+	evicted := false
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "create" && action.GetSubresource() == "eviction" {
+			evicted = true
+		}
+	}
+	if !evicted {
+		t.Errorf("drifted pod is not evicted")
+	}
+}
+
+func Test_hasNativeSidecar(t *testing.T) {
+	native := &corev1.Pod{Spec: corev1.PodSpec{InitContainers: []corev1.Container{
+		{Name: common.MountContainerName, RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways)},
+	}}}
+	if !hasNativeSidecar(native) {
+		t.Errorf("hasNativeSidecar() = false for native sidecar")
+	}
+	legacy := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: common.MountContainerName}}}}
+	if hasNativeSidecar(legacy) {
+		t.Errorf("hasNativeSidecar() = true for sidecar in containers")
+	}
+}
@@ -90,6 +90,10 @@ func (api *API) Handle(group *gin.RouterGroup) {
 	group.GET("/config/pvcs", api.listPVCWithSelectorHandler())
 	group.POST("/config/pvcs/selector", api.listPVCWithSelectorHandler())
 	group.GET("/config/diff", api.getCSIConfigDiff())
+	group.GET("/config/revisions", api.listCSIConfigRevisions())
+	group.GET("/config/revisions/:revision", api.getCSIConfigRevision())
+	group.GET("/config/revisions/:revision/diff", api.diffCSIConfigRevision())
+	group.POST("/config/revisions/:revision/rollback", api.rollbackCSIConfig())
 
 	podGroup := group.Group("/pod/:namespace/:name", api.getPodMiddileware())
 	podGroup.GET("/", api.getPodHandler())
@@ -109,6 +113,8 @@ func (api *API) Handle(group *gin.RouterGroup) {
 	pvGroup.GET("/", api.getPVHandler())
 	pvGroup.GET("/mountpods", api.getMountPodsOfPV())
 	pvGroup.GET("/events", api.getPVEvents())
+	pvGroup.GET("/crashlogs", api.listCrashLogsOfPV())
+	pvGroup.GET("/crashlogs/:node/:uniqueid/:archive", api.downloadCrashLog())
 
 	pvcGroup := group.Group("/pvc/:namespace/:name", api.getPVCMiddileware())
 	pvcGroup.GET("/", api.getPVCHandler())
@@ -17,6 +17,7 @@
 package dashboard
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -24,11 +25,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
 
 	"github.com/juicedata/juicefs-csi-driver/pkg/common"
 	"github.com/juicedata/juicefs-csi-driver/pkg/config"
 )
 
+var cmLog = klog.NewKlogr().WithName("config")
+
 func (api *API) getCSIConfig() gin.HandlerFunc {
 	cmName := config.GetGlobalConfigName()
 	return func(c *gin.Context) {
@@ -57,48 +61,135 @@ func (api *API) putCSIConfig() gin.HandlerFunc {
 			c.JSON(400, gin.H{"error": "invalid config map name"})
 			return
 		}
+		cm.Namespace = api.sysNamespace
 		// validate global config
-		cfg := &config.Config{}
-		d := cm.Data["config.yaml"]
-		if err := cfg.Unmarshal([]byte(d)); err != nil {
+		if err := config.ValidateConfigData(cm.Data[config.ConfigDataKey]); err != nil {
 			c.JSON(400, gin.H{"error": err.Error()})
 			return
 		}
-		if err := cfg.Validate(); err != nil {
-			c.JSON(400, gin.H{"error": err.Error()})
+
+		updated, err := config.UpdateConfigMapWithRevision(c, api.client, &cm, configAuthor(c))
+		if err != nil {
+			if updated == nil {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+			// config map is updated, but its revision is not recorded
+			cmLog.Error(err, "record config revision error")
+		}
+		if err := api.notifyCSINodes(c); err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
 			return
 		}
+		c.JSON(200, updated)
+	}
+}
+
+// configAuthor returns the user of basic auth, or "dashboard" if auth is disabled
+func configAuthor(c *gin.Context) string {
+	if user := c.GetString(gin.AuthUserKey); user != "" {
+		return user
+	}
+	return "dashboard"
+}
 
-		_, err := api.client.CoreV1().ConfigMaps(api.sysNamespace).Update(c, &cm, metav1.UpdateOptions{})
+// notifyCSINodes updates annotation of csi node pods, so that the mounted config is refreshed by kubelet in time
+func (api *API) notifyCSINodes(c *gin.Context) error {
+	s, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			"app.kubernetes.io/name": "juicefs-csi-driver",
+			"app":                    "juicefs-csi-node",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("parse label selector error %v", err)
+	}
+	csiNodeList, err := api.client.CoreV1().Pods(api.sysNamespace).List(c, metav1.ListOptions{LabelSelector: s.String()})
+	if err != nil {
+		return fmt.Errorf("list csi node error %v", err)
+	}
+	for _, pod := range csiNodeList.Items {
+		if pod.Annotations == nil {
+			pod.Annotations = make(map[string]string)
+		}
+		pod.Annotations["juicefs/update-time"] = metav1.Now().Format("2006-01-02T15:04:05Z")
+		_, err = api.client.CoreV1().Pods(api.sysNamespace).Update(c, &pod, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (api *API) listCSIConfigRevisions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revisions, err := config.ListConfigRevisions(c, api.client, api.sysNamespace, config.GetGlobalConfigName())
 		if err != nil {
 			c.JSON(500, gin.H{"error": err.Error()})
 			return
 		}
-		s, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
-			MatchLabels: map[string]string{
-				"app.kubernetes.io/name": "juicefs-csi-driver",
-				"app":                    "juicefs-csi-node",
-			},
-		})
+		c.JSON(200, revisions)
+	}
+}
+
+func (api *API) getCSIConfigRevision() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revision, err := strconv.Atoi(c.Param("revision"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid revision"})
+			return
+		}
+		r, err := config.GetConfigRevision(c, api.client, api.sysNamespace, config.GetGlobalConfigName(), revision)
 		if err != nil {
-			c.String(500, "parse label selector error %v", err)
+			c.JSON(404, gin.H{"error": err.Error()})
 			return
 		}
-		csiNodeList, err := api.client.CoreV1().Pods(api.sysNamespace).List(c, metav1.ListOptions{LabelSelector: s.String()})
+		c.JSON(200, r)
+	}
+}
+
+// diffCSIConfigRevision returns the diff from the revision to the one in query "to", or to the current config
+func (api *API) diffCSIConfigRevision() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revision, err := strconv.Atoi(c.Param("revision"))
 		if err != nil {
-			c.String(500, "list csi node error %v", err)
+			c.JSON(400, gin.H{"error": "invalid revision"})
 			return
 		}
-		for _, pod := range csiNodeList.Items {
-			if pod.Annotations == nil {
-				pod.Annotations = make(map[string]string)
+		to := 0
+		if v := c.Query("to"); v != "" {
+			if to, err = strconv.Atoi(v); err != nil {
+				c.JSON(400, gin.H{"error": "invalid revision to diff with"})
+				return
 			}
-			pod.Annotations["juicefs/update-time"] = metav1.Now().Format("2006-01-02T15:04:05Z")
-			_, err = api.client.CoreV1().Pods(api.sysNamespace).Update(c, &pod, metav1.UpdateOptions{})
-			if err != nil {
-				c.JSON(500, gin.H{"error": err.Error()})
+		}
+		diff, err := config.DiffConfigRevisions(c, api.client, api.sysNamespace, config.GetGlobalConfigName(), revision, to)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(200, gin.H{"diff": diff})
+	}
+}
+
+func (api *API) rollbackCSIConfig() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revision, err := strconv.Atoi(c.Param("revision"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid revision"})
+			return
+		}
+		cm, err := config.RollbackConfigMap(c, api.client, api.sysNamespace, config.GetGlobalConfigName(), revision, configAuthor(c))
+		if err != nil {
+			if cm == nil {
+				c.JSON(400, gin.H{"error": err.Error()})
 				return
 			}
+			cmLog.Error(err, "record config revision error")
+		}
+		if err := api.notifyCSINodes(c); err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
 		}
 		c.JSON(200, cm)
 	}
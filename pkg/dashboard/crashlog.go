@@ -0,0 +1,132 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/crashlog"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+)
+
+const csiNodeContainerName = "juicefs-plugin"
+
+var crashLogLog = klog.NewKlogr().WithName("crashlog")
+
+// uniqueIdsOfPV returns the uniqueIds which mount pods of pv may use, the same as LabelSelectorOfMount
+func uniqueIdsOfPV(pv *corev1.PersistentVolume) []string {
+	if pv.Spec.CSI == nil {
+		return nil
+	}
+	values := []string{pv.Spec.CSI.VolumeHandle}
+	if pv.Spec.StorageClassName != "" {
+		values = append(values, pv.Spec.StorageClassName)
+	}
+	return values
+}
+
+// listCrashLogsOfPV lists crash log archives of mount pods of pv in all csi nodes
+func (api *API) listCrashLogsOfPV() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		obj, ok := c.Get("pv")
+		if !ok {
+			c.String(404, "not found")
+			return
+		}
+		pv := obj.(*corev1.PersistentVolume)
+		if err := config.LoadFromConfigMap(c, api.client); err != nil {
+			crashLogLog.Error(err, "load config error")
+		}
+		dir := config.GlobalConfig.CrashLogArchive.GetPath()
+
+		csiNodes, err := api.podSvc.ListCSINodePod(c, "")
+		if err != nil {
+			c.String(500, "list csi node pods error %v", err)
+			return
+		}
+		archives := []crashlog.Archive{}
+		for _, csiNode := range csiNodes {
+			if csiNode.Status.Phase != corev1.PodRunning {
+				continue
+			}
+			for _, uniqueId := range uniqueIdsOfPV(pv) {
+				if !crashlog.ValidName(uniqueId) {
+					continue
+				}
+				stdout, _, err := api.client.ExecuteInContainer(c, csiNode.Name, csiNode.Namespace, csiNodeContainerName, crashlog.ListCommand(dir, uniqueId))
+				if err != nil {
+					crashLogLog.Error(err, "list crash logs in csi node error", "node", csiNode.Spec.NodeName)
+					continue
+				}
+				archives = append(archives, crashlog.ParseListOutput(csiNode.Spec.NodeName, uniqueId, stdout)...)
+			}
+		}
+		sort.Slice(archives, func(i, j int) bool {
+			return archives[i].Time.After(archives[j].Time)
+		})
+		c.IndentedJSON(200, archives)
+	}
+}
+
+// downloadCrashLog downloads a crash log archive of pv from csi node
+func (api *API) downloadCrashLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		obj, ok := c.Get("pv")
+		if !ok {
+			c.String(404, "not found")
+			return
+		}
+		pv := obj.(*corev1.PersistentVolume)
+		nodeName := c.Param("node")
+		uniqueId := c.Param("uniqueid")
+		name := c.Param("archive")
+		if !util.ContainsString(uniqueIdsOfPV(pv), uniqueId) {
+			c.String(404, "not found")
+			return
+		}
+		if err := config.LoadFromConfigMap(c, api.client); err != nil {
+			crashLogLog.Error(err, "load config error")
+		}
+		path, err := crashlog.Path(config.GlobalConfig.CrashLogArchive.GetPath(), uniqueId, name)
+		if err != nil {
+			c.String(400, "%v", err)
+			return
+		}
+		csiNodes, err := api.podSvc.ListCSINodePod(c, nodeName)
+		if err != nil {
+			c.String(500, "get csi node error %v", err)
+			return
+		}
+		if len(csiNodes) == 0 {
+			c.String(404, "csi node not found")
+			return
+		}
+		csiNode := csiNodes[0]
+		c.Header("Content-Type", "application/gzip")
+		c.Header("Content-Disposition", "attachment; filename="+nodeName+"_"+name)
+		if err := resource.DownloadPodFile(c.Request.Context(), api.client, api.kubeconfig, c.Writer,
+			csiNode.Namespace, csiNode.Name, csiNodeContainerName, []string{"cat", path}); err != nil {
+			crashLogLog.Error(err, "download crash log error", "node", nodeName, "path", path)
+		}
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	k8sexec "k8s.io/utils/exec"
@@ -206,7 +207,8 @@ func (d *nodeService) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 	}
 
 	options := []string{}
-	if req.GetReadonly() || req.VolumeCapability.AccessMode.GetMode() == csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY {
+	readOnly := req.GetReadonly() || req.VolumeCapability.AccessMode.GetMode() == csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY
+	if readOnly {
 		options = append(options, "ro")
 	}
 	if m := volCap.GetMount(); m != nil {
@@ -223,6 +225,10 @@ func (d *nodeService) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 	}
 	mountOptions = append(mountOptions, options...)
 
+	if err := d.checkFSAccess(ctxWithLog, volumeID, volCtx, secrets, mountOptions); err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "%v", err)
+	}
+
 	log.Info("mounting juicefs", "secret", fmt.Sprintf("%+v", reflect.ValueOf(secrets).MapKeys()), "options", mountOptions)
 	jfs, err := d.juicefs.JfsMount(ctxWithLog, volumeID, target, secrets, volCtx, mountOptions)
 	if err != nil {
@@ -250,6 +256,8 @@ func (d *nodeService) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 		log.Info("quota already set in controller, skipping SetQuota in node")
 	} else if config.GlobalConfig.EnableSetQuota != nil && !*config.GlobalConfig.EnableSetQuota {
 		log.Info("quota setting disabled, skipping SetQuota")
+	} else if readOnly {
+		log.Info("volume is read-only, skipping SetQuota")
 	} else if cap, exist := volCtx["capacity"]; exist {
 		capacity, err := strconv.ParseInt(cap, 10, 64)
 		if err != nil {
@@ -281,10 +289,42 @@ func (d *nodeService) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 		})
 	}
 
+	d.warmupVolume(ctxWithLog, volCtx, jfs, bindSource)
+
 	log.Info("juicefs volume mounted", "volumeId", volumeID, "target", target, "elapsed", time.Since(start).String())
 	return &csi.NodePublishVolumeResponse{}, nil
 }
 
+// checkFSAccess checks if the namespace of app pod can use the file system at the path by fsAccessPolicies,
+// and records an event on the app pod if not.
+func (d *nodeService) checkFSAccess(ctx context.Context, volumeID string, volCtx map[string]string, secrets map[string]string, mountOptions []string) error {
+	if d.k8sClient == nil || len(config.GlobalConfig.FSAccessPolicies) == 0 {
+		return nil
+	}
+	log := util.GenLog(ctx, klog.NewKlogr(), "checkFSAccess")
+	namespace := volCtx[common.PodInfoNamespace]
+	var secretRef *corev1.SecretReference
+	pv, pvc, err := resource.GetPVWithVolumeHandleOrAppInfo(ctx, d.k8sClient, volumeID, volCtx)
+	if err != nil {
+		log.V(1).Info("get pv of volume error", "error", err)
+	}
+	if pv != nil && pv.Spec.CSI != nil {
+		secretRef = pv.Spec.CSI.NodePublishSecretRef
+	}
+	if namespace == "" && pvc != nil {
+		namespace = pvc.Namespace
+	}
+	if namespace == "" {
+		return fmt.Errorf("namespace of volume %s is unknown, podInfoOnMount of CSIDriver should be enabled to check fsAccessPolicies", volumeID)
+	}
+	err = resource.CheckFSAccess(ctx, d.k8sClient, namespace, secretRef, secrets["name"], config.PathInFS(mountOptions, volCtx["subPath"]))
+	if err != nil && volCtx[common.PodInfoName] != "" {
+		ref := corev1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: namespace, Name: volCtx[common.PodInfoName]}
+		resource.RecordFSAccessDenied(ctx, d.k8sClient, ref, "juicefs-csi-node", err)
+	}
+	return err
+}
+
 // NodeUnpublishVolume is a reverse operation of NodePublishVolume. This RPC is typically called by the CO when the workload using the volume is being moved to a different node, or all the workload using the volume on a node has finished.
 func (d *nodeService) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
 	log := klog.NewKlogr().WithName("NodeUnpublishVolume")
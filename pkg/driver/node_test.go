@@ -25,6 +25,7 @@ import (
 	"os"
 	"os/exec"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 
@@ -33,11 +34,16 @@ import (
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/klog/v2"
 	k8sexec "k8s.io/utils/exec"
 	"k8s.io/utils/mount"
 
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
 	"github.com/juicedata/juicefs-csi-driver/pkg/config"
 	"github.com/juicedata/juicefs-csi-driver/pkg/juicefs"
 	"github.com/juicedata/juicefs-csi-driver/pkg/juicefs/mocks"
@@ -98,6 +104,7 @@ var _ = Describe("nodeService", func() {
 				mockJfs := mocks.NewMockJfs(mockCtl)
 				mockJfs.EXPECT().CreateVol(ctx, volumeId, subPath).Return(bindSource, nil)
 				mockJfs.EXPECT().BindTarget(ctx, bindSource, targetPath).Return(nil)
+				mockJfs.EXPECT().GetSetting().Return(&config.JfsSetting{})
 				mockJuicefs := mocks.NewMockInterface(mockCtl)
 				mockJuicefs.EXPECT().JfsMount(ctx, volumeId, targetPath, secret, volumeCtx, []string{"ro"}).Return(mockJfs, nil)
 				mockJuicefs.EXPECT().CreateTarget(ctx, targetPath).Return(nil)
@@ -142,6 +149,7 @@ var _ = Describe("nodeService", func() {
 				mockJfs := mocks.NewMockJfs(mockCtl)
 				mockJfs.EXPECT().CreateVol(ctx, volumeId, subPath).Return(bindSource, nil)
 				mockJfs.EXPECT().BindTarget(ctx, bindSource, targetPath).Return(nil)
+				mockJfs.EXPECT().GetSetting().Return(&config.JfsSetting{})
 				mockJuicefs := mocks.NewMockInterface(mockCtl)
 				mockJuicefs.EXPECT().JfsMount(ctx, volumeId, targetPath, secret, volumeCtx, mountOptions).Return(mockJfs, nil)
 				mockJuicefs.EXPECT().CreateTarget(ctx, targetPath).Return(nil)
@@ -186,6 +194,7 @@ var _ = Describe("nodeService", func() {
 				mockJfs := mocks.NewMockJfs(mockCtl)
 				mockJfs.EXPECT().CreateVol(ctx, volumeId, subPath).Return(bindSource, nil)
 				mockJfs.EXPECT().BindTarget(ctx, bindSource, targetPath).Return(nil)
+				mockJfs.EXPECT().GetSetting().Return(&config.JfsSetting{})
 				mockJuicefs := mocks.NewMockInterface(mockCtl)
 				mockJuicefs.EXPECT().JfsMount(ctx, volumeId, targetPath, secret, volumeCtx, mountOptions).Return(mockJfs, nil)
 				mockJuicefs.EXPECT().CreateTarget(ctx, targetPath).Return(nil)
@@ -859,3 +868,89 @@ func Test_nodeService_NodeUnstageVolume(t *testing.T) {
 		})
 	}
 }
+
+func Test_nodeService_NodePublishVolume_fsAccessPolicies(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	config.GlobalConfig.FSAccessPolicies = []config.FSAccessPolicy{{
+		Namespaces:     []string{"tenant-a"},
+		SubdirPrefixes: []string{"/tenant-a"},
+	}}
+	stdVolCap := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER},
+	}
+	tests := []struct {
+		name       string
+		volCtx     map[string]string
+		wantCode   codes.Code
+		wantErr    string
+		wantEvents int
+	}{
+		{
+			name:     "allowed",
+			volCtx:   map[string]string{common.PodInfoName: "app", common.PodInfoNamespace: "tenant-a", "subPath": "tenant-a/data"},
+			wantCode: codes.Internal,
+		},
+		{
+			name:       "denied",
+			volCtx:     map[string]string{common.PodInfoName: "app", common.PodInfoNamespace: "tenant-a", "subPath": "tenant-b"},
+			wantCode:   codes.PermissionDenied,
+			wantErr:    "namespace tenant-a is not allowed",
+			wantEvents: 1,
+		},
+		{
+			name:     "namespace-unknown",
+			volCtx:   map[string]string{"subPath": "tenant-a"},
+			wantCode: codes.PermissionDenied,
+			wantErr:  "podInfoOnMount of CSIDriver should be enabled",
+		},
+	}
+	registerer, _ := util.NewPrometheus(config.NodeName)
+	metrics := newNodeMetrics(registerer)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			mockJuicefs := mocks.NewMockInterface(mockCtl)
+			mockJuicefs.EXPECT().CreateTarget(gomock.Any(), gomock.Any()).Return(nil)
+			if tt.wantCode == codes.Internal {
+				// the volume is mounted only when it is allowed
+				mockJuicefs.EXPECT().JfsMount(gomock.Any(), "vol-test", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("mount error"))
+			}
+			client := &k8s.K8sClient{Interface: fake.NewSimpleClientset()}
+			d := &nodeService{
+				juicefs:            mockJuicefs,
+				k8sClient:          client,
+				metrics:            metrics,
+				SafeFormatAndMount: mount.SafeFormatAndMount{Interface: mount.New(""), Exec: k8sexec.New()},
+				unmountedPaths:     &sync.Map{},
+				volLocks:           resource.NewVolumeLocks(),
+			}
+			_, err := d.NodePublishVolume(context.TODO(), &csi.NodePublishVolumeRequest{
+				VolumeId:         "vol-test",
+				TargetPath:       t.TempDir() + "/target",
+				VolumeCapability: stdVolCap,
+				Secrets:          map[string]string{"name": "test"},
+				VolumeContext:    tt.volCtx,
+			})
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("NodePublishVolume() code = %v, want %v, error: %v", got, tt.wantCode, err)
+			}
+			if tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NodePublishVolume() error = %v, want %q", err, tt.wantErr)
+			}
+			events, err := client.CoreV1().Events("tenant-a").List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events.Items) != tt.wantEvents {
+				t.Fatalf("got %d events, want %d", len(events.Items), tt.wantEvents)
+			}
+			for _, e := range events.Items {
+				if e.Reason != resource.ReasonFSAccessDenied || e.Type != corev1.EventTypeWarning || e.InvolvedObject.Kind != "Pod" || e.InvolvedObject.Name != "app" {
+					t.Errorf("unexpected event: %+v", e)
+				}
+			}
+		})
+	}
+}
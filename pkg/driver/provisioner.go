@@ -22,8 +22,6 @@ import (
 	"fmt"
 	"os"
 	"path"
-	"strconv"
-	"strings"
 	"time"
 
 	"google.golang.org/grpc/codes"
@@ -155,26 +153,20 @@ func (j *provisionerService) Provision(ctx context.Context, options provisioncon
 		return nil, provisioncontroller.ProvisioningFinished, fmt.Errorf("claim Selector is not supported")
 	}
 
-	pvMeta := resource.NewObjectMeta(*options.PVC, options.SelectedNode)
-
 	pvName := options.PVName
-	scParams := make(map[string]string)
-	for k, v := range options.StorageClass.Parameters {
-		if strings.HasPrefix(k, "csi.storage.k8s.io/") {
-			scParams[k] = pvMeta.ResolveSecret(v, pvName)
-		} else {
-			scParams[k] = pvMeta.StringParser(options.StorageClass.Parameters[k])
-		}
+	vol, err := resource.ResolveDynamicVolume(pvName, *options.PVC, options.SelectedNode, options.StorageClass)
+	if err != nil {
+		j.metrics.provisionErrors.Inc()
+		return nil, provisioncontroller.ProvisioningFinished, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
+	scParams, subPath, mountOptions, volCtx := vol.Params, vol.SubPath, vol.MountOptions, vol.VolCtx
 	provisionerLog.V(1).Info("Resolved StorageClass.Parameters", "params", scParams)
 
-	subPath := pvName
-	if scParams["pathPattern"] != "" {
-		subPath = scParams["pathPattern"]
-	}
 	// return error if set readonly in dynamic provisioner
+	readOnly := false
 	for _, am := range options.PVC.Spec.AccessModes {
 		if am == corev1.ReadOnlyMany {
+			readOnly = true
 			if options.StorageClass.Parameters["pathPattern"] == "" {
 				j.metrics.provisionErrors.Inc()
 				return nil, provisioncontroller.ProvisioningFinished, status.Errorf(codes.InvalidArgument, "Dynamic mounting uses the sub-path named pv name as data isolation, so read-only mode cannot be used.")
@@ -183,21 +175,16 @@ func (j *provisionerService) Provision(ctx context.Context, options provisioncon
 			}
 		}
 	}
-
-	mountOptions := make([]string, 0)
-	for _, mo := range options.StorageClass.MountOptions {
-		parsedStr := pvMeta.StringParser(mo)
-		mountOptions = append(mountOptions, strings.Split(strings.TrimSpace(parsedStr), ",")...)
-	}
 	provisionerLog.V(1).Info("Resolved MountOptions", "options", mountOptions)
 
-	// set volume context
-	volCtx := make(map[string]string)
-	volCtx["subPath"] = subPath
-	volCtx["capacity"] = strconv.FormatInt(options.PVC.Spec.Resources.Requests.Storage().Value(), 10)
-	for k, v := range scParams {
-		volCtx[k] = v
+	secretRef := &corev1.SecretReference{Name: scParams[common.PublishSecretName], Namespace: scParams[common.PublishSecretNamespace]}
+	if err := resource.CheckFSAccess(ctx, j.K8sClient, options.PVC.Namespace, secretRef, "", config.PathInFS(mountOptions, subPath)); err != nil {
+		j.metrics.provisionErrors.Inc()
+		ref := corev1.ObjectReference{Kind: "PersistentVolumeClaim", APIVersion: "v1", Namespace: options.PVC.Namespace, Name: options.PVC.Name, UID: options.PVC.UID}
+		resource.RecordFSAccessDenied(ctx, j.K8sClient, ref, "juicefs-csi-controller", err)
+		return nil, provisioncontroller.ProvisioningFinished, status.Errorf(codes.PermissionDenied, "%v", err)
 	}
+
 	pv := &corev1.PersistentVolume{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: options.PVName,
@@ -248,7 +235,8 @@ func (j *provisionerService) Provision(ctx context.Context, options provisioncon
 		}
 	}
 
-	if config.GlobalConfig.EnableSetQuota == nil || *config.GlobalConfig.EnableSetQuota {
+	// quota is not set on read-only volumes, whose path is existing data rather than created for them
+	if !readOnly && (config.GlobalConfig.EnableSetQuota == nil || *config.GlobalConfig.EnableSetQuota) {
 		if config.GlobalConfig.EnableControllerSetQuota == nil || *config.GlobalConfig.EnableControllerSetQuota {
 			if util.SupportQuotaPathCreate(true, config.BuiltinCeVersion) && util.SupportQuotaPathCreate(false, config.BuiltinEeVersion) {
 				secret, err := j.K8sClient.GetSecret(ctx, scParams[common.ControllerExpandSecretName], scParams[common.ControllerExpandSecretNamespace])
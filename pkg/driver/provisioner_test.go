@@ -0,0 +1,113 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	provisioncontroller "sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	k8s "github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+)
+
+func Test_provisionerService_Provision_fsAccessPolicies(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	config.GlobalConfig.FSAccessPolicies = []config.FSAccessPolicy{{
+		Namespaces:     []string{"tenant-a"},
+		SubdirPrefixes: []string{"/tenant-a"},
+	}}
+	reclaimPolicy := corev1.PersistentVolumeReclaimDelete
+	sc := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "juicefs-sc"},
+		Parameters: map[string]string{
+			common.PublishSecretName:      "juicefs-secret",
+			common.PublishSecretNamespace: "kube-system",
+		},
+		MountOptions:  []string{"subdir=/tenant-a"},
+		ReclaimPolicy: &reclaimPolicy,
+	}
+	tests := []struct {
+		name      string
+		namespace string
+		wantCode  codes.Code
+	}{
+		{name: "allowed", namespace: "tenant-a", wantCode: codes.OK},
+		{name: "denied", namespace: "tenant-b", wantCode: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &k8s.K8sClient{Interface: fake.NewSimpleClientset()}
+			j := &provisionerService{
+				K8sClient: client,
+				metrics:   newProvisionerMetrics(prometheus.NewRegistry()),
+			}
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: tt.namespace, UID: "pvc-uid"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: k8sresource.MustParse("10Gi")},
+					},
+				},
+			}
+			pv, state, err := j.Provision(context.TODO(), provisioncontroller.ProvisionOptions{
+				StorageClass: sc,
+				PVName:       "pvc-test",
+				PVC:          pvc,
+			})
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("Provision() code = %v, want %v, error: %v", got, tt.wantCode, err)
+			}
+			if state != provisioncontroller.ProvisioningFinished {
+				t.Errorf("Provision() state = %v, want %v", state, provisioncontroller.ProvisioningFinished)
+			}
+			events, err := client.CoreV1().Events(tt.namespace).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantCode == codes.OK {
+				if pv == nil {
+					t.Fatalf("Provision() should return pv when allowed")
+				}
+				if len(events.Items) != 0 {
+					t.Errorf("got %d events, want none", len(events.Items))
+				}
+				return
+			}
+			if len(events.Items) != 1 {
+				t.Fatalf("got %d events, want 1", len(events.Items))
+			}
+			e := events.Items[0]
+			if e.Reason != resource.ReasonFSAccessDenied || e.Type != corev1.EventTypeWarning ||
+				e.InvolvedObject.Kind != "PersistentVolumeClaim" || e.InvolvedObject.Name != "data" {
+				t.Errorf("unexpected event: %+v", e)
+			}
+		})
+	}
+}
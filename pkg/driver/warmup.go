@@ -0,0 +1,113 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/juicefs"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+)
+
+const reasonWarmup = "Warmup"
+
+// warmupVolume warms up paths set in juicefs/warmup-paths of volume context, PVC or app pod annotations in background,
+// after the volume is mounted. It is best-effort: failures and timeout do not affect the mount,
+// and the progress is reported as events of the app pod.
+func (d *nodeService) warmupVolume(ctx context.Context, volCtx map[string]string, jfs juicefs.Jfs, bindSource string) {
+	log := util.GenLog(ctx, klog.NewKlogr(), "warmup")
+	setting := jfs.GetSetting()
+	var pvcAnnotations, podAnnotations map[string]string
+	if setting.PVC != nil {
+		pvcAnnotations = setting.PVC.Annotations
+	}
+	var appPod *corev1.Pod
+	if d.k8sClient != nil && volCtx[common.PodInfoName] != "" {
+		pod, err := d.k8sClient.GetPod(ctx, volCtx[common.PodInfoName], volCtx[common.PodInfoNamespace])
+		if err != nil {
+			log.V(1).Info("get app pod error, skip its annotations", "pod", volCtx[common.PodInfoName], "error", err)
+		} else {
+			appPod = pod
+			podAnnotations = pod.Annotations
+		}
+	}
+	warmup, err := config.ParseWarmupSetting(volCtx, pvcAnnotations, podAnnotations)
+	if err != nil {
+		log.Error(err, "invalid warmup setting, skip warmup")
+		d.warmupEvent(ctx, log, appPod, corev1.EventTypeWarning, fmt.Sprintf("Skip warmup: %v", err))
+		return
+	}
+	if warmup == nil {
+		return
+	}
+
+	go func() {
+		warmupCtx, cancel := context.WithTimeout(util.WithLog(context.Background(), log), warmup.Timeout)
+		defer cancel()
+		start := time.Now()
+		total := len(warmup.Paths)
+		d.warmupEvent(warmupCtx, log, appPod, corev1.EventTypeNormal, fmt.Sprintf("Start warmup of %d paths, timeout %s", total, warmup.Timeout))
+		failed := 0
+		for i, p := range warmup.Paths {
+			_, err := d.juicefs.Warmup(warmupCtx, setting, path.Join(bindSource, p))
+			if warmupCtx.Err() != nil {
+				d.warmupEvent(context.Background(), log, appPod, corev1.EventTypeWarning,
+					fmt.Sprintf("Warmup timed out after %s, %d of %d paths are done", warmup.Timeout, i, total))
+				return
+			}
+			if err != nil {
+				failed++
+				log.Error(err, "warmup error", "path", p)
+				d.warmupEvent(warmupCtx, log, appPod, corev1.EventTypeWarning, fmt.Sprintf("Warmup of /%s failed (%d/%d): %s", p, i+1, total, lastLine(err.Error())))
+				continue
+			}
+			d.warmupEvent(warmupCtx, log, appPod, corev1.EventTypeNormal, fmt.Sprintf("Warmed up /%s (%d/%d)", p, i+1, total))
+		}
+		elapsed := time.Since(start).Round(time.Second)
+		if failed > 0 {
+			d.warmupEvent(warmupCtx, log, appPod, corev1.EventTypeWarning, fmt.Sprintf("Warmup finished in %s, %d of %d paths failed", elapsed, failed, total))
+			return
+		}
+		log.Info("warmup finished", "paths", warmup.Paths, "elapsed", elapsed.String())
+		d.warmupEvent(warmupCtx, log, appPod, corev1.EventTypeNormal, fmt.Sprintf("Warmup finished in %s", elapsed))
+	}()
+}
+
+func (d *nodeService) warmupEvent(ctx context.Context, log klog.Logger, pod *corev1.Pod, eventType, msg string) {
+	if pod == nil {
+		log.Info(msg)
+		return
+	}
+	if err := d.k8sClient.CreateEvent(ctx, *pod, eventType, reasonWarmup, msg); err != nil {
+		log.Error(err, "fail to create event", "pod", pod.Name, "message", msg)
+	}
+}
+
+// lastLine returns the last non-empty line of s, which is the error of juicefs commands usually
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,199 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package grace
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	k8s "github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+)
+
+var driftLog = log.WithName("drift")
+
+const (
+	// how often to check if drift reconciler is enabled when it's disabled or out of maintenance windows
+	driftIdleInterval  = time.Minute
+	reasonDriftUpgrade = "DriftUpgrade"
+)
+
+// StartDriftReconciler periodically upgrades mount pods in this node whose setting drifts from the current config
+// and secret with recreate smooth upgrade. It's a no-op until driftReconciler is enabled in config.
+func StartDriftReconciler(ctx context.Context, client *k8s.K8sClient) {
+	driftLog.Info("drift reconciler started")
+	for {
+		interval := driftIdleInterval
+		cfg := config.GlobalConfig.DriftReconciler
+		if cfg.IsEnabled() {
+			interval = cfg.GetInterval()
+			if cfg.InMaintenanceWindow(time.Now()) {
+				reconcileDrift(ctx, client, cfg.GetMaxConcurrentPerNode())
+			} else {
+				driftLog.V(1).Info("out of maintenance windows, skip")
+				interval = min(interval, driftIdleInterval)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func reconcileDrift(ctx context.Context, client *k8s.K8sClient, maxConcurrent int) {
+	drifted := listDriftedPods(ctx, client)
+	if len(drifted) == 0 {
+		return
+	}
+	driftLog.Info("found drifted mount pods", "count", len(drifted), "maxConcurrent", maxConcurrent)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrent)
+	for _, pod := range drifted {
+		// check again before each upgrade, as it may take a long time
+		if !config.GlobalConfig.DriftReconciler.IsEnabled() || !config.GlobalConfig.DriftReconciler.InMaintenanceWindow(time.Now()) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(pod corev1.Pod) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			upgradeCtx, cancel := context.WithTimeout(ctx, singleUpgradeTimeout)
+			defer cancel()
+			if err := upgradeDriftedPod(upgradeCtx, client, pod); err != nil {
+				driftLog.Error(err, "upgrade drifted mount pod error", "pod", pod.Name)
+			}
+		}(pod)
+	}
+	wg.Wait()
+}
+
+// listDriftedPods returns mount pods in this node which should be upgraded
+func listDriftedPods(ctx context.Context, client *k8s.K8sClient) []corev1.Pod {
+	if node, err := client.GetNode(ctx, config.NodeName); err != nil {
+		driftLog.Error(err, "get node error", "node", config.NodeName)
+		return nil
+	} else if node.Annotations[common.DriftReconcilePausedKey] == common.True {
+		driftLog.V(1).Info("drift reconciler is paused in node", "node", config.NodeName)
+		return nil
+	}
+
+	labelSelector := &metav1.LabelSelector{MatchLabels: map[string]string{common.PodTypeKey: common.PodTypeValue}}
+	fieldSelector := &fields.Set{"spec.nodeName": config.NodeName}
+	pods, err := client.ListPod(ctx, config.Namespace, labelSelector, fieldSelector)
+	if err != nil {
+		driftLog.Error(err, "list mount pods error")
+		return nil
+	}
+	// pods in batch upgrade jobs are handled by the jobs
+	pods, skipped, err := config.FilterPodsNotInOngoingUpgrade(ctx, client, pods)
+	if err != nil {
+		driftLog.Error(err, "filter pods in ongoing upgrade error")
+		return nil
+	}
+	if len(skipped) > 0 {
+		driftLog.V(1).Info("skip pods in ongoing upgrade jobs", "pods", skipped)
+	}
+
+	drifted := []corev1.Pod{}
+	for _, pod := range pods {
+		if isDrifted(ctx, client, pod) {
+			drifted = append(drifted, pod)
+		}
+	}
+	return drifted
+}
+
+// isDrifted checks if mount pod can be upgraded and its hash differs from the one of current setting
+func isDrifted(ctx context.Context, client *k8s.K8sClient, pod corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Annotations[common.DriftReconcilePausedKey] == common.True {
+		return false
+	}
+	if pod.Annotations[common.JfsUpgradeProcess] != "" {
+		return false
+	}
+	if canUpgrade, reason, _ := resource.CanUpgrade(pod, true); !canUpgrade {
+		driftLog.V(1).Info("mount pod can not upgrade, skip", "pod", pod.Name, "reason", reason)
+		return false
+	}
+	setting, err := config.GenSettingAttrWithMountPod(ctx, client, &pod)
+	if err != nil {
+		driftLog.Error(err, "gen setting of mount pod error", "pod", pod.Name)
+		return false
+	}
+	if setting.HashVal == pod.Labels[common.PodJuiceHashLabelKey] {
+		return false
+	}
+	driftLog.Info("mount pod drifts from current setting", "pod", pod.Name, "hash", pod.Labels[common.PodJuiceHashLabelKey], "expectedHash", setting.HashVal)
+	return true
+}
+
+func upgradeDriftedPod(ctx context.Context, client *k8s.K8sClient, pod corev1.Pod) error {
+	msg := fmt.Sprintf("Mount pod %s drifts from current config or secret, upgrade it with recreating", pod.Name)
+	if err := client.CreateEvent(ctx, pod, corev1.EventTypeNormal, reasonDriftUpgrade, msg); err != nil {
+		driftLog.Error(err, "fail to create event")
+	}
+	if _, err := recreateMountPod(ctx, client, pod); err != nil {
+		return err
+	}
+	driftLog.Info("drifted mount pod upgraded", "pod", pod.Name)
+	return nil
+}
+
+// recreateMountPod upgrades mount pod with recreate smooth upgrade, and returns the name of the new mount pod
+func recreateMountPod(ctx context.Context, client *k8s.K8sClient, pod corev1.Pod) (string, error) {
+	pu := &PodUpgrade{
+		client:      client,
+		pod:         &pod,
+		recreate:    true,
+		ce:          util.ContainSubString(pod.Spec.Containers[0].Command, "metaurl"),
+		hashVal:     pod.Labels[common.PodJuiceHashLabelKey],
+		upgradeUUID: resource.GetUpgradeUUID(&pod),
+		status:      config.Running,
+	}
+	if err := pu.gracefulShutdown(ctx, nil); err != nil {
+		if e := resource.DelPodAnnotation(ctx, client, pod.Name, pod.Namespace, []string{common.JfsUpgradeProcess}); e != nil {
+			driftLog.Error(e, "delete annotation upgradeProcess error", "pod", pod.Name)
+		}
+		return "", err
+	}
+	pu.waitForUpgrade(ctx, nil)
+	if pu.status != config.Success {
+		return "", fmt.Errorf("new mount pod of %s is not ready in time", pod.Name)
+	}
+	return pu.newPod, nil
+}
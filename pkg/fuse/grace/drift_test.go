@@ -0,0 +1,155 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package grace
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	k8s "github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+func newDriftTestMountPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "juicefs-node-a-unique-id",
+			Namespace: config.Namespace,
+			Labels: map[string]string{
+				common.PodTypeKey:           common.PodTypeValue,
+				common.PodUniqueIdLabelKey:  "unique-id",
+				common.PodJuiceHashLabelKey: "old-hash",
+			},
+			Annotations: map[string]string{
+				common.UniqueId:    "unique-id",
+				common.JuiceFSUUID: "test-fs",
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: config.NodeName,
+			Containers: []corev1.Container{{
+				Name:    "jfs-mount",
+				Image:   "juicedata/mount:ee-nightly",
+				Command: []string{"sh", "-c", "exec /sbin/mount.juicefs test /jfs/unique-id -o foreground,no-update"},
+			}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+				{Type: corev1.ContainersReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func Test_listDriftedPods(t *testing.T) {
+	defer func(nodeName, namespace string) {
+		config.NodeName, config.Namespace = nodeName, namespace
+	}(config.NodeName, config.Namespace)
+	config.NodeName, config.Namespace = "node-a", "kube-system"
+	defer config.GlobalConfig.Reset()
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "juicefs-unique-id-secret", Namespace: config.Namespace}}
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "unique-id"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{VolumeAttributes: map[string]string{}},
+			},
+		},
+	}
+	// hash of the mount pod generated with the current config
+	setting, err := config.GenSettingAttrWithMountPod(context.TODO(),
+		&k8s.K8sClient{Interface: fake.NewSimpleClientset(secret, pv, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: config.NodeName}})},
+		newDriftTestMountPod())
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentHash := setting.HashVal
+	now := metav1.Now()
+
+	tests := []struct {
+		name        string
+		nodePaused  bool
+		patch       []config.MountPodPatch
+		mutate      func(pod *corev1.Pod)
+		wantDrifted bool
+	}{
+		{
+			name:        "drifted",
+			wantDrifted: true,
+		},
+		{
+			name:   "up to date",
+			mutate: func(pod *corev1.Pod) { pod.Labels[common.PodJuiceHashLabelKey] = currentHash },
+		},
+		{
+			name:        "config changed",
+			patch:       []config.MountPodPatch{{Labels: map[string]string{"patch": "new"}}},
+			mutate:      func(pod *corev1.Pod) { pod.Labels[common.PodJuiceHashLabelKey] = currentHash },
+			wantDrifted: true,
+		},
+		{
+			name:       "node paused",
+			nodePaused: true,
+		},
+		{
+			name:   "pod paused",
+			mutate: func(pod *corev1.Pod) { pod.Annotations[common.DriftReconcilePausedKey] = common.True },
+		},
+		{
+			name:   "in upgrade",
+			mutate: func(pod *corev1.Pod) { pod.Annotations[common.JfsUpgradeProcess] = "true" },
+		},
+		{
+			name:   "deleting",
+			mutate: func(pod *corev1.Pod) { pod.DeletionTimestamp = &now },
+		},
+		{
+			name:   "not ready",
+			mutate: func(pod *corev1.Pod) { pod.Status.Conditions = nil },
+		},
+		{
+			name:   "not support recreate smooth upgrade",
+			mutate: func(pod *corev1.Pod) { pod.Spec.Containers[0].Image = "juicedata/mount:ce-v1.1.0" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: config.NodeName}}
+			if tt.nodePaused {
+				node.Annotations = map[string]string{common.DriftReconcilePausedKey: common.True}
+			}
+			config.GlobalConfig.MountPodPatch = tt.patch
+			pod := newDriftTestMountPod()
+			if tt.mutate != nil {
+				tt.mutate(pod)
+			}
+			client := &k8s.K8sClient{Interface: fake.NewSimpleClientset(secret, pv, node, pod)}
+			got := listDriftedPods(context.TODO(), client)
+			if drifted := len(got) == 1 && got[0].Name == pod.Name; drifted != tt.wantDrifted || len(got) > 1 {
+				t.Errorf("listDriftedPods() = %d pods, want drifted %v", len(got), tt.wantDrifted)
+			}
+		})
+	}
+}
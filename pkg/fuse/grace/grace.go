@@ -201,6 +201,8 @@ type PodUpgrade struct {
 	hashVal     string
 	upgradeUUID string
 	status      config.UpgradeStatus
+	// newPod is the name of mount pod recreated after upgrade
+	newPod string
 }
 
 func NewPodUpgrade(ctx context.Context, client *k8s.K8sClient, name string, recreate bool, conn net.Conn) (*PodUpgrade, error) {
@@ -453,6 +455,7 @@ func (p *PodUpgrade) waitForUpgrade(ctx context.Context, conn net.Conn) {
 				if resource.IsPodReady(po) {
 					sendMessage(conn, fmt.Sprintf("POD-SUCCESS [%s] Upgrade mount pod and recreate one: %s !", p.pod.Name, po.Name))
 					p.status = config.Success
+					p.newPod = po.Name
 					done <- struct{}{}
 					return
 				}
@@ -566,6 +569,11 @@ func TriggerShutdown(socketPath string, name string, recreateFlag bool) error {
 }
 
 func sendMessage(conn net.Conn, message string) {
+	if conn == nil {
+		// upgrade triggered inside csi node, e.g. by drift reconciler
+		log.V(1).Info(message)
+		return
+	}
 	_, err := conn.Write([]byte(message + "\n"))
 	if err != nil {
 		log.V(1).Info("error sending message", "message", message, "error", err)
@@ -21,6 +21,10 @@ import (
 	"reflect"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
 	"github.com/juicedata/juicefs-csi-driver/pkg/util"
 )
 
@@ -148,3 +152,34 @@ func Test_resolvePpid(t *testing.T) {
 		})
 	}
 }
+
+func Test_shouldRotate(t *testing.T) {
+	now := metav1.Now()
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		deleting    bool
+		want        bool
+	}{
+		{name: "not marked", want: false},
+		{name: "pending", annotations: map[string]string{common.CredentialRotationStatusKey: common.CredentialRotationPending}, want: true},
+		{name: "interrupted", annotations: map[string]string{common.CredentialRotationStatusKey: common.CredentialRotationRunning}, want: true},
+		{name: "in upgrade", annotations: map[string]string{
+			common.CredentialRotationStatusKey: common.CredentialRotationRunning,
+			common.JfsUpgradeProcess:           "true",
+		}, want: false},
+		{name: "succeeded", annotations: map[string]string{common.CredentialRotationStatusKey: common.CredentialRotationSucceeded}, want: false},
+		{name: "deleting", annotations: map[string]string{common.CredentialRotationStatusKey: common.CredentialRotationPending}, deleting: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			if tt.deleting {
+				pod.DeletionTimestamp = &now
+			}
+			if got := shouldRotate(pod); got != tt.want {
+				t.Errorf("shouldRotate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package grace
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	k8s "github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+)
+
+var rotationLog = log.WithName("credential-rotation")
+
+const (
+	rotationInterval         = 30 * time.Second
+	reasonCredentialRotation = "CredentialRotation"
+)
+
+// StartCredentialRotator periodically upgrades mount pods in this node which are marked by CSI Controller
+// after credentials in their volume secret are changed, with recreate smooth upgrade.
+// The progress is recorded in annotation juicefs-credential-rotation-status of mount pods.
+func StartCredentialRotator(ctx context.Context, client *k8s.K8sClient) {
+	rotationLog.Info("credential rotator started")
+	for {
+		rotateCredentials(ctx, client)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(rotationInterval):
+		}
+	}
+}
+
+func rotateCredentials(ctx context.Context, client *k8s.K8sClient) {
+	labelSelector := &metav1.LabelSelector{MatchLabels: map[string]string{common.PodTypeKey: common.PodTypeValue}}
+	fieldSelector := &fields.Set{"spec.nodeName": config.NodeName}
+	pods, err := client.ListPod(ctx, config.Namespace, labelSelector, fieldSelector)
+	if err != nil {
+		rotationLog.Error(err, "list mount pods error")
+		return
+	}
+	for _, pod := range pods {
+		if !shouldRotate(pod) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		// rotate one by one, to limit the impact on the node
+		rotateCtx, cancel := context.WithTimeout(ctx, singleUpgradeTimeout)
+		rotatePodCredentials(rotateCtx, client, pod)
+		cancel()
+	}
+}
+
+// shouldRotate checks if mount pod is marked to rotate credentials, or its rotation is interrupted
+func shouldRotate(pod corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Annotations[common.JfsUpgradeProcess] != "" {
+		return false
+	}
+	status := pod.Annotations[common.CredentialRotationStatusKey]
+	return status == common.CredentialRotationPending || status == common.CredentialRotationRunning
+}
+
+func rotatePodCredentials(ctx context.Context, client *k8s.K8sClient, pod corev1.Pod) {
+	hash := pod.Annotations[common.CredentialRotationHashKey]
+	if canUpgrade, reason, _ := resource.CanUpgrade(pod, true); !canUpgrade {
+		setRotationStatus(ctx, client, pod, hash, common.CredentialRotationFailed, corev1.EventTypeWarning,
+			fmt.Sprintf("Can not rotate credentials with smooth upgrade: %s, please recreate the mount pod manually", reason))
+		return
+	}
+	setting, err := config.GenSettingAttrWithMountPod(ctx, client, &pod)
+	if err != nil {
+		setRotationStatus(ctx, client, pod, hash, common.CredentialRotationFailed, corev1.EventTypeWarning,
+			fmt.Sprintf("Can not rotate credentials, generate setting error: %v", err))
+		return
+	}
+	if setting.HashVal == pod.Labels[common.PodJuiceHashLabelKey] {
+		setRotationStatus(ctx, client, pod, hash, common.CredentialRotationSucceeded, corev1.EventTypeNormal,
+			"Credentials used by mount pod are not changed, no need to rotate")
+		return
+	}
+
+	setRotationStatus(ctx, client, pod, hash, common.CredentialRotationRunning, corev1.EventTypeNormal,
+		"Rotate credentials with recreate smooth upgrade")
+	newPodName, err := recreateMountPod(ctx, client, pod)
+	if err != nil {
+		rotationLog.Error(err, "rotate credentials error", "pod", pod.Name)
+		setRotationStatus(ctx, client, pod, hash, common.CredentialRotationFailed, corev1.EventTypeWarning,
+			fmt.Sprintf("Rotate credentials error: %v", err))
+		return
+	}
+	newPod, err := client.GetPod(ctx, newPodName, pod.Namespace)
+	if err != nil {
+		rotationLog.Error(err, "get new mount pod error", "pod", newPodName)
+		return
+	}
+	setRotationStatus(ctx, client, *newPod, hash, common.CredentialRotationSucceeded, corev1.EventTypeNormal,
+		fmt.Sprintf("Credentials are rotated, mount pod is recreated from %s", pod.Name))
+	rotationLog.Info("credentials rotated", "pod", pod.Name, "newPod", newPodName)
+}
+
+func setRotationStatus(ctx context.Context, client *k8s.K8sClient, pod corev1.Pod, hash, status, eventType, msg string) {
+	annotations := map[string]string{common.CredentialRotationStatusKey: status}
+	if hash != "" {
+		annotations[common.CredentialRotationHashKey] = hash
+	}
+	if err := resource.AddPodAnnotation(ctx, client, pod.Name, pod.Namespace, annotations); err != nil {
+		rotationLog.Error(err, "update credential rotation status error", "pod", pod.Name, "status", status)
+	}
+	if err := client.CreateEvent(ctx, pod, eventType, reasonCredentialRotation, msg); err != nil {
+		rotationLog.Error(err, "fail to create event", "pod", pod.Name)
+	}
+}
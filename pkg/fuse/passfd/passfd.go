@@ -205,6 +205,32 @@ func (fs *Fds) getFdAddress(ctx context.Context, upgradeUUID string) (string, er
 	return addressInPod, nil
 }
 
+// MountFuse mounts FUSE at mountPath for the mount pod, which can't mount it without privileged. mountPath is
+// in the directory propagated to the host by CSI Node, and the FUSE fd is passed to the client in the mount pod
+// by the fd server, as the fd of the old client in smooth upgrade. Nothing is mounted if the fd is still kept.
+func (fs *Fds) MountFuse(ctx context.Context, pod *corev1.Pod, mountPath, source string, readOnly bool) error {
+	if fs == nil || config.DisableGraceUpgrade {
+		return fmt.Errorf("fuse fd server is disabled")
+	}
+	upgradeUUID := resource.GetUpgradeUUID(pod)
+	fs.globalMu.Lock()
+	defer fs.globalMu.Unlock()
+	f := fs.fds[upgradeUUID]
+	if f == nil {
+		return fmt.Errorf("fuse fd of upgradeUUID %s not found in global fuse fds", upgradeUUID)
+	}
+	if f.fuseFd > 0 {
+		return nil
+	}
+	fuseFd, err := mountFuse(mountPath, source, readOnly)
+	if err != nil {
+		return err
+	}
+	fdLog.V(1).Info("mount FUSE for mount pod", "pod", pod.Name, "mountPath", mountPath, "fd", fuseFd)
+	f.fuseFd = fuseFd
+	return nil
+}
+
 func (fs *Fds) StopFd(ctx context.Context, pod *corev1.Pod) {
 	if fs == nil || config.DisableGraceUpgrade {
 		return
@@ -2,6 +2,27 @@
 
 package passfd
 
-import "syscall"
+import (
+	"fmt"
+	"syscall"
+)
 
 const msgCmsgCloexec = syscall.MSG_CMSG_CLOEXEC
+
+// mountFuse opens /dev/fuse and mounts it at mountPath as fusermount does, the fd is served to the client later
+func mountFuse(mountPath, source string, readOnly bool) (int, error) {
+	fuseFd, err := syscall.Open("/dev/fuse", syscall.O_RDWR|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, fmt.Errorf("open /dev/fuse error: %v", err)
+	}
+	flags := uintptr(syscall.MS_NOSUID | syscall.MS_NODEV)
+	if readOnly {
+		flags |= syscall.MS_RDONLY
+	}
+	data := fmt.Sprintf("fd=%d,rootmode=40000,user_id=0,group_id=0,allow_other", fuseFd)
+	if err := syscall.Mount(source, mountPath, "fuse.juicefs", flags, data); err != nil {
+		_ = syscall.Close(fuseFd)
+		return -1, fmt.Errorf("mount FUSE at %s error: %v", mountPath, err)
+	}
+	return fuseFd, nil
+}
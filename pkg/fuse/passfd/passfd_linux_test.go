@@ -0,0 +1,109 @@
+//go:build linux
+
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package passfd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+func TestFds_MountFuse(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("mounting FUSE requires root")
+	}
+	if _, err := os.Stat("/dev/fuse"); err != nil {
+		t.Skip("/dev/fuse does not exist")
+	}
+	ctx := context.TODO()
+	fds := &Fds{globalMu: sync.Mutex{}, basePath: t.TempDir(), fds: make(map[string]*fd)}
+	pod := newFusePassPod("non-privileged")
+	if _, err := fds.getFdAddress(ctx, "non-privileged"); err != nil {
+		t.Fatal(err)
+	}
+	// the directory propagated to the host by csi node, e.g. /jfs in csi node
+	mountPath := filepath.Join(t.TempDir(), "pvc-test-abcdef")
+	if err := os.MkdirAll(mountPath, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := fds.MountFuse(ctx, pod, mountPath, "JuiceFS:test", false); err != nil {
+		t.Fatalf("MountFuse() error = %v", err)
+	}
+	defer func() {
+		_ = syscall.Unmount(mountPath, syscall.MNT_DETACH)
+		fds.StopFd(ctx, pod)
+	}()
+
+	// the mount point is visible in the mount namespace of csi node, not only in the mount pod
+	mountInfo, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, line := range strings.Split(string(mountInfo), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 8 && fields[4] == mountPath {
+			found = true
+			if sep := strings.Index(line, " - "); sep < 0 || !strings.HasPrefix(line[sep+3:], "fuse.juicefs JuiceFS:test ") {
+				t.Errorf("unexpected mount: %s", line)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("mount point %s is not found in mountinfo", mountPath)
+	}
+
+	// mounting again keeps the fd
+	fuseFd := fds.fds["non-privileged"].fuseFd
+	if err := fds.MountFuse(ctx, pod, mountPath, "JuiceFS:test", false); err != nil || fds.fds["non-privileged"].fuseFd != fuseFd {
+		t.Fatalf("MountFuse() should keep the fd, error = %v", err)
+	}
+
+	// the client in the mount pod gets the fd of /dev/fuse from the fd server
+	if err := fds.ServeFuseFd(ctx, pod); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("unix", fds.fds["non-privileged"].serverAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	msg, received, err := getFd(conn.(*net.UnixConn), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, f := range received {
+			_ = syscall.Close(f)
+		}
+	}()
+	if string(msg) != "FUSE" || len(received) != 2 {
+		t.Fatalf("got msg %q and %d fds, want FUSE and 2 fds", msg, len(received))
+	}
+	link, err := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", received[1]))
+	if err != nil || link != "/dev/fuse" {
+		t.Errorf("received fd links to %s, error = %v, want /dev/fuse", link, err)
+	}
+}
@@ -2,4 +2,10 @@
 
 package passfd
 
+import "fmt"
+
 const msgCmsgCloexec = 0
+
+func mountFuse(mountPath, source string, readOnly bool) (int, error) {
+	return -1, fmt.Errorf("mounting FUSE for mount pods is only supported in linux")
+}
@@ -45,6 +45,7 @@ import (
 	"github.com/juicedata/juicefs-csi-driver/pkg/juicefs/mount/builder"
 	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
 	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/credential"
 	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
 )
 
@@ -65,6 +66,7 @@ type Interface interface {
 	JfsUnmount(ctx context.Context, volumeID, mountPath string) error
 	JfsCleanupMountPoint(ctx context.Context, mountPath string) error
 	SetQuota(ctx context.Context, secrets map[string]string, jfsSetting *config.JfsSetting, quotaPath string, capacity int64) error
+	Warmup(ctx context.Context, jfsSetting *config.JfsSetting, warmupPath string) (string, error)
 	Settings(ctx context.Context, volumeID, uniqueId, uuid string, secrets, volCtx map[string]string, options []string) (*config.JfsSetting, error)
 	GetSubPath(ctx context.Context, volumeID string) (string, error)
 	CreateTarget(ctx context.Context, target string) error
@@ -115,6 +117,10 @@ func (fs *jfs) CreateVol(ctx context.Context, volumeID, subPath string) (string,
 	if !config.StorageClassShareMount && !config.FSShareMount && !config.ByProcess {
 		return fs.MountPath, nil
 	}
+	if !config.ByProcess && fs.Setting != nil && fs.Setting.ReadOnly {
+		// read-only client mounts the path of the volume, which can not be created by it
+		return fs.MountPath, nil
+	}
 	volPath := filepath.Join(fs.MountPath, subPath)
 	log.V(1).Info("checking volPath exists", "volPath", volPath, "fs", fs)
 	var exists bool
@@ -310,29 +316,41 @@ func (j *juicefs) Settings(ctx context.Context, volumeID, uniqueId, uuid string,
 	if err != nil {
 		log.Error(err, "Get PV with volumeID error", "volumeId", volumeID)
 	}
+	var node *corev1.Node
+	if j.K8sClient != nil && config.NodeName != "" {
+		node, err = j.K8sClient.GetNodeByCache(ctx, config.NodeName)
+		if err != nil {
+			log.V(1).Info("Get current node error, skip node-aware mount pod patch", "node", config.NodeName, "error", err)
+			node = nil
+		}
+	}
+
+	var namespace *corev1.Namespace
+	if j.K8sClient != nil && pvc != nil {
+		namespace, err = j.K8sClient.GetNamespaceByCache(ctx, pvc.Namespace)
+		if err != nil {
+			log.V(1).Info("Get namespace of pvc error, match namespace-aware mount pod patch by name", "namespace", pvc.Namespace, "error", err)
+			namespace = nil
+		}
+	}
+
 	// overwrite volCtx with allowed pvc annotations
 	if pvc != nil {
 		if volCtx == nil {
 			volCtx = make(map[string]string)
 		}
 		for k, v := range pvc.Annotations {
-			if !isPVCMountPodAnnotationAllowed(k) {
+			if !isPVCMountPodAnnotationAllowed(k, pvc.Namespace, namespace) {
+				if config.AllowUnsafePVCMountPodAnnotations && strings.HasPrefix(k, "juicefs/") {
+					log.Info("Ignore pvc annotation not allowed by annotation policies", "annotation", k, "pvc", pvc.Name, "namespace", pvc.Namespace)
+				}
 				continue
 			}
 			volCtx[k] = v
 		}
 	}
 
-	var node *corev1.Node
-	if j.K8sClient != nil && config.NodeName != "" {
-		node, err = j.K8sClient.GetNodeByCache(ctx, config.NodeName)
-		if err != nil {
-			log.V(1).Info("Get current node error, skip node-aware mount pod patch", "node", config.NodeName, "error", err)
-			node = nil
-		}
-	}
-
-	jfsSetting, err := config.ParseSettingWithNode(ctx, secrets, volCtx, options, volumeID, uniqueId, uuid, pv, pvc, node)
+	jfsSetting, err := config.ParseSettingWithNode(ctx, secrets, volCtx, options, volumeID, uniqueId, uuid, pv, pvc, node, namespace)
 	if err != nil {
 		log.Error(err, "Parse config error", "secret", secrets["name"])
 		return nil, err
@@ -347,7 +365,7 @@ func (j *juicefs) Settings(ctx context.Context, volumeID, uniqueId, uuid string,
 	// do format/auth in process mode
 	if config.ByProcess {
 		if !jfsSetting.IsCe {
-			if secrets["token"] == "" {
+			if jfsSetting.Token == "" {
 				log.Info("token is empty, skip authfs.")
 			} else {
 				_, err := j.AuthFs(ctx, secrets, jfsSetting, false)
@@ -370,11 +388,20 @@ func (j *juicefs) Settings(ctx context.Context, volumeID, uniqueId, uuid string,
 	return jfsSetting, nil
 }
 
-func isPVCMountPodAnnotationAllowed(key string) bool {
+// isPVCMountPodAnnotationAllowed checks if the annotation of a PVC in the namespace can override the mount settings.
+// PVC annotation policies are checked again here, in case the PVC was admitted when the validating webhook was
+// unavailable.
+func isPVCMountPodAnnotationAllowed(key, namespaceName string, namespace *corev1.Namespace) bool {
 	if isPVCMountResourceAnnotation(key) {
 		return true
 	}
-	return config.AllowUnsafePVCMountPodAnnotations && strings.HasPrefix(key, "juicefs")
+	if !config.AllowUnsafePVCMountPodAnnotations || !strings.HasPrefix(key, "juicefs") {
+		return false
+	}
+	if namespace == nil {
+		namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+	}
+	return config.GlobalConfig.IsPVCAnnotationAllowed(key, namespace)
 }
 
 func isPVCMountResourceAnnotation(key string) bool {
@@ -433,22 +460,41 @@ func (j *juicefs) genJfsSettings(ctx context.Context, volumeID string, target st
 // If these conditions are not met, the function returns `false`, and the system should
 // fall back to using the `volumeId` as the unique ID.
 func (j *juicefs) shouldUseFSNameAsUniqueId(ctx context.Context, fsname string, secrets map[string]string) (bool, error) {
-	log := util.GenLog(ctx, jfsLog, "shouldUseFSNameAsUniqueId")
 	if fsname == "" {
 		return false, nil
 	}
 
-	secretName := fmt.Sprintf("juicefs-%s-secret", fsname)
-	existSecret, err := j.K8sClient.GetSecret(ctx, secretName, config.Namespace)
-	if err != nil {
-		if k8serrors.IsNotFound(err) {
-			return true, nil
+	// mount pods with read-only, plaintext and encrypted secrets may all exist
+	for _, secretName := range config.MountSecretNames(fsname) {
+		existSecret, err := j.K8sClient.GetSecret(ctx, secretName, config.Namespace)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
 		}
+		if ok, err := j.matchesExistSecret(ctx, fsname, existSecret, secrets); err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (j *juicefs) matchesExistSecret(ctx context.Context, fsname string, existSecret *corev1.Secret, secrets map[string]string) (bool, error) {
+	log := util.GenLog(ctx, jfsLog, "shouldUseFSNameAsUniqueId")
+	secretName := existSecret.Name
+	if secrets[credential.ProviderKey] != "" {
+		// credentials are not saved in secret, they can not be compared
+		log.Info("credentials are from external provider, fallback to volumeId", "secretName", secretName, "fsname", fsname)
+		return false, nil
+	}
+	existData, err := config.DecryptSecretData(existSecret)
+	if err != nil {
 		return false, err
 	}
 
 	v1, isCe := secrets["metaurl"]
-	v2, existIsCe := existSecret.Data["metaurl"]
+	v2, existIsCe := existData["metaurl"]
 
 	if isCe != existIsCe {
 		log.Info("fallback to volumeId", "secretName", secretName, "fsname", fsname, "isCe", isCe, "existIsCe", existIsCe)
@@ -456,7 +502,7 @@ func (j *juicefs) shouldUseFSNameAsUniqueId(ctx context.Context, fsname string,
 	}
 
 	if isCe {
-		r := v1 == string(v2)
+		r := v1 == v2
 		if !r {
 			log.Info("metaurl is not equal with exist secret, fallback to volumeId", "secretName", secretName, "fsname", fsname)
 		}
@@ -464,7 +510,7 @@ func (j *juicefs) shouldUseFSNameAsUniqueId(ctx context.Context, fsname string,
 	}
 
 	// EE
-	if secrets["token"] != string(existSecret.Data["token"]) {
+	if secrets["token"] != existData["token"] {
 		log.V(1).Info("token is not equal with exist secret, fallback to volumeId", "secretName", secretName, "fsname", fsname)
 		return false, nil
 	}
@@ -481,8 +527,8 @@ func (j *juicefs) shouldUseFSNameAsUniqueId(ctx context.Context, fsname string,
 	}
 
 	existConsoleUrl := ""
-	if val, ok := existSecret.Data["BASE_URL"]; ok {
-		existConsoleUrl = string(val)
+	if val, ok := existData["BASE_URL"]; ok {
+		existConsoleUrl = val
 	}
 	r := consoleUrl == existConsoleUrl
 	if !r {
@@ -749,6 +795,10 @@ func (j *juicefs) JfsCleanupMountPoint(ctx context.Context, mountPath string) er
 // AuthFs authenticates JuiceFS, enterprise edition only
 func (j *juicefs) AuthFs(ctx context.Context, secrets map[string]string, setting *config.JfsSetting, force bool) (string, error) {
 	log := util.GenLog(ctx, jfsLog, "AuthFs")
+	secrets, _, err := config.ResolveCredentials(ctx, secrets)
+	if err != nil {
+		return "", err
+	}
 	args, cmdArgs, err := config.GenAuthCmd(secrets, setting)
 	if err != nil {
 		return "", err
@@ -790,6 +840,10 @@ func (j *juicefs) SetQuota(ctx context.Context, secrets map[string]string, jfsSe
 	if cap <= 0 {
 		return fmt.Errorf("capacity %d is too small, at least 1GiB for quota", capacity)
 	}
+	secrets, _, err := config.ResolveCredentials(ctx, secrets)
+	if err != nil {
+		return err
+	}
 
 	var args, cmdArgs []string
 	if jfsSetting.IsCe {
@@ -814,7 +868,6 @@ func (j *juicefs) SetQuota(ctx context.Context, secrets map[string]string, jfsSe
 	for key, val := range jfsSetting.Envs {
 		envs = append(envs, fmt.Sprintf("%s=%s", key, val))
 	}
-	var err error
 	if !jfsSetting.IsCe {
 		var authRes string
 		authRes, err = j.AuthFs(ctx, secrets, jfsSetting, true)
@@ -843,6 +896,22 @@ func (j *juicefs) SetQuota(ctx context.Context, secrets map[string]string, jfsSe
 	return wrapSetQuotaErr(string(res), err)
 }
 
+// Warmup warms up the cache of warmupPath in the mount point, which is shared with mount pod,
+// the output of juicefs warmup is returned
+func (j *juicefs) Warmup(ctx context.Context, jfsSetting *config.JfsSetting, warmupPath string) (string, error) {
+	log := util.GenLog(ctx, jfsLog, "Warmup")
+	cliPath := config.CliPath
+	if jfsSetting.IsCe {
+		cliPath = config.CeCliPath
+	}
+	log.Info("warmup cmd", "command", strings.Join([]string{cliPath, "warmup", warmupPath}, " "))
+	res, err := j.Exec.CommandContext(ctx, cliPath, "warmup", warmupPath).CombinedOutput()
+	if err != nil {
+		return string(res), errors.Wrap(err, string(res))
+	}
+	return string(res), nil
+}
+
 func wrapSetQuotaErr(res string, err error) error {
 	if err != nil {
 		re := string(res)
@@ -927,6 +996,10 @@ func (j *juicefs) Upgrade() {
 
 func (j *juicefs) ceFormat(ctx context.Context, secrets map[string]string, noUpdate bool, setting *config.JfsSetting) (string, error) {
 	log := util.GenLog(ctx, jfsLog, "ceFormat")
+	secrets, _, err := config.ResolveCredentials(ctx, secrets)
+	if err != nil {
+		return "", err
+	}
 	args, cmdArgs, err := config.GenFormatCmd(secrets, noUpdate, setting)
 	if err != nil {
 		return "", err
@@ -35,6 +35,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 	k8sexec "k8s.io/utils/exec"
 	"k8s.io/utils/mount"
@@ -1260,3 +1261,78 @@ func Test_juicefs_validTarget(t *testing.T) {
 		})
 	}
 }
+
+func Test_isPVCMountPodAnnotationAllowed(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	defer func(allow bool) { config.AllowUnsafePVCMountPodAnnotations = allow }(config.AllowUnsafePVCMountPodAnnotations)
+	config.AllowUnsafePVCMountPodAnnotations = true
+	config.GlobalConfig.PVCAnnotationPolicies = []config.PVCAnnotationPolicy{
+		{Namespaces: []string{"ml"}, AllowedKeys: []string{"juicefs/mount-image"}},
+		{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}}, AllowedKeys: []string{"juicefs/mount-*"}},
+	}
+	gold := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "gold", Labels: map[string]string{"tier": "gold"}}}
+	tests := []struct {
+		name          string
+		key           string
+		namespaceName string
+		namespace     *corev1.Namespace
+		want          bool
+	}{
+		{name: "allowed-by-namespace-name", key: "juicefs/mount-image", namespaceName: "ml", want: true},
+		{name: "not-allowed-in-namespace", key: "juicefs/host-path", namespaceName: "ml", want: false},
+		{name: "allowed-by-namespace-selector", key: "juicefs/mount-cache-pvc", namespaceName: "gold", namespace: gold, want: true},
+		{name: "selector-not-matched-without-namespace", key: "juicefs/mount-cache-pvc", namespaceName: "gold", want: false},
+		{name: "no-policy-matched", key: "juicefs/host-path", namespaceName: "default", want: false},
+		{name: "resources-always-allowed", key: "juicefs/mount-cpu-limit", namespaceName: "default", want: true},
+		{name: "not-juicefs-annotation", key: "volume.kubernetes.io/selected-node", namespaceName: "ml", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPVCMountPodAnnotationAllowed(tt.key, tt.namespaceName, tt.namespace); got != tt.want {
+				t.Errorf("isPVCMountPodAnnotationAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	config.AllowUnsafePVCMountPodAnnotations = false
+	if isPVCMountPodAnnotationAllowed("juicefs/mount-image", "ml", nil) {
+		t.Errorf("isPVCMountPodAnnotationAllowed() should deny annotations without JUICEFS_ALLOW_UNSAFE_PVC_MOUNT_POD_ANNOTATIONS")
+	}
+}
+
+func Test_juicefs_shouldUseFSNameAsUniqueId(t *testing.T) {
+	secrets := map[string]string{"name": "test", "metaurl": "redis://127.0.0.1:6379/1"}
+	tests := []struct {
+		name        string
+		existSecret string
+		metaurl     string
+		want        bool
+	}{
+		{name: "no-exist-secret", want: true},
+		{name: "same-metaurl", existSecret: "juicefs-test-secret", metaurl: "redis://127.0.0.1:6379/1", want: true},
+		{name: "different-metaurl", existSecret: "juicefs-test-secret", metaurl: "redis://127.0.0.1:6379/2", want: false},
+		{name: "different-metaurl-read-only", existSecret: "juicefs-test-ro-secret", metaurl: "redis://127.0.0.1:6379/2", want: false},
+		{name: "different-metaurl-encrypted", existSecret: "juicefs-test-enc-secret", metaurl: "redis://127.0.0.1:6379/2", want: false},
+		{name: "different-metaurl-read-only-encrypted", existSecret: "juicefs-test-ro-enc-secret", metaurl: "redis://127.0.0.1:6379/2", want: false},
+		{name: "same-metaurl-read-only-encrypted", existSecret: "juicefs-test-ro-enc-secret", metaurl: "redis://127.0.0.1:6379/1", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			if tt.existSecret != "" {
+				client = fake.NewSimpleClientset(&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: tt.existSecret, Namespace: config.Namespace},
+					Data:       map[string][]byte{"name": []byte("test"), "metaurl": []byte(tt.metaurl)},
+				})
+			}
+			j := &juicefs{K8sClient: &k8s.K8sClient{Interface: client}}
+			got, err := j.shouldUseFSNameAsUniqueId(context.TODO(), "test", secrets)
+			if err != nil {
+				t.Fatalf("shouldUseFSNameAsUniqueId() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("shouldUseFSNameAsUniqueId() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -323,3 +323,18 @@ func (mr *MockInterfaceMockRecorder) Unmount(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unmount", reflect.TypeOf((*MockInterface)(nil).Unmount), arg0)
 }
+
+// Warmup mocks base method.
+func (m *MockInterface) Warmup(arg0 context.Context, arg1 *config.JfsSetting, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Warmup", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Warmup indicates an expected call of Warmup.
+func (mr *MockInterfaceMockRecorder) Warmup(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warmup", reflect.TypeOf((*MockInterface)(nil).Warmup), arg0, arg1, arg2)
+}
@@ -26,11 +26,13 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/juicedata/juicefs-csi-driver/pkg/common"
 	"github.com/juicedata/juicefs-csi-driver/pkg/config"
 	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/credential"
 	"github.com/juicedata/juicefs-csi-driver/pkg/util/security"
 )
 
@@ -38,6 +40,9 @@ const (
 	JfsDirName      = "jfs-dir"
 	UpdateDBDirName = "updatedb"
 	UpdateDBCfgFile = "/etc/updatedb.conf"
+
+	// container runtimes inject CDI devices of annotations with the prefix cdi.k8s.io/
+	CDIFuseDeviceAnnotation = "cdi.k8s.io/juicefs-fuse"
 )
 
 type BaseBuilder struct {
@@ -170,12 +175,27 @@ func (r *BaseBuilder) genHostname() string {
 	}
 }
 
+// genWarmupCommand generates the command warming up paths under mountPath in the mount container, which is best-effort:
+// it succeeds even if warmup fails or times out, the output is written to the log of the container
+func (r *BaseBuilder) genWarmupCommand(mountPath string, warmup *config.WarmupSetting) string {
+	cliPath := config.CliPath
+	if r.jfsSetting.IsCe {
+		cliPath = config.CeCliPath
+	}
+	paths := make([]string, 0, len(warmup.Paths))
+	for _, p := range warmup.Paths {
+		paths = append(paths, security.EscapeBashStr(path.Join(mountPath, p)))
+	}
+	return fmt.Sprintf("(echo \"start warmup of %d paths, timeout %s\" && timeout %d %s warmup %s && echo \"warmup succeeded\" || echo \"warmup failed or timed out, ignored\") >> /proc/1/fd/1 2>&1",
+		len(paths), warmup.Timeout, int64(warmup.Timeout.Seconds()), cliPath, strings.Join(paths, " "))
+}
+
 // genMountCommand generates mount command
 func (r *BaseBuilder) genMountCommand() string {
 	cmd := ""
 	options := []string{}
 	subdir := r.jfsSetting.SubPath
-	if r.jfsSetting.MountShareMode == "" {
+	if !r.jfsSetting.SharesClient() {
 		for _, option := range r.jfsSetting.Options {
 			if strings.HasPrefix(option, "subdir=") {
 				s := strings.Split(option, "=")
@@ -241,6 +261,10 @@ func (r *BaseBuilder) genInitCommand() string {
 			}
 		}
 	}
+	if credentialCmd := credential.Command(r.jfsSetting.Credential); credentialCmd != "" {
+		// export credentials before format or auth, which use them as envs
+		formatCmd = strings.TrimSuffix(credentialCmd+"\n"+formatCmd, "\n")
+	}
 
 	return formatCmd
 }
@@ -322,6 +346,17 @@ func GenMetadata(jfsSetting *config.JfsSetting) (labels map[string]string, annot
 	if jfsSetting.MountShareMode != "" {
 		annotations[common.JuicefsMountShareMode] = jfsSetting.MountShareMode
 	}
+	if jfsSetting.ReadOnly {
+		annotations[common.JuicefsReadOnlyKey] = common.True
+	}
+	if config.GlobalConfig.MountPodMetrics.IsEnabled() {
+		// mount pod may be shared by volumes in share mode, labels are of the first one
+		setVolumeLabels(labels, jfsSetting.PV, jfsSetting.PVC)
+	}
+	if jfsSetting.PVC != nil && config.GlobalConfig.GetResourceBudget(jfsSetting.GetNamespace()) != nil {
+		// counted in the resource budget of the pvc namespace
+		labels[common.MountPodPVCNamespaceLabelKey] = jfsSetting.GetNamespace().Name
+	}
 	// inter labels & annotations
 	annotations[common.JuiceFSUUID] = jfsSetting.UUID
 	annotations[common.UniqueId] = jfsSetting.UniqueId
@@ -332,6 +367,21 @@ func GenMetadata(jfsSetting *config.JfsSetting) (labels map[string]string, annot
 	return
 }
 
+func setVolumeLabels(labels map[string]string, pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim) {
+	set := func(k, v string) {
+		if v != "" && len(validation.IsValidLabelValue(v)) == 0 {
+			labels[k] = v
+		}
+	}
+	if pv != nil {
+		set(common.MountPodPVLabelKey, pv.Name)
+	}
+	if pvc != nil {
+		set(common.MountPodPVCLabelKey, pvc.Name)
+		set(common.MountPodPVCNamespaceLabelKey, pvc.Namespace)
+	}
+}
+
 // _genMetadata generates labels & annotations
 func (r *BaseBuilder) _genMetadata() (labels map[string]string, annotations map[string]string) {
 	return GenMetadata(r.jfsSetting)
@@ -20,11 +20,20 @@ import (
 	"reflect"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/juicedata/juicefs-csi-driver/pkg/common"
 	"github.com/juicedata/juicefs-csi-driver/pkg/config"
 )
 
 func TestGenMetadata(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	config.GlobalConfig.ResourceBudgets = []config.ResourceBudget{{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "free"}},
+		Total:             corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("10Gi")},
+	}}
 	tests := []struct {
 		name            string
 		jfsSetting      *config.JfsSetting
@@ -88,6 +97,51 @@ func TestGenMetadata(t *testing.T) {
 				common.UniqueId:    "unique3",
 			},
 		},
+		{
+			name: "test-resource-budget-namespace",
+			jfsSetting: &config.JfsSetting{
+				Attr:        &config.PodAttr{},
+				PVC:         &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "tenant-a"}},
+				Namespace:   &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Labels: map[string]string{"tier": "free"}}},
+				UUID:        "uuid5",
+				UniqueId:    "unique5",
+				HashVal:     "hash1",
+				UpgradeUUID: "hash1",
+			},
+			wantLabels: map[string]string{
+				common.PodTypeKey:                   common.PodTypeValue,
+				common.PodUniqueIdLabelKey:          "unique5",
+				common.PodJuiceHashLabelKey:         "hash1",
+				common.PodUpgradeUUIDLabelKey:       "hash1",
+				common.MountPodPVCNamespaceLabelKey: "tenant-a",
+			},
+			wantAnnotations: map[string]string{
+				common.JuiceFSUUID: "uuid5",
+				common.UniqueId:    "unique5",
+			},
+		},
+		{
+			name: "test-resource-budget-namespace-not-matched",
+			jfsSetting: &config.JfsSetting{
+				Attr:        &config.PodAttr{},
+				PVC:         &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "tenant-b"}},
+				Namespace:   &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b"}},
+				UUID:        "uuid6",
+				UniqueId:    "unique6",
+				HashVal:     "hash1",
+				UpgradeUUID: "hash1",
+			},
+			wantLabels: map[string]string{
+				common.PodTypeKey:             common.PodTypeValue,
+				common.PodUniqueIdLabelKey:    "unique6",
+				common.PodJuiceHashLabelKey:   "hash1",
+				common.PodUpgradeUUIDLabelKey: "hash1",
+			},
+			wantAnnotations: map[string]string{
+				common.JuiceFSUUID: "uuid6",
+				common.UniqueId:    "unique6",
+			},
+		},
 	}
 
 	for _, tt := range tests {
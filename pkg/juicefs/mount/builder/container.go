@@ -31,12 +31,30 @@ import (
 
 type ContainerBuilder struct {
 	PodBuilder
+	// PVCs served by the sidecar, empty if the sidecar is for one PVC only
+	sharedMounts []SharedMount
+}
+
+// SharedMount is a PVC served by a sidecar shared with other PVCs of the same filesystem,
+// its path under the mount point of the sidecar is bind mounted to its own mount path.
+type SharedMount struct {
+	// mount path of the PVC in sidecar, which is propagated to app containers
+	MountPath string
+	// path relative to the mount point of the sidecar
+	BindPath string
+	// subPath of the PVC, and its path in the filesystem to set quota
+	SubPath   string
+	QuotaPath string
+	// capacity in GiB, 0 means no quota
+	Capacity int64
+	// paths to warm up under the mount path after mount, nil if no warmup
+	Warmup *config.WarmupSetting
 }
 
 var _ SidecarInterface = &ContainerBuilder{}
 
 func NewContainerBuilder(setting *config.JfsSetting, capacity int64) SidecarInterface {
-	return &ContainerBuilder{PodBuilder{
+	return &ContainerBuilder{PodBuilder: PodBuilder{
 		BaseBuilder: BaseBuilder{
 			jfsSetting: setting,
 			capacity:   capacity,
@@ -44,6 +62,18 @@ func NewContainerBuilder(setting *config.JfsSetting, capacity int64) SidecarInte
 	}
 }
 
+// NewSharedContainerBuilder generates a sidecar mounting the filesystem once for all PVCs in mounts,
+// quota is set for each of them.
+func NewSharedContainerBuilder(setting *config.JfsSetting, mounts []SharedMount) SidecarInterface {
+	return &ContainerBuilder{
+		PodBuilder: PodBuilder{
+			BaseBuilder: BaseBuilder{
+				jfsSetting: setting,
+			}},
+		sharedMounts: mounts,
+	}
+}
+
 // NewMountSidecar generates a pod with a juicefs sidecar
 // exactly the same spec as Mount Pod
 // except fuse passfd path
@@ -72,31 +102,23 @@ func (r *ContainerBuilder) NewMountSidecar() *corev1.Pod {
 	}
 
 	// check mount & create subpath & set quota
-	capacity := ""
-	if r.capacity > 0 {
-		capacity = strconv.FormatInt(r.capacity, 10)
-	}
-	subpath := r.jfsSetting.SubPath
-	community := "ce"
-	if !r.jfsSetting.IsCe {
-		community = "ee"
-	}
-	quotaPath := r.getQuotaPath()
-	name := r.jfsSetting.Name
 	if pod.Spec.Containers[0].Lifecycle == nil {
 		pod.Spec.Containers[0].Lifecycle = &corev1.Lifecycle{}
 	}
+	postStart := r.genCheckMountCommand(r.jfsSetting.SubPath, r.getQuotaPath(), r.capacity)
+	if r.jfsSetting.Warmup != nil {
+		postStart = strings.Join([]string{postStart, r.genWarmupCommand(r.jfsSetting.MountPath, r.jfsSetting.Warmup)}, " && ")
+	}
+	if len(r.sharedMounts) > 0 {
+		postStart = r.genSharedMountCommand()
+		if r.jfsSetting.Attr.Lifecycle == nil && pod.Spec.Containers[0].Lifecycle.PreStop != nil {
+			pod.Spec.Containers[0].Lifecycle.PreStop = &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{Command: []string{"sh", "-c", "+e", r.genSharedUmountCommand()}},
+			}
+		}
+	}
 	pod.Spec.Containers[0].Lifecycle.PostStart = &corev1.LifecycleHandler{
-		Exec: &corev1.ExecAction{Command: []string{"bash", "-c",
-			fmt.Sprintf("time subpath=%s name=%s capacity=%s community=%s quotaPath=%s %s '%s' >> /proc/1/fd/1",
-				security.EscapeBashStr(subpath),
-				security.EscapeBashStr(name),
-				capacity,
-				community,
-				security.EscapeBashStr(quotaPath),
-				checkMountScriptPath,
-				security.EscapeBashStr(r.jfsSetting.MountPath),
-			)}},
+		Exec: &corev1.ExecAction{Command: []string{"bash", "-c", postStart}},
 	}
 
 	mountCmd := r.genMountCommand()
@@ -109,6 +131,54 @@ func (r *ContainerBuilder) NewMountSidecar() *corev1.Pod {
 	return pod
 }
 
+// genCheckMountCommand generates the command waiting for the mount point ready and setting quota of subpath
+func (r *ContainerBuilder) genCheckMountCommand(subpath, quotaPath string, capacity int64) string {
+	capacityStr := ""
+	if capacity > 0 {
+		capacityStr = strconv.FormatInt(capacity, 10)
+	}
+	community := "ce"
+	if !r.jfsSetting.IsCe {
+		community = "ee"
+	}
+	return fmt.Sprintf("time subpath=%s name=%s capacity=%s community=%s quotaPath=%s %s '%s' >> /proc/1/fd/1",
+		security.EscapeBashStr(subpath),
+		security.EscapeBashStr(r.jfsSetting.Name),
+		capacityStr,
+		community,
+		security.EscapeBashStr(quotaPath),
+		checkMountScriptPath,
+		security.EscapeBashStr(r.jfsSetting.MountPath),
+	)
+}
+
+// genSharedMountCommand generates the command setting quota for each PVC of the shared sidecar,
+// and bind mounting their paths to their own mount paths
+func (r *ContainerBuilder) genSharedMountCommand() string {
+	cmds := make([]string, 0, len(r.sharedMounts))
+	for _, m := range r.sharedMounts {
+		source := security.EscapeBashStr(filepath.Join(r.jfsSetting.MountPath, m.BindPath))
+		target := security.EscapeBashStr(m.MountPath)
+		cmd := fmt.Sprintf("%s && mkdir -p %s %s && (mountpoint -q %s || mount --bind %s %s)",
+			r.genCheckMountCommand(m.SubPath, m.QuotaPath, m.Capacity), source, target, target, source, target)
+		if m.Warmup != nil {
+			cmd = strings.Join([]string{cmd, r.genWarmupCommand(m.MountPath, m.Warmup)}, " && ")
+		}
+		cmds = append(cmds, cmd)
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// genSharedUmountCommand generates the command umounting bind mount points of PVCs before the mount point of sidecar
+func (r *ContainerBuilder) genSharedUmountCommand() string {
+	cmds := make([]string, 0, len(r.sharedMounts)+1)
+	for _, m := range r.sharedMounts {
+		cmds = append(cmds, fmt.Sprintf("umount %s -l; rmdir %s", m.MountPath, m.MountPath))
+	}
+	cmds = append(cmds, fmt.Sprintf("umount %s -l; rmdir %s; exit 0", r.jfsSetting.MountPath, r.jfsSetting.MountPath))
+	return strings.Join(cmds, "; ")
+}
+
 func (r *ContainerBuilder) OverwriteVolumeMounts(mount *corev1.VolumeMount) {
 	// do not overwrite volumeMounts
 }
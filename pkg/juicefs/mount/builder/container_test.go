@@ -0,0 +1,56 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package builder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+)
+
+func TestContainerBuilder_genSharedMountCommand(t *testing.T) {
+	setting := &config.JfsSetting{Name: "test", IsCe: true, MountPath: "/jfs/shared", Attr: &config.PodAttr{}}
+	r := NewSharedContainerBuilder(setting, []SharedMount{
+		{MountPath: "/jfs/a", BindPath: "pvc-a", SubPath: "pvc-a", QuotaPath: "/data/pvc-a", Capacity: 10},
+		{MountPath: "/jfs/b", BindPath: ".", QuotaPath: "/data"},
+	}).(*ContainerBuilder)
+
+	want := "time subpath=pvc-a name=test capacity=10 community=ce quotaPath=/data/pvc-a /jfs-scripts/check_mount.sh '/jfs/shared' >> /proc/1/fd/1" +
+		" && mkdir -p /jfs/shared/pvc-a /jfs/a && (mountpoint -q /jfs/a || mount --bind /jfs/shared/pvc-a /jfs/a)" +
+		" && time subpath= name=test capacity= community=ce quotaPath=/data /jfs-scripts/check_mount.sh '/jfs/shared' >> /proc/1/fd/1" +
+		" && mkdir -p /jfs/shared /jfs/b && (mountpoint -q /jfs/b || mount --bind /jfs/shared /jfs/b)"
+	if got := r.genSharedMountCommand(); got != want {
+		t.Errorf("genSharedMountCommand() = %s\nwant %s", got, want)
+	}
+	wantUmount := "umount /jfs/a -l; rmdir /jfs/a; umount /jfs/b -l; rmdir /jfs/b; umount /jfs/shared -l; rmdir /jfs/shared; exit 0"
+	if got := r.genSharedUmountCommand(); got != wantUmount {
+		t.Errorf("genSharedUmountCommand() = %s, want %s", got, wantUmount)
+	}
+}
+
+func TestContainerBuilder_genWarmupCommand(t *testing.T) {
+	setting := &config.JfsSetting{Name: "test", IsCe: true, MountPath: "/jfs/abc", Attr: &config.PodAttr{}}
+	r := NewContainerBuilder(setting, 0).(*ContainerBuilder)
+	warmup := &config.WarmupSetting{Paths: []string{"dataset", "", "a$b"}, Timeout: time.Minute}
+
+	want := `(echo "start warmup of 3 paths, timeout 1m0s" && timeout 60 /usr/local/bin/juicefs warmup /jfs/abc/dataset /jfs/abc $'/jfs/abc/a$b'` +
+		` && echo "warmup succeeded" || echo "warmup failed or timed out, ignored") >> /proc/1/fd/1 2>&1`
+	if got := r.genWarmupCommand(setting.MountPath, warmup); got != want {
+		t.Errorf("genWarmupCommand() = %s\nwant %s", got, want)
+	}
+}
@@ -75,10 +75,9 @@ func (r *JobBuilder) NewJobForDeleteVolume() *batchv1.Job {
 	return job
 }
 
-func (r *JobBuilder) NewJobForCleanCache() *batchv1.Job {
+func (r *JobBuilder) NewJobForCleanCache() (*batchv1.Job, error) {
 	jobName := GenJobNameByVolumeId(r.jfsSetting.VolumeId) + "-cleancache-" + util.RandStringRunes(6)
-	job := r.newCleanJob(jobName)
-	return job
+	return r.newCleanJob(jobName)
 }
 
 func GenJobNameByVolumeId(volumeId string) string {
@@ -88,7 +87,8 @@ func GenJobNameByVolumeId(volumeId string) string {
 }
 
 func (r *JobBuilder) newJob(jobName string) *batchv1.Job {
-	if r.jfsSetting.SecretName == "" {
+	// jobs refer to the secret by envs, do not write plaintext into the encrypted secret of mount pods
+	if r.jfsSetting.SecretName == "" || config.IsEncryptedSecretName(r.jfsSetting.SecretName) {
 		secretName := jobName + "-secret"
 		r.jfsSetting.SecretName = secretName
 	}
@@ -132,8 +132,11 @@ func (r *JobBuilder) newJob(jobName string) *batchv1.Job {
 	return &job
 }
 
-func (r *JobBuilder) newCleanJob(jobName string) *batchv1.Job {
-	podTemplate := r.genCleanCachePod()
+func (r *JobBuilder) newCleanJob(jobName string) (*batchv1.Job, error) {
+	podTemplate, err := r.genCleanCachePod()
+	if err != nil {
+		return nil, err
+	}
 	ttlSecond := DefaultJobTTLSecond
 	podTemplate.Spec.RestartPolicy = corev1.RestartPolicyNever
 	podTemplate.Spec.NodeName = config.NodeName
@@ -156,7 +159,7 @@ func (r *JobBuilder) newCleanJob(jobName string) *batchv1.Job {
 			TTLSecondsAfterFinished: &ttlSecond,
 		},
 	}
-	return &job
+	return &job, nil
 }
 
 func (r *JobBuilder) getCreateVolumeCmd() string {
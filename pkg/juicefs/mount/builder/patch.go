@@ -0,0 +1,110 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+)
+
+// protectedFields are fields of mount pod managed by CSI, which can not be changed by pod patches
+type protectedFields struct {
+	MountContainer string
+	Volumes        map[string]corev1.Volume
+	VolumeMounts   []corev1.VolumeMount
+	Env            map[string]corev1.EnvVar
+}
+
+func getProtectedFields(pod *corev1.Pod) protectedFields {
+	f := protectedFields{
+		Volumes: map[string]corev1.Volume{},
+		Env:     map[string]corev1.EnvVar{},
+	}
+	for _, v := range pod.Spec.Volumes {
+		if config.IsInterVolume(v.Name) {
+			f.Volumes[v.Name] = v
+		}
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return f
+	}
+	f.MountContainer = pod.Spec.Containers[0].Name
+	for _, vm := range pod.Spec.Containers[0].VolumeMounts {
+		if config.IsInterVolume(vm.Name) {
+			f.VolumeMounts = append(f.VolumeMounts, vm)
+		}
+	}
+	for _, env := range pod.Spec.Containers[0].Env {
+		if _, ok := config.CSISetEnvMap[env.Name]; ok {
+			f.Env[env.Name] = env
+		}
+	}
+	return f
+}
+
+// ApplyPodPatches applies generic pod patches to the generated mount pod in order.
+// A patch which fails or changes volumes and envs managed by CSI is skipped, and returned in the error.
+func ApplyPodPatches(pod *corev1.Pod, patches []config.PodPatch) error {
+	var errs []error
+	for i, patch := range patches {
+		patched, err := applyPodPatch(pod, patch)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("pod patch %d: %v", i, err))
+			continue
+		}
+		*pod = *patched
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func applyPodPatch(pod *corev1.Pod, patch config.PodPatch) (*corev1.Pod, error) {
+	data, err := json.Marshal(pod)
+	if err != nil {
+		return nil, err
+	}
+	if patch.StrategicMergePatch != nil {
+		if data, err = strategicpatch.StrategicMergePatch(data, patch.StrategicMergePatch.Raw, corev1.Pod{}); err != nil {
+			return nil, fmt.Errorf("strategic merge patch: %v", err)
+		}
+	}
+	if patch.JSONPatch != nil {
+		p, err := jsonpatch.DecodePatch(patch.JSONPatch.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("decode json patch: %v", err)
+		}
+		if data, err = p.Apply(data); err != nil {
+			return nil, fmt.Errorf("json patch: %v", err)
+		}
+	}
+	patched := &corev1.Pod{}
+	if err := json.Unmarshal(data, patched); err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(getProtectedFields(pod), getProtectedFields(patched)) {
+		return nil, fmt.Errorf("volumes, envs or container %s managed by CSI can not be changed", common.MountContainerName)
+	}
+	return patched, nil
+}
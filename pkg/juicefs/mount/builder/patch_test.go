@@ -0,0 +1,157 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package builder
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+)
+
+func newPatchTestPod() *corev1.Pod {
+	return &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  common.MountContainerName,
+				Image: "juicedata/mount:ce-nightly",
+				Env: []corev1.EnvVar{
+					{Name: "JFS_FOREGROUND", Value: "1"},
+					{Name: "FOO", Value: "bar"},
+				},
+				VolumeMounts: []corev1.VolumeMount{{Name: "jfs-dir", MountPath: "/jfs"}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name: "jfs-dir",
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{
+					Path: "/var/lib/juicefs/volume",
+				}},
+			}},
+		},
+	}
+}
+
+func raw(s string) *runtime.RawExtension {
+	return &runtime.RawExtension{Raw: []byte(s)}
+}
+
+func TestApplyPodPatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		patches []config.PodPatch
+		wantErr bool
+		check   func(t *testing.T, pod *corev1.Pod)
+	}{
+		{
+			name: "strategic merge patch",
+			patches: []config.PodPatch{{
+				StrategicMergePatch: raw(`{"spec":{"priorityClassName":"high","securityContext":{"runAsUser":0},"tolerations":[{"operator":"Exists"}],"containers":[{"name":"jfs-mount","env":[{"name":"FOO","value":"baz"}]}]}}`),
+			}},
+			check: func(t *testing.T, pod *corev1.Pod) {
+				if pod.Spec.PriorityClassName != "high" {
+					t.Errorf("priorityClassName = %s, want high", pod.Spec.PriorityClassName)
+				}
+				if pod.Spec.SecurityContext == nil || pod.Spec.SecurityContext.RunAsUser == nil || *pod.Spec.SecurityContext.RunAsUser != 0 {
+					t.Errorf("securityContext not patched: %v", pod.Spec.SecurityContext)
+				}
+				if len(pod.Spec.Tolerations) != 1 {
+					t.Errorf("tolerations = %v, want 1", pod.Spec.Tolerations)
+				}
+				if len(pod.Spec.Containers[0].Env) != 2 || pod.Spec.Containers[0].Env[1].Value != "baz" {
+					t.Errorf("env = %v, want FOO=baz merged", pod.Spec.Containers[0].Env)
+				}
+			},
+		},
+		{
+			name: "json patch after strategic merge patch",
+			patches: []config.PodPatch{{
+				StrategicMergePatch: raw(`{"spec":{"priorityClassName":"high"}}`),
+				JSONPatch:           raw(`[{"op":"replace","path":"/spec/priorityClassName","value":"low"},{"op":"add","path":"/spec/containers/0/args","value":["-v"]}]`),
+			}},
+			check: func(t *testing.T, pod *corev1.Pod) {
+				if pod.Spec.PriorityClassName != "low" {
+					t.Errorf("priorityClassName = %s, want low", pod.Spec.PriorityClassName)
+				}
+				if len(pod.Spec.Containers[0].Args) != 1 {
+					t.Errorf("args = %v, want [-v]", pod.Spec.Containers[0].Args)
+				}
+			},
+		},
+		{
+			name: "patch changing csi volume is skipped",
+			patches: []config.PodPatch{
+				{JSONPatch: raw(`[{"op":"replace","path":"/spec/volumes/0/hostPath/path","value":"/tmp"}]`)},
+				{StrategicMergePatch: raw(`{"spec":{"priorityClassName":"high"}}`)},
+			},
+			wantErr: true,
+			check: func(t *testing.T, pod *corev1.Pod) {
+				if pod.Spec.Volumes[0].HostPath.Path != "/var/lib/juicefs/volume" {
+					t.Errorf("jfs-dir volume is changed: %v", pod.Spec.Volumes[0])
+				}
+				if pod.Spec.PriorityClassName != "high" {
+					t.Errorf("following patch is not applied")
+				}
+			},
+		},
+		{
+			name: "patch changing csi env is skipped",
+			patches: []config.PodPatch{{
+				StrategicMergePatch: raw(`{"spec":{"priorityClassName":"high","containers":[{"name":"jfs-mount","env":[{"name":"JFS_FOREGROUND","value":"0"}]}]}}`),
+			}},
+			wantErr: true,
+			check: func(t *testing.T, pod *corev1.Pod) {
+				if pod.Spec.Containers[0].Env[0].Value != "1" || pod.Spec.PriorityClassName != "" {
+					t.Errorf("patch is not skipped: %v", pod.Spec)
+				}
+			},
+		},
+		{
+			name: "patch removing csi volume mount is skipped",
+			patches: []config.PodPatch{{
+				JSONPatch: raw(`[{"op":"remove","path":"/spec/containers/0/volumeMounts/0"}]`),
+			}},
+			wantErr: true,
+			check: func(t *testing.T, pod *corev1.Pod) {
+				if len(pod.Spec.Containers[0].VolumeMounts) != 1 {
+					t.Errorf("volume mount is removed")
+				}
+			},
+		},
+		{
+			name: "invalid json patch",
+			patches: []config.PodPatch{{
+				JSONPatch: raw(`[{"op":"remove","path":"/spec/notexist"}]`),
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := newPatchTestPod()
+			err := ApplyPodPatches(pod, tt.patches)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ApplyPodPatches() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, pod)
+			}
+		})
+	}
+}
@@ -24,11 +24,13 @@ import (
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/juicedata/juicefs-csi-driver/pkg/common"
 	"github.com/juicedata/juicefs-csi-driver/pkg/config"
 	"github.com/juicedata/juicefs-csi-driver/pkg/fuse/passfd"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/security"
 )
 
 type PodBuilder struct {
@@ -46,7 +48,14 @@ func NewPodBuilder(setting *config.JfsSetting, capacity int64) *PodBuilder {
 
 // NewMountPod generates a pod with juicefs client
 func (r *PodBuilder) NewMountPod(podName string) (*corev1.Pod, error) {
-	pod := r.genCommonJuicePod(r.genCommonContainer)
+	cnGen := r.genCommonContainer
+	if r.jfsSetting.NonPrivileged {
+		cnGen = r.genNonPrivilegedContainer
+	}
+	pod := r.genCommonJuicePod(cnGen)
+	if r.jfsSetting.NonPrivileged {
+		r.genFuseDevice(pod)
+	}
 	pod.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
 
 	pod.Name = podName
@@ -61,7 +70,15 @@ func (r *PodBuilder) NewMountPod(podName string) (*corev1.Pod, error) {
 		Name:  "JFS_FOREGROUND",
 		Value: "1",
 	})
+	if config.IsEncryptedSecretName(r.jfsSetting.SecretName) {
+		if err := r.genSecretDecryption(pod); err != nil {
+			return nil, err
+		}
+	}
 
+	if r.jfsSetting.NonPrivileged && !config.SupportFusePass(pod) {
+		return nil, fmt.Errorf("non-privileged mount pod requires the FUSE fd passed from csi node, which is not supported by image %s", pod.Spec.Containers[0].Image)
+	}
 	// inject fuse fd
 	if podName != "" && config.SupportFusePass(pod) {
 		fdAddress, err := passfd.GetFdAddress(context.TODO(), r.jfsSetting.UpgradeUUID)
@@ -80,12 +97,18 @@ func (r *PodBuilder) NewMountPod(podName string) (*corev1.Pod, error) {
 	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, volumeMounts...)
 
 	// add cache-dir hostpath & PVC volume
-	cacheVolumes, cacheVolumeMounts := r.genCacheDirVolumes()
+	cacheVolumes, cacheVolumeMounts, err := r.genCacheDirVolumes()
+	if err != nil {
+		return nil, err
+	}
 	pod.Spec.Volumes = append(pod.Spec.Volumes, cacheVolumes...)
 	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, cacheVolumeMounts...)
 
 	// add mount path host path volume
-	mountVolumes, mountVolumeMounts := r.genHostPathVolumes()
+	mountVolumes, mountVolumeMounts, err := r.genHostPathVolumes()
+	if err != nil {
+		return nil, err
+	}
 	pod.Spec.Volumes = append(pod.Spec.Volumes, mountVolumes...)
 	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, mountVolumeMounts...)
 
@@ -105,9 +128,141 @@ func (r *PodBuilder) NewMountPod(podName string) (*corev1.Pod, error) {
 		pod.Spec.InitContainers = append(pod.Spec.InitContainers, r.jfsSetting.Attr.InitContainers...)
 	}
 
+	if err := ApplyPodPatches(pod, r.jfsSetting.Attr.PodPatches); err != nil {
+		builderLog.Error(err, "apply pod patches error, ignore them", "podName", podName)
+	}
 	return pod, nil
 }
 
+// genSecretDecryption makes the mount container decrypt its encrypted secret when it starts, with the csi binary copied
+// by an init container and key files mounted read-only from volumes of csi node, instead of envs and volumes referring
+// to the secret. Decrypted credentials are only kept in memory of the mount container.
+func (r *PodBuilder) genSecretDecryption(pod *corev1.Pod) error {
+	s := config.GlobalConfig.SecretEncryption
+	if s == nil {
+		return fmt.Errorf("secret %s is encrypted, but secretEncryption is not configured", r.jfsSetting.SecretName)
+	}
+	csi, keyVolumes, keyMounts, err := secretKeyVolumes(s.KeyFiles)
+	if err != nil {
+		return err
+	}
+	cn := &pod.Spec.Containers[0]
+	envs := make([]corev1.EnvVar, 0, len(cn.Env))
+	for _, env := range cn.Env {
+		if ref := env.ValueFrom; ref != nil && ref.SecretKeyRef != nil &&
+			ref.SecretKeyRef.Name == r.jfsSetting.SecretName {
+			continue
+		}
+		envs = append(envs, env)
+	}
+	cn.Env = append(envs, corev1.EnvVar{Name: common.JfsEncryptedSecretDirEnv, Value: encryptedSecretDir})
+
+	secretVolumes := map[string]bool{}
+	volumes := make([]corev1.Volume, 0, len(pod.Spec.Volumes))
+	for _, v := range pod.Spec.Volumes {
+		if v.Secret != nil && v.Secret.SecretName == r.jfsSetting.SecretName {
+			secretVolumes[v.Name] = true
+			continue
+		}
+		volumes = append(volumes, v)
+	}
+	mounts := make([]corev1.VolumeMount, 0, len(cn.VolumeMounts))
+	for _, m := range cn.VolumeMounts {
+		if !secretVolumes[m.Name] {
+			mounts = append(mounts, m)
+		}
+	}
+	secretMode := int32(0400)
+	volumes = append(volumes, corev1.Volume{
+		Name: encryptedSecretVolumeName,
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+			SecretName:  r.jfsSetting.SecretName,
+			DefaultMode: &secretMode,
+		}},
+	}, corev1.Volume{
+		Name:         csiBinVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	mounts = append(mounts, corev1.VolumeMount{
+		Name:      encryptedSecretVolumeName,
+		MountPath: encryptedSecretDir,
+		ReadOnly:  true,
+	}, corev1.VolumeMount{
+		Name:      csiBinVolumeName,
+		MountPath: csiBinDir,
+		ReadOnly:  true,
+	})
+	pod.Spec.Volumes = append(volumes, keyVolumes...)
+	cn.VolumeMounts = append(mounts, keyMounts...)
+
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, corev1.Container{
+		Name:            csiBinVolumeName,
+		Image:           csi.Image,
+		ImagePullPolicy: csi.ImagePullPolicy,
+		Command:         []string{"cp", csiBinPath, csiBinDir},
+		// the same as the mount container, so that the init container does not change resources of the pod
+		Resources:    *cn.Resources.DeepCopy(),
+		VolumeMounts: []corev1.VolumeMount{{Name: csiBinVolumeName, MountPath: csiBinDir}},
+	})
+
+	keyArgs := ""
+	for _, f := range s.KeyFiles {
+		keyArgs += " --key-file=" + security.EscapeBashStr(f)
+	}
+	decryptCmd := fmt.Sprintf(`secrets="$(%s decrypt-secret --secret-dir="$%s"%s)" || exit 1
+eval "$secrets"
+unset secrets`, path.Join(csiBinDir, path.Base(csiBinPath)), common.JfsEncryptedSecretDirEnv, keyArgs)
+	cn.Command[len(cn.Command)-1] = decryptCmd + "\n" + cn.Command[len(cn.Command)-1]
+	return nil
+}
+
+// secretKeyVolumes returns the container of csi node which mounts key files of secretEncryption, with volumes and
+// read-only volumeMounts of the key files for mount containers
+func secretKeyVolumes(keyFiles []string) (*corev1.Container, []corev1.Volume, []corev1.VolumeMount, error) {
+	for i := range config.CSIPod.Spec.Containers {
+		csi := &config.CSIPod.Spec.Containers[i]
+		if len(keyFiles) == 0 || findVolumeMount(csi.VolumeMounts, keyFiles[0]) == nil {
+			continue
+		}
+		var volumes []corev1.Volume
+		var mounts []corev1.VolumeMount
+		added := map[string]bool{}
+		for _, f := range keyFiles {
+			m := findVolumeMount(csi.VolumeMounts, f)
+			if m == nil {
+				return nil, nil, nil, fmt.Errorf("key file %s of secretEncryption is not mounted in container %s of csi node", f, csi.Name)
+			}
+			if added[m.Name] {
+				continue
+			}
+			added[m.Name] = true
+			name := secretKeyVolumePrefix + m.Name
+			for _, v := range config.CSIPod.Spec.Volumes {
+				if v.Name == m.Name {
+					volumes = append(volumes, corev1.Volume{Name: name, VolumeSource: *v.VolumeSource.DeepCopy()})
+				}
+			}
+			mounts = append(mounts, corev1.VolumeMount{Name: name, MountPath: m.MountPath, SubPath: m.SubPath, ReadOnly: true})
+		}
+		return csi, volumes, mounts, nil
+	}
+	return nil, nil, nil, fmt.Errorf("key files %v of secretEncryption are not mounted in csi node", keyFiles)
+}
+
+// findVolumeMount returns the volumeMount which file is in, the innermost one if there are more than one
+func findVolumeMount(mounts []corev1.VolumeMount, file string) *corev1.VolumeMount {
+	var found *corev1.VolumeMount
+	for i, m := range mounts {
+		if file != m.MountPath && !strings.HasPrefix(file, strings.TrimSuffix(m.MountPath, "/")+"/") {
+			continue
+		}
+		if found == nil || len(m.MountPath) > len(found.MountPath) {
+			found = &mounts[i]
+		}
+	}
+	return found
+}
+
 // genCommonContainer: generate common privileged container
 func (r *PodBuilder) genCommonContainer() corev1.Container {
 	isPrivileged := true
@@ -129,19 +284,82 @@ func (r *PodBuilder) genCommonContainer() corev1.Container {
 	}
 }
 
-func (r *PodBuilder) expandMountPodTemplate(value string) string {
-	return config.ReplaceMountPodTemplate(value, *r.jfsSetting)
+// genNonPrivilegedContainer: generate container without privileged, which only has CAP_SYS_ADMIN to mount fuse
+func (r *PodBuilder) genNonPrivilegedContainer() corev1.Container {
+	isPrivileged := false
+	rootUser := int64(0)
+	return corev1.Container{
+		Name:            common.MountContainerName,
+		Image:           r.BaseBuilder.jfsSetting.Attr.Image,
+		ImagePullPolicy: r.BaseBuilder.jfsSetting.Attr.ImagePullPolicy,
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: &isPrivileged,
+			RunAsUser:  &rootUser,
+			Capabilities: &corev1.Capabilities{
+				Add: []corev1.Capability{"SYS_ADMIN"},
+			},
+			// the default AppArmor profile of container runtimes denies mount
+			AppArmorProfile: &corev1.AppArmorProfile{
+				Type: corev1.AppArmorProfileTypeUnconfined,
+			},
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  common.JfsInsideContainer,
+				Value: "1",
+			},
+		},
+	}
+}
+
+// genFuseDevice: request /dev/fuse for non-privileged mount pod, from the device plugin or as a CDI device
+func (r *PodBuilder) genFuseDevice(pod *corev1.Pod) {
+	cfg := config.GlobalConfig.NonPrivilegedMountPod
+	if cfg == nil {
+		return
+	}
+	if cfg.FuseResource != "" {
+		// copy resources which are shared with the setting
+		resources := &pod.Spec.Containers[0].Resources
+		resources.Limits, resources.Requests = resources.Limits.DeepCopy(), resources.Requests.DeepCopy()
+		if resources.Limits == nil {
+			resources.Limits = corev1.ResourceList{}
+		}
+		if resources.Requests == nil {
+			resources.Requests = corev1.ResourceList{}
+		}
+		// extended resources can't be overcommitted, the request must equal to the limit
+		resources.Limits[corev1.ResourceName(cfg.FuseResource)] = resource.MustParse("1")
+		resources.Requests[corev1.ResourceName(cfg.FuseResource)] = resource.MustParse("1")
+	}
+	if cfg.CDIDevice != "" {
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[CDIFuseDeviceAnnotation] = cfg.CDIDevice
+	}
+}
+
+func (r *PodBuilder) expandMountPodTemplate(value string) (string, error) {
+	expanded, err := config.ExpandMountPodTemplate(value, *r.jfsSetting)
+	if err != nil {
+		return "", fmt.Errorf("expand template %q error: %v", value, err)
+	}
+	return expanded, nil
 }
 
 // genCacheDirVolumes: generate cache-dir hostpath & PVC volume
-func (r *PodBuilder) genCacheDirVolumes() ([]corev1.Volume, []corev1.VolumeMount) {
+func (r *PodBuilder) genCacheDirVolumes() ([]corev1.Volume, []corev1.VolumeMount, error) {
 	cacheVolumes := []corev1.Volume{}
 	cacheVolumeMounts := []corev1.VolumeMount{}
 
 	hostPathType := corev1.HostPathDirectoryOrCreate
 
 	for idx, cacheDir := range r.jfsSetting.CacheDirs {
-		cacheDir = r.expandMountPodTemplate(cacheDir)
+		cacheDir, err := r.expandMountPodTemplate(cacheDir)
+		if err != nil {
+			return nil, nil, err
+		}
 		name := fmt.Sprintf("cachedir-%d", idx)
 
 		hostPath := corev1.HostPathVolumeSource{
@@ -256,18 +474,20 @@ func (r *PodBuilder) genCacheDirVolumes() ([]corev1.Volume, []corev1.VolumeMount
 		})
 	}
 
-	return cacheVolumes, cacheVolumeMounts
+	return cacheVolumes, cacheVolumeMounts, nil
 }
 
 // genHostPathVolumes: generate host path volumes
-func (r *PodBuilder) genHostPathVolumes() (volumes []corev1.Volume, volumeMounts []corev1.VolumeMount) {
+func (r *PodBuilder) genHostPathVolumes() (volumes []corev1.Volume, volumeMounts []corev1.VolumeMount, err error) {
 	volumes = []corev1.Volume{}
 	volumeMounts = []corev1.VolumeMount{}
 	if len(r.jfsSetting.HostPath) == 0 {
 		return
 	}
 	for idx, hostPath := range r.jfsSetting.HostPath {
-		hostPath = r.expandMountPodTemplate(hostPath)
+		if hostPath, err = r.expandMountPodTemplate(hostPath); err != nil {
+			return nil, nil, err
+		}
 		name := fmt.Sprintf("hostpath-%d", idx)
 		volumes = append(volumes, corev1.Volume{
 			Name: name,
@@ -293,6 +513,11 @@ func (r *PodBuilder) genPodVolumes() ([]corev1.Volume, []corev1.VolumeMount) {
 	dir := corev1.HostPathDirectoryOrCreate
 	file := corev1.HostPathFileOrCreate
 	mp := corev1.MountPropagationBidirectional
+	if r.jfsSetting.NonPrivileged {
+		// Bidirectional is only allowed in privileged containers, FUSE is mounted by csi node in jfs dir instead,
+		// which propagates to the host and then to the mount pod, and the client gets the FUSE fd from csi node
+		mp = corev1.MountPropagationHostToContainer
+	}
 	volumes := []corev1.Volume{
 		{
 			Name: JfsDirName,
@@ -345,7 +570,7 @@ func (r *PodBuilder) genPodVolumes() ([]corev1.Volume, []corev1.VolumeMount) {
 }
 
 // genCleanCachePod: generate pod to clean cache in host
-func (r *PodBuilder) genCleanCachePod() *corev1.Pod {
+func (r *PodBuilder) genCleanCachePod() (*corev1.Pod, error) {
 	volumeMountPrefix := "/var/jfsCache"
 	cacheVolumes := []corev1.Volume{}
 	cacheVolumeMounts := []corev1.VolumeMount{}
@@ -353,7 +578,10 @@ func (r *PodBuilder) genCleanCachePod() *corev1.Pod {
 	hostPathType := corev1.HostPathDirectoryOrCreate
 
 	for idx, cacheDir := range r.jfsSetting.CacheDirs {
-		cacheDir = r.expandMountPodTemplate(cacheDir)
+		cacheDir, err := r.expandMountPodTemplate(cacheDir)
+		if err != nil {
+			return nil, err
+		}
 		name := fmt.Sprintf("cachedir-%d", idx)
 
 		hostPathVolume := corev1.Volume{
@@ -390,5 +618,5 @@ func (r *PodBuilder) genCleanCachePod() *corev1.Pod {
 			Volumes: cacheVolumes,
 		},
 	}
-	return pod
+	return pod, nil
 }
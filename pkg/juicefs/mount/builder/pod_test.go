@@ -23,6 +23,7 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -190,7 +191,10 @@ func Test_getCacheDirVolumes(t *testing.T) {
 	s, _ := config.ParseSetting(context.TODO(), map[string]string{"name": "test"}, nil, optionWithoutCacheDir, "", "", "test", nil, nil)
 	s.HashVal = "test"
 	r.jfsSetting = s
-	cacheVolumes, cacheVolumeMounts := r.genCacheDirVolumes()
+	cacheVolumes, cacheVolumeMounts, err := r.genCacheDirVolumes()
+	if err != nil {
+		t.Fatal(err)
+	}
 	volumes = append(volumes, cacheVolumes...)
 	volumeMounts = append(volumeMounts, cacheVolumeMounts...)
 	if len(volumes) != 2 || len(volumeMounts) != 2 {
@@ -200,7 +204,10 @@ func Test_getCacheDirVolumes(t *testing.T) {
 	s, _ = config.ParseSetting(context.TODO(), map[string]string{"name": "test"}, nil, optionWithCacheDir, "", "", "test", nil, nil)
 	s.HashVal = "test"
 	r.jfsSetting = s
-	cacheVolumes, cacheVolumeMounts = r.genCacheDirVolumes()
+	cacheVolumes, cacheVolumeMounts, err = r.genCacheDirVolumes()
+	if err != nil {
+		t.Fatal(err)
+	}
 	volumes = append(volumes, cacheVolumes...)
 	volumeMounts = append(volumeMounts, cacheVolumeMounts...)
 	if len(volumes) != 3 || len(volumeMounts) != 3 {
@@ -210,7 +217,10 @@ func Test_getCacheDirVolumes(t *testing.T) {
 	s, _ = config.ParseSetting(context.TODO(), map[string]string{"name": "test"}, nil, optionWithCacheDir2, "", "", "test", nil, nil)
 	s.HashVal = "test"
 	r.jfsSetting = s
-	cacheVolumes, cacheVolumeMounts = r.genCacheDirVolumes()
+	cacheVolumes, cacheVolumeMounts, err = r.genCacheDirVolumes()
+	if err != nil {
+		t.Fatal(err)
+	}
 	volumes = append(volumes, cacheVolumes...)
 	volumeMounts = append(volumeMounts, cacheVolumeMounts...)
 	if len(volumes) != 5 || len(volumeMounts) != 5 {
@@ -220,7 +230,10 @@ func Test_getCacheDirVolumes(t *testing.T) {
 	s, _ = config.ParseSetting(context.TODO(), map[string]string{"name": "test"}, nil, optionWithCacheDir3, "", "", "test", nil, nil)
 	s.HashVal = "test"
 	r.jfsSetting = s
-	cacheVolumes, cacheVolumeMounts = r.genCacheDirVolumes()
+	cacheVolumes, cacheVolumeMounts, err = r.genCacheDirVolumes()
+	if err != nil {
+		t.Fatal(err)
+	}
 	volumes = append(volumes, cacheVolumes...)
 	volumeMounts = append(volumeMounts, cacheVolumeMounts...)
 	if len(volumes) != 6 || len(volumeMounts) != 6 {
@@ -244,7 +257,10 @@ func Test_getCacheDirVolumes(t *testing.T) {
 		},
 	}
 	r.jfsSetting = s
-	cacheVolumes, cacheVolumeMounts = r.genCacheDirVolumes()
+	cacheVolumes, cacheVolumeMounts, err = r.genCacheDirVolumes()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// verify the ephemeral volume and mount are created correctly
 	foundEphemeralVolume := false
@@ -376,7 +392,10 @@ func TestNewMountPod(t *testing.T) {
 		BaseBuilder: BaseBuilder{s, 0},
 	}
 	cmdWithCacheDir := `exec /bin/mount.juicefs ${metaurl} /jfs/default-imagenet -o cache-dir=/dev/shm/imagenet-0:/dev/shm/imagenet-1,cache-size=10240,metrics=0.0.0.0:9567`
-	cacheVolumes, cacheVolumeMounts := r.genCacheDirVolumes()
+	cacheVolumes, cacheVolumeMounts, err := r.genCacheDirVolumes()
+	if err != nil {
+		t.Fatal(err)
+	}
 	podCacheTest := corev1.Pod{}
 	deepcopyPodFromDefault(&podCacheTest)
 	podCacheTest.Spec.Containers[0].Command = []string{"sh", "-c", cmdWithCacheDir}
@@ -710,6 +729,214 @@ func TestNewMountPod_ExpandTemplateHostPath(t *testing.T) {
 	if assert.NotNil(t, hostPathMount) {
 		assert.Equal(t, expectedHostPath, hostPathMount.MountPath)
 	}
+
+	// templates failed to expand are not mounted as they are
+	r.jfsSetting.HostPath = []string{"/var/jfsCache/${.PVC.labels.team | required}"}
+	_, err = r.NewMountPod(podName)
+	assert.ErrorContains(t, err, "value is required")
+}
+
+func TestNewMountPod_NonPrivileged(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	volumeID := "pvc-non-privileged"
+	podName := fmt.Sprintf("juicefs-%s-%s", config.NodeName, volumeID)
+	tests := []struct {
+		name      string
+		cfg       *config.NonPrivilegedMountPod
+		image     string
+		wantLimit string
+		wantAnno  string
+		wantErr   bool
+	}{
+		{
+			name:      "device plugin",
+			cfg:       &config.NonPrivilegedMountPod{FuseResource: "smarter-devices/fuse"},
+			image:     unsupoortFusePassImage,
+			wantLimit: "smarter-devices/fuse",
+		},
+		{
+			name:     "cdi device",
+			cfg:      &config.NonPrivilegedMountPod{CDIDevice: "juicefs.com/fuse=fuse"},
+			image:    unsupoortFusePassImage,
+			wantAnno: "juicefs.com/fuse=fuse",
+		},
+		{
+			name:    "image without fuse fd passing",
+			cfg:     &config.NonPrivilegedMountPod{CDIDevice: "juicefs.com/fuse=fuse"},
+			image:   "juicedata/mount:ce-v1.1.0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.GlobalConfig.NonPrivilegedMountPod = tt.cfg
+			jfsSetting, err := config.ParseSetting(
+				context.TODO(),
+				map[string]string{
+					"name":    "test",
+					"metaurl": "redis://127.0.0.1:6379/0",
+				},
+				map[string]string{common.MountPodPrivilegedKey: "false"},
+				nil,
+				volumeID,
+				volumeID,
+				"test",
+				nil,
+				nil,
+			)
+			if !assert.NoError(t, err) || !assert.True(t, jfsSetting.NonPrivileged) {
+				return
+			}
+			jfsSetting.HashVal = "test"
+			jfsSetting.UpgradeUUID = "test"
+			jfsSetting.MountPath = path.Join(config.PodMountBase, volumeID)
+			jfsSetting.Attr.Image = tt.image
+
+			r := PodBuilder{
+				BaseBuilder: BaseBuilder{jfsSetting, 0},
+			}
+			pod, err := r.NewMountPod(podName)
+			if tt.wantErr {
+				assert.Error(t, err, "FUSE of non-privileged mount pod can only be mounted by csi node")
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			sc := pod.Spec.Containers[0].SecurityContext
+			assert.False(t, *sc.Privileged)
+			assert.Equal(t, []corev1.Capability{"SYS_ADMIN"}, sc.Capabilities.Add)
+			// FUSE is mounted by csi node in jfs dir, and propagated from the host to the mount pod
+			for _, m := range pod.Spec.Containers[0].VolumeMounts {
+				if m.Name == JfsDirName {
+					assert.Equal(t, config.PodMountBase, m.MountPath)
+					assert.Equal(t, corev1.MountPropagationHostToContainer, *m.MountPropagation)
+				}
+			}
+			for _, v := range pod.Spec.Volumes {
+				if v.Name == JfsDirName {
+					assert.Equal(t, config.MountPointPath, v.HostPath.Path)
+				}
+			}
+			// the client gets the FUSE fd from csi node
+			hasCommEnv := false
+			for _, env := range pod.Spec.Containers[0].Env {
+				if env.Name == common.JfsCommEnv {
+					hasCommEnv = true
+				}
+			}
+			assert.True(t, hasCommEnv, "non-privileged mount pod should get FUSE fd from csi node")
+			if tt.wantLimit != "" {
+				resources := pod.Spec.Containers[0].Resources
+				assert.Equal(t, "1", resources.Limits.Name(corev1.ResourceName(tt.wantLimit), "").String())
+				assert.Equal(t, "1", resources.Requests.Name(corev1.ResourceName(tt.wantLimit), "").String())
+				_, ok := jfsSetting.Attr.Resources.Limits[corev1.ResourceName(tt.wantLimit)]
+				assert.False(t, ok, "resources of setting should not be changed")
+			}
+			assert.Equal(t, tt.wantAnno, pod.Annotations[CDIFuseDeviceAnnotation])
+		})
+	}
+}
+
+func TestNewMountPod_SecretEncryption(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	defer func(pod corev1.Pod) { config.CSIPod = pod }(config.CSIPod)
+	keyDir := t.TempDir()
+	keyFile := path.Join(keyDir, "key")
+	if err := os.WriteFile(keyFile, []byte("MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE="), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config.GlobalConfig.SecretEncryption = &config.SecretEncryption{KeyFiles: []string{keyFile}}
+	keySource := corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "juicefs-secret-encryption"}}
+	config.CSIPod = corev1.Pod{Spec: corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Name:         "juicefs-plugin",
+			Image:        "juicedata/juicefs-csi-driver:test",
+			VolumeMounts: []corev1.VolumeMount{{Name: "kubelet-dir", MountPath: "/var/lib/kubelet"}, {Name: "keys", MountPath: keyDir}},
+		}},
+		Volumes: []corev1.Volume{{Name: "keys", VolumeSource: keySource}},
+	}}
+	volumeID := "pvc-secret-encryption"
+	podName := fmt.Sprintf("juicefs-%s-%s", config.NodeName, volumeID)
+	jfsSetting, err := config.ParseSetting(
+		context.TODO(),
+		map[string]string{
+			"name":            "test",
+			"metaurl":         "redis://:pass@127.0.0.1:6379/0",
+			"encrypt_rsa_key": "rsa",
+			"envs":            `{"A": "1", "B-C": "2"}`,
+		},
+		nil,
+		nil,
+		volumeID,
+		volumeID,
+		"test",
+		nil,
+		nil,
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	jfsSetting.HashVal = "test"
+	jfsSetting.UpgradeUUID = "test"
+	jfsSetting.MountPath = path.Join(config.PodMountBase, volumeID)
+	jfsSetting.SecretName = config.MountSecretName(volumeID, false)
+	jfsSetting.Attr.Image = unsupoortFusePassImage
+
+	r := PodBuilder{
+		BaseBuilder: BaseBuilder{jfsSetting, 0},
+	}
+	pod, err := r.NewMountPod(podName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	refs := map[string]string{}
+	for _, env := range pod.Spec.Containers[0].Env {
+		if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+			refs[env.Name] = env.ValueFrom.SecretKeyRef.Key
+		} else {
+			refs[env.Name] = env.Value
+		}
+	}
+	assert.NotContains(t, refs, "metaurl")
+	assert.NotContains(t, refs, "A")
+	assert.NotContains(t, refs, "B-C", "keys which are not shell variable names are written to files by the decrypted script")
+	assert.Equal(t, encryptedSecretDir, refs[common.JfsEncryptedSecretDirEnv])
+	volumes := map[string]corev1.VolumeSource{}
+	for _, v := range pod.Spec.Volumes {
+		volumes[v.Name] = v.VolumeSource
+	}
+	assert.NotContains(t, volumes, "rsa-key")
+	assert.Equal(t, keySource, volumes[secretKeyVolumePrefix+"keys"])
+	assert.Equal(t, jfsSetting.SecretName, volumes[encryptedSecretVolumeName].Secret.SecretName)
+	mounts := map[string]corev1.VolumeMount{}
+	for _, m := range pod.Spec.Containers[0].VolumeMounts {
+		mounts[m.Name] = m
+	}
+	assert.NotContains(t, mounts, "rsa-key")
+	assert.Equal(t, corev1.VolumeMount{Name: secretKeyVolumePrefix + "keys", MountPath: keyDir, ReadOnly: true}, mounts[secretKeyVolumePrefix+"keys"])
+	assert.True(t, mounts[encryptedSecretVolumeName].ReadOnly)
+	if assert.Len(t, pod.Spec.InitContainers, 1) {
+		assert.Equal(t, "juicedata/juicefs-csi-driver:test", pod.Spec.InitContainers[0].Image)
+		assert.Equal(t, []string{"cp", csiBinPath, csiBinDir}, pod.Spec.InitContainers[0].Command)
+	}
+	cmd := pod.Spec.Containers[0].Command[2]
+	assert.Contains(t, cmd, csiBinDir+"/juicefs-csi-driver decrypt-secret --secret-dir=\"$"+common.JfsEncryptedSecretDirEnv+"\" --key-file="+keyFile)
+	assert.Less(t, strings.Index(cmd, "decrypt-secret"), strings.Index(cmd, "juicefs format"))
+
+	// key files must be mounted in csi node, so that mount containers can read them
+	config.CSIPod.Spec.Containers[0].VolumeMounts = nil
+	_, err = r.NewMountPod(podName)
+	assert.ErrorContains(t, err, "not mounted in csi node")
+
+	secret := r.NewSecret()
+	if !assert.NoError(t, EncryptSecret(&secret)) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(secret.StringData["metaurl"], "enc:v1:"))
+	assert.True(t, strings.HasPrefix(secret.StringData["jfsSettings"], "enc:v1:"))
+	assert.True(t, strings.HasPrefix(secret.StringData["B-C"], "enc:v1:"))
+	assert.NotContains(t, secret.StringData[checkMountScriptName], "enc:v1:")
 }
 
 func TestPodMount_getCommand(t *testing.T) {
@@ -717,6 +944,8 @@ func TestPodMount_getCommand(t *testing.T) {
 		mountPath string
 		subPath   string
 		options   []string
+		shareMode string
+		readOnly  bool
 	}
 	tests := []struct {
 		name   string
@@ -756,6 +985,31 @@ func TestPodMount_getCommand(t *testing.T) {
 			},
 			want: "exec /sbin/mount.juicefs test /jfs/test-volume -o foreground,no-update,subdir=test/jfs/test-volume/subpath",
 		},
+		{
+			name:   "test-share-mode",
+			isCe:   true,
+			source: "redis://127.0.0.1:6379/0",
+			args: args{
+				mountPath: "/jfs/test-volume",
+				options:   []string{"subdir=test"},
+				subPath:   "pvc-1",
+				shareMode: "fsShareMount",
+			},
+			want: "exec /bin/mount.juicefs ${metaurl} /jfs/test-volume -o subdir=test,metrics=0.0.0.0:9567",
+		},
+		{
+			name:   "test-share-mode-read-only",
+			isCe:   true,
+			source: "redis://127.0.0.1:6379/0",
+			args: args{
+				mountPath: "/jfs/test-volume",
+				options:   []string{"subdir=test", "ro", "read-only"},
+				subPath:   "pvc-1",
+				shareMode: "fsShareMount",
+				readOnly:  true,
+			},
+			want: "exec /bin/mount.juicefs ${metaurl} /jfs/test-volume -o ro,read-only,subdir=test/pvc-1,metrics=0.0.0.0:9567",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -768,6 +1022,9 @@ func TestPodMount_getCommand(t *testing.T) {
 				SubPath:   tt.args.subPath,
 				Options:   tt.args.options,
 				Attr:      &config.PodAttr{},
+
+				MountShareMode: tt.args.shareMode,
+				ReadOnly:       tt.args.readOnly,
 			}
 			r := PodBuilder{
 				BaseBuilder: BaseBuilder{jfsSetting, 0},
@@ -864,7 +1121,10 @@ func TestBuilder_genHostPathVolumes(t *testing.T) {
 					jfsSetting: tt.fields.jfsSetting,
 				},
 			}
-			gotVolumes, gotVolumeMounts := r.genHostPathVolumes()
+			gotVolumes, gotVolumeMounts, err := r.genHostPathVolumes()
+			if err != nil {
+				t.Fatal(err)
+			}
 			if !reflect.DeepEqual(gotVolumes, tt.wantVolumes) {
 				t.Errorf("genHostPathVolumes() gotVolumes = %v, want %v", gotVolumes, tt.wantVolumes)
 			}
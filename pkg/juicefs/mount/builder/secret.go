@@ -17,6 +17,7 @@ limitations under the License.
 package builder
 
 import (
+	"fmt"
 	"strings"
 
 	batchv1 "k8s.io/api/batch/v1"
@@ -25,6 +26,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
 )
 
 const (
@@ -34,6 +36,14 @@ const (
 	// race condition with projected volumes (kubernetes/kubernetes#63726).
 	checkMountScriptDir  = "/jfs-scripts"
 	checkMountScriptPath = checkMountScriptDir + "/" + checkMountScriptName
+
+	// the encrypted secret is mounted in mount containers, and decrypted by the csi binary copied from the csi image
+	encryptedSecretVolumeName = "juicefs-encrypted-secret"
+	encryptedSecretDir        = "/etc/juicefs-encrypted-secret"
+	csiBinVolumeName          = "juicefs-csi-bin"
+	csiBinDir                 = "/juicefs-csi-bin"
+	csiBinPath                = "/usr/local/bin/juicefs-csi-driver"
+	secretKeyVolumePrefix     = "juicefs-key-"
 )
 
 var (
@@ -69,22 +79,24 @@ fi
 `
 )
 
+// NewSecret generates the secret of mount pod, credentials from the external provider are not put in it,
+// mount containers fetch them from the provider instead.
 func (r *BaseBuilder) NewSecret() corev1.Secret {
 	data := make(map[string]string)
 	data["jfsSettings"] = r.jfsSetting.String()
-	if r.jfsSetting.MetaUrl != "" {
+	if r.jfsSetting.MetaUrl != "" && !r.jfsSetting.Credential.Provides("metaurl") {
 		data["metaurl"] = r.jfsSetting.MetaUrl
 	}
-	if r.jfsSetting.SecretKey != "" {
+	if r.jfsSetting.SecretKey != "" && !r.jfsSetting.Credential.Provides("secret-key") {
 		data["secretkey"] = r.jfsSetting.SecretKey
 	}
-	if r.jfsSetting.SecretKey2 != "" {
+	if r.jfsSetting.SecretKey2 != "" && !r.jfsSetting.Credential.Provides("secret-key2") {
 		data["secretkey2"] = r.jfsSetting.SecretKey2
 	}
-	if r.jfsSetting.Token != "" {
+	if r.jfsSetting.Token != "" && !r.jfsSetting.Credential.Provides("token") {
 		data["token"] = r.jfsSetting.Token
 	}
-	if r.jfsSetting.Passphrase != "" {
+	if r.jfsSetting.Passphrase != "" && !r.jfsSetting.Credential.Provides("passphrase") {
 		data["passphrase"] = r.jfsSetting.Passphrase
 	}
 	if r.jfsSetting.EncryptRsaKey != "" {
@@ -118,21 +130,44 @@ func (r *BaseBuilder) NewSecret() corev1.Secret {
 	return secret
 }
 
+// EncryptSecret encrypts the secret of mount pod if it's named as an encrypted one, except the check mount script
+// which is not a credential, the mount container loads the secret from the env file written by csi node
+func EncryptSecret(secret *corev1.Secret) error {
+	if !config.IsEncryptedSecretName(secret.Name) {
+		return nil
+	}
+	keyring, err := config.SecretKeyring()
+	if err != nil {
+		return fmt.Errorf("load keys of secret encryption: %v", err)
+	}
+	if keyring == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(secret.StringData))
+	for k := range secret.StringData {
+		if k != checkMountScriptName {
+			keys = append(keys, k)
+		}
+	}
+	secret.StringData, err = keyring.Encrypt(secret.StringData, keys...)
+	return err
+}
+
 func (r *BaseBuilder) GetEnvKey() []string {
 	keys := []string{}
-	if r.jfsSetting.MetaUrl != "" {
+	if r.jfsSetting.MetaUrl != "" && !r.jfsSetting.Credential.Provides("metaurl") {
 		keys = append(keys, "metaurl")
 	}
-	if r.jfsSetting.SecretKey != "" {
+	if r.jfsSetting.SecretKey != "" && !r.jfsSetting.Credential.Provides("secret-key") {
 		keys = append(keys, "secretkey")
 	}
-	if r.jfsSetting.SecretKey2 != "" {
+	if r.jfsSetting.SecretKey2 != "" && !r.jfsSetting.Credential.Provides("secret-key2") {
 		keys = append(keys, "secretkey2")
 	}
-	if r.jfsSetting.Token != "" {
+	if r.jfsSetting.Token != "" && !r.jfsSetting.Credential.Provides("token") {
 		keys = append(keys, "token")
 	}
-	if r.jfsSetting.Passphrase != "" {
+	if r.jfsSetting.Passphrase != "" && !r.jfsSetting.Credential.Provides("passphrase") {
 		keys = append(keys, "passphrase")
 	}
 	if strings.Contains(r.jfsSetting.FormatOptions, "session-token") {
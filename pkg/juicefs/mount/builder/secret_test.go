@@ -18,9 +18,11 @@ package builder
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/credential"
 )
 
 func TestBaseBuilder_GetEnvKey(t *testing.T) {
@@ -48,6 +50,18 @@ func TestBaseBuilder_GetEnvKey(t *testing.T) {
 				"metaurl", "secretkey", "secretkey2", "token", "passphrase", "a",
 			},
 		},
+		{
+			name: "test-credential-provider",
+			fields: fields{
+				jfsSetting: &config.JfsSetting{
+					MetaUrl:    "redis://",
+					SecretKey:  "test_secret",
+					Passphrase: "test_passphrase",
+					Credential: &credential.Ref{Provider: "file", Path: "/mnt/creds", Keys: []string{"metaurl", "secret-key"}},
+				},
+			},
+			want: []string{"passphrase"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -60,3 +74,30 @@ func TestBaseBuilder_GetEnvKey(t *testing.T) {
 		})
 	}
 }
+
+func TestBaseBuilder_NewSecretWithCredentialProvider(t *testing.T) {
+	r := &BaseBuilder{
+		jfsSetting: &config.JfsSetting{
+			Name:       "test",
+			IsCe:       true,
+			MetaUrl:    "redis://:pass@redis:6379/1",
+			Source:     "redis://:pass@redis:6379/1",
+			SecretKey:  "test_secret",
+			FormatCmd:  "/usr/local/bin/juicefs format --secret-key=${secretkey} ${metaurl} test",
+			Credential: &credential.Ref{Provider: "file", Path: "/mnt/creds", Keys: []string{"metaurl", "secret-key"}},
+			Attr:       &config.PodAttr{},
+		},
+	}
+	secret := r.NewSecret()
+	for k, v := range secret.StringData {
+		if strings.Contains(v, "pass@redis") || strings.Contains(v, "test_secret") {
+			t.Errorf("raw credential is put in secret key %s: %s", k, v)
+		}
+	}
+	want := `metaurl="$(cat '/mnt/creds/metaurl')" || exit 1; export metaurl
+secretkey="$(cat '/mnt/creds/secret-key')" || exit 1; export secretkey
+/usr/local/bin/juicefs format --secret-key=${secretkey} ${metaurl} test`
+	if got := r.genInitCommand(); got != want {
+		t.Errorf("genInitCommand() = %s, want %s", got, want)
+	}
+}
@@ -73,17 +73,20 @@ func (r *ServerlessBuilder) NewMountSidecar() *corev1.Pod {
 	}
 	quotaPath := r.getQuotaPath()
 	name := r.jfsSetting.Name
+	postStart := fmt.Sprintf("time subpath=%s name=%s capacity=%s community=%s quotaPath=%s %s '%s' >> /proc/1/fd/1",
+		security.EscapeBashStr(subpath),
+		security.EscapeBashStr(name),
+		capacity,
+		community,
+		security.EscapeBashStr(quotaPath),
+		checkMountScriptPath,
+		security.EscapeBashStr(r.jfsSetting.MountPath),
+	)
+	if r.jfsSetting.Warmup != nil {
+		postStart = strings.Join([]string{postStart, r.genWarmupCommand(r.jfsSetting.MountPath, r.jfsSetting.Warmup)}, " && ")
+	}
 	pod.Spec.Containers[0].Lifecycle.PostStart = &corev1.LifecycleHandler{
-		Exec: &corev1.ExecAction{Command: []string{"bash", "-c",
-			fmt.Sprintf("time subpath=%s name=%s capacity=%s community=%s quotaPath=%s %s '%s' >> /proc/1/fd/1",
-				security.EscapeBashStr(subpath),
-				security.EscapeBashStr(name),
-				capacity,
-				community,
-				security.EscapeBashStr(quotaPath),
-				checkMountScriptPath,
-				security.EscapeBashStr(r.jfsSetting.MountPath),
-			)}},
+		Exec: &corev1.ExecAction{Command: []string{"bash", "-c", postStart}},
 	}
 	pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, []corev1.EnvVar{{Name: "JFS_NO_UMOUNT", Value: "1"}, {Name: "JFS_FOREGROUND", Value: "1"}}...)
 	// generate volumes and volumeMounts only used in serverless sidecar
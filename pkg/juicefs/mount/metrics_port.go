@@ -0,0 +1,129 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package mount
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	jfsConfig "github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+)
+
+// reservation of allocated port lasts until the mount pod is created and can be listed
+const metricsPortReserveTTL = time.Minute
+
+var metricsPortReservations = struct {
+	sync.Mutex
+	ports map[int32]time.Time
+}{ports: make(map[int32]time.Time)}
+
+// setMetricsPort allocates a unique metrics port in this node for hostNetwork mount pod,
+// and adds prometheus scrape metadata if configured
+func (p *PodMount) setMetricsPort(ctx context.Context, pod *corev1.Pod) error {
+	cfg := jfsConfig.GlobalConfig.MountPodMetrics
+	if !cfg.IsEnabled() {
+		return nil
+	}
+	if resource.UseRandomMetricsPort(pod) {
+		port, err := p.allocMetricsPort(ctx)
+		if err != nil {
+			return err
+		}
+		p.log.Info("allocate metrics port for mount pod", "podName", pod.Name, "port", port)
+		resource.SetMetricsPort(pod, port)
+	}
+	if cfg.ScrapeAnnotations {
+		resource.SetMetricsScrapeAnnotations(pod)
+	}
+	return nil
+}
+
+func (p *PodMount) allocMetricsPort(ctx context.Context) (int32, error) {
+	labelSelector := &metav1.LabelSelector{MatchLabels: map[string]string{common.PodTypeKey: common.PodTypeValue}}
+	fieldSelector := &fields.Set{"spec.nodeName": jfsConfig.NodeName}
+	pods, err := p.K8sClient.ListPod(ctx, jfsConfig.Namespace, labelSelector, fieldSelector)
+	if err != nil {
+		return 0, fmt.Errorf("list mount pods to allocate metrics port: %v", err)
+	}
+	used := map[int32]bool{int32(jfsConfig.WebPort): true}
+	for _, pod := range pods {
+		if v, ok := pod.Annotations[common.MetricsPortKey]; ok {
+			if port, err := strconv.ParseInt(v, 10, 32); err == nil {
+				used[int32(port)] = true
+			}
+		}
+		// hostNetwork mount pods created before mountPodMetrics is enabled, or with metrics port set by users, have no annotation.
+		// Random ports of older ones, i.e. metrics=0.0.0.0:0, are unknown until they are found listening.
+		if pod.Spec.HostNetwork {
+			if port := resource.GetCommandMetricsPort(&pod); port != 0 {
+				used[port] = true
+			}
+		}
+	}
+
+	metricsPortReservations.Lock()
+	defer metricsPortReservations.Unlock()
+	now := time.Now()
+	for port, t := range metricsPortReservations.ports {
+		if now.Sub(t) > metricsPortReserveTTL {
+			delete(metricsPortReservations.ports, port)
+			continue
+		}
+		used[port] = true
+	}
+	start, end := jfsConfig.GlobalConfig.MountPodMetrics.GetHostPortRange()
+	port, err := pickMetricsPort(used, start, end, metricsPortFree)
+	if err != nil {
+		return 0, err
+	}
+	metricsPortReservations.ports[port] = now
+	return port, nil
+}
+
+// pickMetricsPort returns the lowest port in [start, end] which is neither used nor listened on
+func pickMetricsPort(used map[int32]bool, start, end int32, free func(port int32) bool) (int32, error) {
+	for port := start; port <= end; port++ {
+		if !used[port] && free(port) {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free metrics port in range %d-%d", start, end)
+}
+
+// metricsPortFree checks no process listens on the port, e.g. mount pods on random ports or other hostNetwork pods.
+// Ports of the node are only visible when csi node runs with hostNetwork.
+var metricsPortFree = func(port int32) bool {
+	if !jfsConfig.CSIPod.Spec.HostNetwork {
+		return true
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	_ = listener.Close()
+	return true
+}
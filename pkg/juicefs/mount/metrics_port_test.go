@@ -0,0 +1,90 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package mount
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/klog/v2"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	jfsConfig "github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+func TestAllocMetricsPort(t *testing.T) {
+	defer jfsConfig.GlobalConfig.Reset()
+	oldNodeName, oldCSIPod := jfsConfig.NodeName, jfsConfig.CSIPod
+	defer func() { jfsConfig.NodeName, jfsConfig.CSIPod = oldNodeName, oldCSIPod }()
+	jfsConfig.NodeName = "node-1"
+	jfsConfig.CSIPod = corev1.Pod{Spec: corev1.PodSpec{HostNetwork: true}}
+	metricsPortReservations.ports = make(map[int32]time.Time)
+
+	// a process listening on the first port of the range, e.g. an older mount pod on a random port
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	start := int32(listener.Addr().(*net.TCPAddr).Port)
+	jfsConfig.GlobalConfig.MountPodMetrics = &jfsConfig.MountPodMetrics{
+		Enable:        true,
+		HostPortRange: fmt.Sprintf("%d-%d", start, start+3),
+	}
+
+	mountPod := func(name string, hostNetwork bool, options string, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   jfsConfig.Namespace,
+				Labels:      map[string]string{common.PodTypeKey: common.PodTypeValue},
+				Annotations: annotations,
+			},
+			Spec: corev1.PodSpec{
+				NodeName:    jfsConfig.NodeName,
+				HostNetwork: hostNetwork,
+				Containers: []corev1.Container{{
+					Command: []string{"sh", "-c", "exec /usr/local/bin/juicefs mount ${metaurl} /jfs/pvc-xxx -o " + options},
+				}},
+			},
+		}
+	}
+	p := &PodMount{
+		log: klog.NewKlogr(),
+		K8sClient: &k8sclient.K8sClient{Interface: fake.NewSimpleClientset(
+			mountPod("annotated", true, fmt.Sprintf("metrics=0.0.0.0:%d", start+1), map[string]string{common.MetricsPortKey: fmt.Sprint(start + 1)}),
+			mountPod("set-by-user", true, fmt.Sprintf("metrics=0.0.0.0:%d", start+2), nil),
+			mountPod("random", true, "metrics=0.0.0.0:0", nil),
+			mountPod("not-host-network", false, fmt.Sprintf("metrics=0.0.0.0:%d", start+3), nil),
+		)},
+	}
+
+	port, err := p.allocMetricsPort(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, start+3, port)
+
+	_, err = p.allocMetricsPort(context.TODO())
+	assert.Error(t, err, "the last port is reserved")
+}
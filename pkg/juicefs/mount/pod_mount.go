@@ -80,6 +80,11 @@ func NewPodMount(client *k8sclient.K8sClient, mounter k8sMount.SafeFormatAndMoun
 
 func (p *PodMount) JMount(ctx context.Context, appInfo *jfsConfig.AppInfo, jfsSetting *jfsConfig.JfsSetting) error {
 	p.log = util.GenLog(ctx, p.log, "JMount")
+	if jfsSetting.NonPrivileged {
+		if err := jfsConfig.CheckNonPrivilegedMountPod(); err != nil {
+			return fmt.Errorf("can't create non-privileged mount pod: %v", err)
+		}
+	}
 	hashVal := jfsConfig.GenHashOfSetting(p.log, *jfsSetting)
 	jfsSetting.HashVal = hashVal
 	jfsSetting.UpgradeUUID = string(uuid.NewUUID())
@@ -469,7 +474,7 @@ func (p *PodMount) createOrAddRef(ctx context.Context, podName string, jfsSettin
 	log := util.GenLog(ctx, p.log, "createOrAddRef")
 	log.V(1).Info("mount pod", "podName", podName)
 	jfsSetting.MountPath = jfsSetting.MountPath + podName[len(podName)-7:]
-	jfsSetting.SecretName = fmt.Sprintf("juicefs-%s-secret", jfsSetting.UniqueId)
+	jfsSetting.SecretName = jfsConfig.MountSecretName(jfsSetting.UniqueId, jfsSetting.ReadOnly)
 
 	r := builder.NewPodBuilder(jfsSetting, 0)
 	secret := r.NewSecret()
@@ -478,6 +483,9 @@ func (p *PodMount) createOrAddRef(ctx context.Context, podName string, jfsSettin
 	} else {
 		builder.SetPVAsOwner(&secret, jfsSetting.PV)
 	}
+	if err = builder.EncryptSecret(&secret); err != nil {
+		return false, err
+	}
 	key := util.GetReferenceKey(jfsSetting.TargetPath)
 
 	waitCtx, waitCancel := context.WithTimeout(ctx, 60*time.Second)
@@ -529,11 +537,27 @@ func (p *PodMount) createOrAddRef(ctx context.Context, podName string, jfsSettin
 					}
 				}
 
+				if err := p.setMetricsPort(ctx, newPod); err != nil {
+					// metrics is not critical, fall back to a random port
+					log.Error(err, "set metrics port of mount pod error", "podName", podName)
+				}
+				if err := p.applyResourceBudget(ctx, newPod, jfsSetting); err != nil {
+					log.Error(err, "apply resource budget error", "podName", podName)
+					return false, err
+				}
+
 				if err := resource.CreateOrUpdateSecret(ctx, p.K8sClient, &secret); err != nil {
 					return false, err
 				}
 
 				supportFusePass := config.SupportFusePass(newPod)
+				if jfsSetting.NonPrivileged {
+					// the mount pod can't mount FUSE visible to the host by itself
+					if err := passfd.GlobalFds.MountFuse(ctx, newPod, jfsSetting.MountPath, "JuiceFS:"+jfsSetting.Name, jfsSetting.ReadOnly); err != nil {
+						log.Error(err, "mount FUSE for non-privileged mount pod error", "podName", podName)
+						return false, err
+					}
+				}
 				if supportFusePass {
 					if err := passfd.GlobalFds.ServeFuseFd(ctx, newPod); err != nil {
 						log.Error(err, "serve fuse fd error", "podName", podName)
@@ -548,6 +572,11 @@ func (p *PodMount) createOrAddRef(ctx context.Context, podName string, jfsSettin
 					if supportFusePass {
 						passfd.GlobalFds.StopFd(ctx, newPod)
 					}
+					if jfsSetting.NonPrivileged {
+						_ = util.DoWithTimeout(ctx, defaultCheckTimeout, func(ctx context.Context) error {
+							return util.UmountPath(ctx, jfsSetting.MountPath, true)
+						})
+					}
 					return false, err
 				}
 				return true, nil
@@ -743,7 +772,11 @@ func (p *PodMount) CleanCache(ctx context.Context, image string, id string, volu
 	jfsSetting.Attr.Image = image
 	jfsSetting.CacheDirs = cacheDirs
 	r := builder.NewJobBuilder(jfsSetting, 0)
-	job := r.NewJobForCleanCache()
+	job, err := r.NewJobForCleanCache()
+	if err != nil {
+		log.Error(err, "generate clean cache job err")
+		return err
+	}
 	log.V(1).Info("Clean cache job", "jobName", job)
 	_, err = p.K8sClient.GetJob(ctx, job.Name, job.Namespace)
 	if err != nil && k8serrors.IsNotFound(err) {
@@ -0,0 +1,35 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package mount
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	jfsConfig "github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+)
+
+// applyResourceBudget checks limits of the new mount pod against the total budget of the namespace of its pvc,
+// the pod is labeled with the namespace when it's generated, so that it's counted in the budget
+func (p *PodMount) applyResourceBudget(ctx context.Context, pod *corev1.Pod, jfsSetting *jfsConfig.JfsSetting) error {
+	if jfsSetting.PVC == nil {
+		return nil
+	}
+	return resource.ApplyResourceBudget(ctx, p.K8sClient, pod, jfsSetting.GetNamespace())
+}
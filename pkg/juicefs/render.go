@@ -0,0 +1,213 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juicefs
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	podmount "github.com/juicedata/juicefs-csi-driver/pkg/juicefs/mount"
+	"github.com/juicedata/juicefs-csi-driver/pkg/juicefs/mount/builder"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+)
+
+// name of the application pod which uses the pvc in rendering
+const renderAppPodName = "render-app"
+
+// RenderInput are the objects used to render mount pod offline
+type RenderInput struct {
+	// PV to mount. If nil, it is provisioned for PVC with StorageClass
+	PV        *corev1.PersistentVolume
+	PVC       *corev1.PersistentVolumeClaim
+	SC        *storagev1.StorageClass
+	Secrets   []corev1.Secret
+	Node      *corev1.Node
+	Namespace *corev1.Namespace
+	// UUID of the volume. If empty, it is got by `juicefs status` for CE volume, which requires access to the meta engine
+	UUID string
+}
+
+// MatchedPatch is an item of mountPodPatch in config matching the volume
+type MatchedPatch struct {
+	Index int                  `json:"index"`
+	Patch config.MountPodPatch `json:"patch"`
+}
+
+// RenderResult is the mount pod which a pvc will get in node
+type RenderResult struct {
+	UniqueId       string                 `json:"uniqueId"`
+	Hash           string                 `json:"hash"`
+	MatchedPatches []MatchedPatch         `json:"matchedPatches"`
+	Conflicts      []config.PatchConflict `json:"conflicts,omitempty"`
+	MountPod       *corev1.Pod            `json:"mountPod"`
+}
+
+// Render generates the mount pod of input without a cluster, in the same way as NodePublishVolume.
+// It uses the global config, config.NodeName and config.Namespace.
+func Render(ctx context.Context, in RenderInput) (*RenderResult, error) {
+	pv, pvc := in.PV, in.PVC
+	if pv == nil {
+		if pvc == nil || in.SC == nil {
+			return nil, fmt.Errorf("either pv or pvc with its storageClass is required")
+		}
+		var err error
+		if pv, err = provisionForRender(pvc, in.SC, in.Node); err != nil {
+			return nil, err
+		}
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != config.DriverName {
+		return nil, fmt.Errorf("pv %s is not a volume of driver %s", pv.Name, config.DriverName)
+	}
+	pv = pv.DeepCopy()
+	objs := []runtime.Object{pv}
+	volCtx := make(map[string]string)
+	for k, v := range pv.Spec.CSI.VolumeAttributes {
+		volCtx[k] = v
+	}
+	if pvc != nil {
+		pvc = pvc.DeepCopy()
+		pvc.Spec.VolumeName = pv.Name
+		pv.Spec.ClaimRef = &corev1.ObjectReference{Kind: "PersistentVolumeClaim", Name: pvc.Name, Namespace: pvc.Namespace}
+		// pv is found by application pod if its name differs from volumeHandle
+		appPod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: renderAppPodName, Namespace: pvc.Namespace},
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+				Name:         pvc.Name,
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name}},
+			}}},
+		}
+		volCtx[common.PodInfoName] = appPod.Name
+		volCtx[common.PodInfoNamespace] = appPod.Namespace
+		objs = append(objs, pvc, appPod)
+	}
+	if in.SC != nil {
+		objs = append(objs, in.SC)
+	}
+	if in.Node != nil {
+		objs = append(objs, in.Node)
+	}
+	if in.Namespace != nil {
+		objs = append(objs, in.Namespace)
+	}
+	for i := range in.Secrets {
+		objs = append(objs, &in.Secrets[i])
+	}
+
+	var secrets map[string]string
+	if ref := pv.Spec.CSI.NodePublishSecretRef; ref != nil {
+		for _, s := range in.Secrets {
+			if s.Name == ref.Name && s.Namespace == ref.Namespace {
+				secrets = make(map[string]string)
+				for k, v := range s.Data {
+					secrets[k] = string(v)
+				}
+				for k, v := range s.StringData {
+					secrets[k] = v
+				}
+			}
+		}
+	}
+	if secrets == nil {
+		return nil, fmt.Errorf("secret of pv %s not found", pv.Name)
+	}
+
+	client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset(objs...)}
+	j := &juicefs{K8sClient: client}
+	volumeId := pv.Spec.CSI.VolumeHandle
+	uniqueId, sc, err := j.getUniqueId(ctx, volumeId, secrets)
+	if err != nil {
+		return nil, err
+	}
+	setting, err := j.Settings(ctx, volumeId, uniqueId, in.UUID, secrets, volCtx, pv.Spec.MountOptions)
+	if err != nil {
+		return nil, fmt.Errorf("parse settings error, set uuid of the volume if it's failed to get by `juicefs status`: %v", err)
+	}
+	setting.SC = sc
+	setting.TargetPath = filepath.Join("/var/lib/kubelet/pods", renderAppPodName, "volumes/kubernetes.io~csi", pv.Name, "mount")
+	setting.MountPath = filepath.Join(config.PodMountBase, setting.UniqueId)
+
+	// the same as JMount and createOrAddRef, except that pod name and mount path have no random suffix
+	setting.HashVal = config.GenHashOfSetting(jfsLog, *setting)
+	setting.UpgradeUUID = setting.HashVal
+	podName := podmount.GenPodNameByUniqueId(setting.UniqueId, false)
+	setting.SecretName = config.MountSecretName(setting.UniqueId, setting.ReadOnly)
+	pod, err := builder.NewPodBuilder(setting, 0).NewMountPod(podName)
+	if err != nil {
+		return nil, err
+	}
+	pod.Annotations[util.GetReferenceKey(setting.TargetPath)] = setting.TargetPath
+
+	result := &RenderResult{
+		UniqueId:       setting.UniqueId,
+		Hash:           setting.HashVal,
+		MatchedPatches: []MatchedPatch{},
+		MountPod:       pod,
+	}
+	matched, conflicts := config.GlobalConfig.MatchMountPodPatch(*setting, setting.Node)
+	for _, i := range matched {
+		result.MatchedPatches = append(result.MatchedPatches, MatchedPatch{Index: i, Patch: config.GlobalConfig.MountPodPatch[i]})
+	}
+	result.Conflicts = conflicts
+	return result, nil
+}
+
+// provisionForRender generates the pv which provisioner creates for pvc
+func provisionForRender(pvc *corev1.PersistentVolumeClaim, sc *storagev1.StorageClass, node *corev1.Node) (*corev1.PersistentVolume, error) {
+	pvName := "pvc-" + string(pvc.UID)
+	if pvc.UID == "" {
+		pvName = "pvc-" + pvc.Name
+	}
+	vol, err := resource.ResolveDynamicVolume(pvName, *pvc, node, sc)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: pvName},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceStorage: pvc.Spec.Resources.Requests[corev1.ResourceStorage],
+			},
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:           config.DriverName,
+					VolumeHandle:     pvName,
+					FSType:           "juicefs",
+					VolumeAttributes: vol.VolCtx,
+					NodePublishSecretRef: &corev1.SecretReference{
+						Name:      vol.Params[common.PublishSecretName],
+						Namespace: vol.Params[common.PublishSecretNamespace],
+					},
+				},
+			},
+			AccessModes:      pvc.Spec.AccessModes,
+			StorageClassName: sc.Name,
+			MountOptions:     vol.MountOptions,
+			VolumeMode:       pvc.Spec.VolumeMode,
+		},
+	}, nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juicefs
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+)
+
+func TestRender(t *testing.T) {
+	defer func(nodeName, namespace string, cfg *config.Config) {
+		config.NodeName, config.Namespace, config.GlobalConfig = nodeName, namespace, cfg
+	}(config.NodeName, config.Namespace, config.GlobalConfig)
+	config.NodeName = "node-1"
+	config.Namespace = "kube-system"
+	config.GlobalConfig = &config.Config{
+		MountPodPatch: []config.MountPodPatch{
+			{CEMountImage: "juicedata/mount:ce-v1.2.0"},
+			{
+				PVCSelector:  &config.PVCSelector{MatchStorageClassName: "juicefs-sc"},
+				CEMountImage: "juicedata/mount:ce-v1.3.0",
+			},
+			{
+				NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}},
+				MountOptions: []string{"cache-size=2048"},
+			},
+			{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "b"}},
+				Labels:            map[string]string{"tenant": "b"},
+			},
+		},
+	}
+
+	scName := "juicefs-sc"
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "juicefs-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"name":    []byte("myjfs"),
+			"metaurl": []byte("redis://127.0.0.1:6379/1"),
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default", UID: "1234"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &scName,
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		},
+	}
+	sc := &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: scName},
+		Provisioner: config.DriverName,
+		Parameters: map[string]string{
+			common.PublishSecretName:      "juicefs-secret",
+			common.PublishSecretNamespace: "default",
+		},
+		MountOptions: []string{"writeback"},
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"zone": "a"}}}
+	staticPV := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "static-pv"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+				Driver:               config.DriverName,
+				VolumeHandle:         "static-handle",
+				NodePublishSecretRef: &corev1.SecretReference{Name: "juicefs-secret", Namespace: "default"},
+			}},
+		},
+	}
+
+	t.Run("dynamic provisioning", func(t *testing.T) {
+		result, err := Render(context.TODO(), RenderInput{
+			PVC:     pvc,
+			SC:      sc,
+			Secrets: []corev1.Secret{secret},
+			Node:    node,
+			UUID:    "uuid-1",
+		})
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if result.UniqueId != "pvc-1234" {
+			t.Errorf("uniqueId = %s, want pvc-1234", result.UniqueId)
+		}
+		pod := result.MountPod
+		if pod.Name != "juicefs-node-1-pvc-1234" || pod.Namespace != "kube-system" {
+			t.Errorf("mount pod = %s/%s", pod.Namespace, pod.Name)
+		}
+		if pod.Spec.Containers[0].Image != "juicedata/mount:ce-v1.3.0" {
+			t.Errorf("image = %s, want image of the latter patch", pod.Spec.Containers[0].Image)
+		}
+		if pod.Labels[common.PodJuiceHashLabelKey] != result.Hash || result.Hash == "" {
+			t.Errorf("hash = %s, label = %s", result.Hash, pod.Labels[common.PodJuiceHashLabelKey])
+		}
+		indexes := []int{}
+		for _, p := range result.MatchedPatches {
+			indexes = append(indexes, p.Index)
+		}
+		if len(indexes) != 3 || indexes[0] != 0 || indexes[1] != 1 || indexes[2] != 2 {
+			t.Errorf("matched patches = %v, want [0 1 2]", indexes)
+		}
+		if len(result.Conflicts) != 1 || result.Conflicts[0].Field != "ceMountImage" {
+			t.Errorf("conflicts = %v, want ceMountImage", result.Conflicts)
+		}
+	})
+
+	t.Run("static pv with namespace", func(t *testing.T) {
+		result, err := Render(context.TODO(), RenderInput{
+			PV:        staticPV,
+			PVC:       pvc,
+			Secrets:   []corev1.Secret{secret},
+			Namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"tenant": "b"}}},
+			UUID:      "uuid-1",
+		})
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if result.UniqueId != "static-handle" {
+			t.Errorf("uniqueId = %s, want static-handle", result.UniqueId)
+		}
+		if result.MountPod.Labels["tenant"] != "b" {
+			t.Errorf("labels = %v, want patch of namespace applied", result.MountPod.Labels)
+		}
+	})
+
+	t.Run("secret not found", func(t *testing.T) {
+		if _, err := Render(context.TODO(), RenderInput{PV: staticPV, UUID: "uuid-1"}); err == nil {
+			t.Errorf("Render() expect error")
+		}
+	})
+}
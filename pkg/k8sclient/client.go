@@ -34,6 +34,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
@@ -89,6 +90,8 @@ type K8sClient struct {
 	RestConfig               *rest.Config
 	nodeCacheMu              sync.Mutex
 	nodeCache                map[string]nodeCacheValue
+	namespaceCacheMu         sync.Mutex
+	namespaceCache           map[string]namespaceCacheValue
 	kubernetes.Interface
 }
 
@@ -97,6 +100,11 @@ type nodeCacheValue struct {
 	at   time.Time
 }
 
+type namespaceCacheValue struct {
+	namespace *corev1.Namespace
+	at        time.Time
+}
+
 func NewClient() (*K8sClient, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
@@ -143,6 +151,7 @@ func newClient(config rest.Config) (*K8sClient, error) {
 		enableAPIServerListCache: enableAPIServerListCache,
 		RestConfig:               &config,
 		nodeCache:                map[string]nodeCacheValue{},
+		namespaceCache:           map[string]namespaceCacheValue{},
 		Interface:                client,
 	}, nil
 }
@@ -252,6 +261,37 @@ func (k *K8sClient) GetNodeByCache(ctx context.Context, nodeName string) (*corev
 	return node, nil
 }
 
+func (k *K8sClient) GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
+	return k.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+}
+
+func (k *K8sClient) GetNamespaceByCache(ctx context.Context, name string) (*corev1.Namespace, error) {
+	k.namespaceCacheMu.Lock()
+	if k.namespaceCache == nil {
+		k.namespaceCache = map[string]namespaceCacheValue{}
+	}
+	cache, ok := k.namespaceCache[name]
+	if ok && time.Since(cache.at) < time.Minute {
+		namespace := cache.namespace.DeepCopy()
+		k.namespaceCacheMu.Unlock()
+		return namespace, nil
+	}
+	k.namespaceCacheMu.Unlock()
+
+	namespace, err := k.GetNamespace(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	k.namespaceCacheMu.Lock()
+	k.namespaceCache[name] = namespaceCacheValue{
+		namespace: namespace.DeepCopy(),
+		at:        time.Now(),
+	}
+	k.namespaceCacheMu.Unlock()
+	return namespace, nil
+}
+
 func (k *K8sClient) GetPodLog(ctx context.Context, podName, namespace, containerName string) (string, error) {
 	tailLines := int64(20)
 	req := k.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
@@ -273,6 +313,41 @@ func (k *K8sClient) GetPodLog(ctx context.Context, podName, namespace, container
 	return str, nil
 }
 
+// GetPodLogTail gets the last limitBytes of container log, previous for log of the last terminated container
+func (k *K8sClient) GetPodLogTail(ctx context.Context, podName, namespace, containerName string, previous bool, limitBytes int64) ([]byte, error) {
+	req := k.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: containerName,
+		Previous:  previous,
+	})
+	podLogs, err := req.Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer podLogs.Close()
+
+	var (
+		data []byte
+		buf  = make([]byte, 32*1024)
+	)
+	for {
+		n, err := podLogs.Read(buf)
+		data = append(data, buf[:n]...)
+		if int64(len(data)) > 2*limitBytes {
+			data = append([]byte{}, data[int64(len(data))-limitBytes:]...)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if int64(len(data)) > limitBytes {
+		data = data[int64(len(data))-limitBytes:]
+	}
+	return data, nil
+}
+
 func (k *K8sClient) PatchPod(ctx context.Context, podName, namespace string, data []byte, pt types.PatchType) error {
 	_, err := k.CoreV1().Pods(namespace).Patch(ctx, podName, pt, data, metav1.PatchOptions{})
 	return err
@@ -293,6 +368,13 @@ func (k *K8sClient) DeletePod(ctx context.Context, pod *corev1.Pod) error {
 	return k.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
 }
 
+// EvictPod evicts the pod with eviction API, which respects PodDisruptionBudgets
+func (k *K8sClient) EvictPod(ctx context.Context, pod *corev1.Pod) error {
+	return k.CoreV1().Pods(pod.Namespace).EvictV1(ctx, &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+	})
+}
+
 func (k *K8sClient) GetSecret(ctx context.Context, secretName, namespace string) (*corev1.Secret, error) {
 	secret, err := k.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
 	if err != nil {
@@ -301,6 +383,22 @@ func (k *K8sClient) GetSecret(ctx context.Context, secretName, namespace string)
 	return secret, nil
 }
 
+func (k *K8sClient) ListSecret(ctx context.Context, namespace string, labelSelector *metav1.LabelSelector) ([]corev1.Secret, error) {
+	listOptions := metav1.ListOptions{}
+	if labelSelector != nil {
+		labelMap, err := metav1.LabelSelectorAsSelector(labelSelector)
+		if err != nil {
+			return nil, err
+		}
+		listOptions.LabelSelector = labelMap.String()
+	}
+	secretList, err := k.CoreV1().Secrets(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	return secretList.Items, nil
+}
+
 func (k *K8sClient) CreateSecret(ctx context.Context, secret *corev1.Secret) (*corev1.Secret, error) {
 	if secret == nil {
 		return nil, nil
@@ -424,6 +522,19 @@ func (k *K8sClient) GetPersistentVolumeClaim(ctx context.Context, pvcName, names
 	return mntPod, nil
 }
 
+func (k *K8sClient) CreatePersistentVolumeClaim(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	return k.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(ctx, pvc, metav1.CreateOptions{})
+}
+
+func (k *K8sClient) UpdatePersistentVolumeClaim(ctx context.Context, pvc *corev1.PersistentVolumeClaim) error {
+	_, err := k.CoreV1().PersistentVolumeClaims(pvc.Namespace).Update(ctx, pvc, metav1.UpdateOptions{})
+	return err
+}
+
+func (k *K8sClient) DeletePersistentVolumeClaim(ctx context.Context, pvcName, namespace string) error {
+	return k.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, pvcName, metav1.DeleteOptions{})
+}
+
 func (k *K8sClient) GetReplicaSet(ctx context.Context, rsName, namespace string) (*appsv1.ReplicaSet, error) {
 	rs, err := k.AppsV1().ReplicaSets(namespace).Get(ctx, rsName, metav1.GetOptions{})
 	if err != nil {
@@ -516,6 +627,26 @@ func (k *K8sClient) UpdateConfigMap(ctx context.Context, cfg *corev1.ConfigMap)
 	return err
 }
 
+func (k *K8sClient) ListConfigMap(ctx context.Context, namespace string, labelSelector *metav1.LabelSelector) ([]corev1.ConfigMap, error) {
+	listOptions := metav1.ListOptions{}
+	if labelSelector != nil {
+		labelMap, err := metav1.LabelSelectorAsSelector(labelSelector)
+		if err != nil {
+			return nil, err
+		}
+		listOptions.LabelSelector = labelMap.String()
+	}
+	cmList, err := k.CoreV1().ConfigMaps(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	return cmList.Items, nil
+}
+
+func (k *K8sClient) DeleteConfigMap(ctx context.Context, cmName, namespace string) error {
+	return k.CoreV1().ConfigMaps(namespace).Delete(ctx, cmName, metav1.DeleteOptions{})
+}
+
 func (k *K8sClient) CreateEvent(ctx context.Context, pod corev1.Pod, evtType, reason, message string) error {
 	now := time.Now()
 	_, err := k.CoreV1().Events(pod.Namespace).Create(ctx, &corev1.Event{
@@ -545,6 +676,28 @@ func (k *K8sClient) CreateEvent(ctx context.Context, pod corev1.Pod, evtType, re
 	return err
 }
 
+// CreateObjectEvent creates an event for the object referred by ref, e.g. pvc or app pod
+func (k *K8sClient) CreateObjectEvent(ctx context.Context, ref corev1.ObjectReference, component, evtType, reason, message string) error {
+	now := time.Now()
+	_, err := k.CoreV1().Events(ref.Namespace).Create(ctx, &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.%x", ref.Name, now.UnixNano()),
+			Namespace: ref.Namespace,
+		},
+		InvolvedObject: ref,
+		Reason:         reason,
+		Message:        message,
+		Source: corev1.EventSource{
+			Component: component,
+		},
+		FirstTimestamp:      metav1.Time{Time: now},
+		LastTimestamp:       metav1.Time{Time: now},
+		Type:                evtType,
+		ReportingController: component,
+	}, metav1.CreateOptions{})
+	return err
+}
+
 func (k *K8sClient) GetEvents(ctx context.Context, pod *corev1.Pod) ([]corev1.Event, error) {
 	events, err := k.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{FieldSelector: fmt.Sprintf("involvedObject.name=%s", pod.Name), TypeMeta: metav1.TypeMeta{Kind: "Pod"}})
 	if err != nil {
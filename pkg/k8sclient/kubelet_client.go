@@ -55,6 +55,36 @@ type KubeletClient struct {
 	exitFunc func(int)
 }
 
+// StatsSummary is the subset of kubelet `/stats/summary` response used by the driver
+type StatsSummary struct {
+	Pods []PodStats `json:"pods"`
+}
+
+type PodStats struct {
+	PodRef     PodReference     `json:"podRef"`
+	Containers []ContainerStats `json:"containers"`
+}
+
+type PodReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	UID       string `json:"uid"`
+}
+
+type ContainerStats struct {
+	Name   string       `json:"name"`
+	CPU    *CPUStats    `json:"cpu,omitempty"`
+	Memory *MemoryStats `json:"memory,omitempty"`
+}
+
+type CPUStats struct {
+	UsageNanoCores *uint64 `json:"usageNanoCores,omitempty"`
+}
+
+type MemoryStats struct {
+	WorkingSetBytes *uint64 `json:"workingSetBytes,omitempty"`
+}
+
 // KubeletClientConfig defines config parameters for the kubelet client
 type KubeletClientConfig struct {
 	// Address specifies the kubelet address
@@ -220,3 +250,25 @@ func (kc *KubeletClient) GetNodeRunningPods() (*corev1.PodList, error) {
 	kc.checkAccessErr(nil)
 	return podLists, nil
 }
+
+// GetStatsSummary gets cpu and memory stats of pods on the node from kubelet
+func (kc *KubeletClient) GetStatsSummary() (*StatsSummary, error) {
+	resp, err := kc.client.Get(fmt.Sprintf("https://%v:%d/stats/summary?only_cpu_and_memory=true", kc.host, kc.port))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	summary := &StatsSummary{}
+	if err = json.NewDecoder(resp.Body).Decode(summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package crashlog saves logs of failed or deleted mount pods as archives in csi node.
+// Archives are laid out as <dir>/<uniqueId>/<unix timestamp>_<mount pod name>.tar.gz
+package crashlog
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const archiveSuffix = ".tar.gz"
+
+var (
+	log = klog.NewKlogr().WithName("crashlog")
+)
+
+// Entry is a file in the archive
+type Entry struct {
+	Name    string
+	Content []byte
+}
+
+// Archive is the summary of an archive file
+type Archive struct {
+	Node     string    `json:"node,omitempty"`
+	UniqueId string    `json:"uniqueId"`
+	Name     string    `json:"name"`
+	PodName  string    `json:"podName"`
+	Size     int64     `json:"size"`
+	Time     time.Time `json:"time"`
+}
+
+// ValidName checks if name can be used as a path element safely
+func ValidName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
+
+// Write saves entries as a tar.gz archive of mount pod, returns path of the archive
+func Write(dir, uniqueId, podName string, now time.Time, entries []Entry) (string, error) {
+	if !ValidName(uniqueId) || !ValidName(podName) {
+		return "", fmt.Errorf("invalid uniqueId %q or pod name %q", uniqueId, podName)
+	}
+	volDir := filepath.Join(dir, uniqueId)
+	if err := os.MkdirAll(volDir, 0755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%d_%s%s", now.Unix(), podName, archiveSuffix)
+	path := filepath.Join(volDir, name)
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", err
+	}
+	if err := writeTarGz(f, now, entries); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return "", err
+	}
+	return path, os.Rename(tmpPath, path)
+}
+
+func writeTarGz(w io.Writer, now time.Time, entries []Entry) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    e.Name,
+			Mode:    0644,
+			Size:    int64(len(e.Content)),
+			ModTime: now,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(e.Content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// List lists archives of uniqueId, the latest first. All archives are listed if uniqueId is empty.
+func List(dir, uniqueId string) ([]Archive, error) {
+	var volDirs []string
+	if uniqueId != "" {
+		if !ValidName(uniqueId) {
+			return nil, fmt.Errorf("invalid uniqueId %q", uniqueId)
+		}
+		volDirs = []string{uniqueId}
+	} else {
+		dirEntries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		for _, d := range dirEntries {
+			if d.IsDir() {
+				volDirs = append(volDirs, d.Name())
+			}
+		}
+	}
+	archives := []Archive{}
+	for _, volDir := range volDirs {
+		files, err := os.ReadDir(filepath.Join(dir, volDir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, file := range files {
+			a, ok := parseName(volDir, file.Name())
+			if !ok {
+				continue
+			}
+			info, err := file.Info()
+			if err != nil {
+				continue
+			}
+			a.Size = info.Size()
+			archives = append(archives, a)
+		}
+	}
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].Time.After(archives[j].Time)
+	})
+	return archives, nil
+}
+
+func parseName(uniqueId, name string) (Archive, bool) {
+	if !strings.HasSuffix(name, archiveSuffix) {
+		return Archive{}, false
+	}
+	pair := strings.SplitN(strings.TrimSuffix(name, archiveSuffix), "_", 2)
+	if len(pair) != 2 {
+		return Archive{}, false
+	}
+	ts, err := strconv.ParseInt(pair[0], 10, 64)
+	if err != nil {
+		return Archive{}, false
+	}
+	return Archive{
+		UniqueId: uniqueId,
+		Name:     name,
+		PodName:  pair[1],
+		Time:     time.Unix(ts, 0),
+	}, true
+}
+
+// Rotate removes the oldest archives until the total size is no more than maxSize
+func Rotate(dir string, maxSize int64) error {
+	archives, err := List(dir, "")
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, a := range archives {
+		total += a.Size
+	}
+	// archives are sorted by time desc, remove from the tail
+	for i := len(archives) - 1; i >= 0 && total > maxSize; i-- {
+		a := archives[i]
+		log.Info("remove crash log archive for rotation", "uniqueId", a.UniqueId, "name", a.Name)
+		if err := os.Remove(filepath.Join(dir, a.UniqueId, a.Name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= a.Size
+	}
+	return nil
+}
+
+// Path returns the path of archive, and checks the archive name
+func Path(dir, uniqueId, name string) (string, error) {
+	if !ValidName(uniqueId) || !ValidName(name) {
+		return "", fmt.Errorf("invalid uniqueId %q or archive name %q", uniqueId, name)
+	}
+	if _, ok := parseName(uniqueId, name); !ok {
+		return "", fmt.Errorf("invalid archive name %q", name)
+	}
+	return filepath.Join(dir, uniqueId, name), nil
+}
+
+// ListCommand is the command run in csi node to list archives of uniqueId, output is parsed by ParseListOutput.
+// The directory is passed as a positional argument, so that it is never interpreted by the shell.
+func ListCommand(dir, uniqueId string) []string {
+	script := fmt.Sprintf(`cd "$1" 2>/dev/null && for f in *%s; do [ -f "$f" ] && echo "$f $(stat -c %%s "$f")"; done; exit 0`, archiveSuffix)
+	return []string{"sh", "-c", script, "sh", filepath.Join(dir, uniqueId)}
+}
+
+// ParseListOutput parses output of ListCommand
+func ParseListOutput(node, uniqueId, output string) []Archive {
+	archives := []Archive{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		a, ok := parseName(uniqueId, fields[0])
+		if !ok {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		a.Node = node
+		a.Size = size
+		archives = append(archives, a)
+	}
+	return archives
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crashlog
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteAndList(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1700000000, 0)
+	path, err := Write(dir, "pvc-1", "juicefs-node-pvc-1-abc", now, []Entry{
+		{Name: "pod.yaml", Content: []byte("kind: Pod")},
+		{Name: "jfs-mount.log", Content: []byte("panic")},
+	})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if want := filepath.Join(dir, "pvc-1", "1700000000_juicefs-node-pvc-1-abc.tar.gz"); path != want {
+		t.Errorf("Write() path = %s, want %s", path, want)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, h.Name)
+	}
+	if len(names) != 2 || names[0] != "pod.yaml" || names[1] != "jfs-mount.log" {
+		t.Errorf("archive entries = %v", names)
+	}
+
+	if _, err := Write(dir, "pvc-2", "juicefs-node-pvc-2-abc", now.Add(time.Minute), nil); err != nil {
+		t.Fatal(err)
+	}
+	archives, err := List(dir, "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(archives) != 2 || archives[0].UniqueId != "pvc-2" || archives[1].PodName != "juicefs-node-pvc-1-abc" {
+		t.Errorf("List() = %+v, want the latest first", archives)
+	}
+	archives, err = List(dir, "pvc-1")
+	if err != nil || len(archives) != 1 {
+		t.Errorf("List(pvc-1) = %+v, %v", archives, err)
+	}
+	if _, err := Write(dir, "../pvc", "pod", now, nil); err == nil {
+		t.Errorf("Write() with invalid uniqueId should fail")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1700000000, 0)
+	content := make([]byte, 4096)
+	for i := 0; i < 3; i++ {
+		if _, err := Write(dir, "pvc-1", "pod", now.Add(time.Duration(i)*time.Minute), []Entry{{Name: "log", Content: content}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archives, _ := List(dir, "")
+	size := archives[0].Size
+	if err := Rotate(dir, size*2); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	archives, _ = List(dir, "")
+	if len(archives) != 2 {
+		t.Fatalf("Rotate() left %d archives, want 2", len(archives))
+	}
+	if archives[1].Time.Unix() != now.Add(time.Minute).Unix() {
+		t.Errorf("Rotate() should remove the oldest archive, left %+v", archives)
+	}
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		uniqueId string
+		archive  string
+		wantErr  bool
+	}{
+		{name: "valid", uniqueId: "pvc-1", archive: "1700000000_pod.tar.gz"},
+		{name: "traversal-uniqueId", uniqueId: "..", archive: "1700000000_pod.tar.gz", wantErr: true},
+		{name: "traversal-name", uniqueId: "pvc-1", archive: "../1700000000_pod.tar.gz", wantErr: true},
+		{name: "not-archive", uniqueId: "pvc-1", archive: "passwd", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Path("/var/lib/juicefs/crash-logs", tt.uniqueId, tt.archive)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Path() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != "/var/lib/juicefs/crash-logs/pvc-1/1700000000_pod.tar.gz" {
+				t.Errorf("Path() = %s", got)
+			}
+		})
+	}
+}
+
+func TestParseListOutput(t *testing.T) {
+	output := "1700000000_pod-a.tar.gz 1024\n1700000060_pod-b.tar.gz 2048\ninvalid line here\nfoo.txt 12\n"
+	archives := ParseListOutput("node-1", "pvc-1", output)
+	if len(archives) != 2 {
+		t.Fatalf("ParseListOutput() = %+v, want 2 archives", archives)
+	}
+	if a := archives[1]; a.Node != "node-1" || a.PodName != "pod-b" || a.Size != 2048 || a.Time.Unix() != 1700000060 {
+		t.Errorf("ParseListOutput() = %+v", a)
+	}
+}
+
+func TestListCommand(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1700000000, 0)
+	// names with shell syntax in static PVs must not be expanded
+	for _, uniqueId := range []string{"pvc-1", "$(touch expanded)", "`touch expanded`", "it's"} {
+		if _, err := Write(dir, uniqueId, "juicefs-mount", now, nil); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		cmd := ListCommand(dir, uniqueId)
+		c := exec.Command(cmd[0], cmd[1:]...)
+		c.Dir = dir
+		out, err := c.Output()
+		if err != nil {
+			t.Fatalf("ListCommand(%q) error = %v", uniqueId, err)
+		}
+		archives := ParseListOutput("node", uniqueId, string(out))
+		if len(archives) != 1 || archives[0].PodName != "juicefs-mount" {
+			t.Errorf("ListCommand(%q) archives = %v, want 1 archive", uniqueId, archives)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "expanded")); !os.IsNotExist(err) {
+		t.Errorf("uniqueId is expanded by shell")
+	}
+}
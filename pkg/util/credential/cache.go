@@ -0,0 +1,83 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credential
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultCache is the cache used by Resolve
+var DefaultCache = NewCache(time.Minute)
+
+type cacheEntry struct {
+	values  map[string]string
+	fetched time.Time
+}
+
+// Cache caches credentials for ttl. Rotated credentials are picked up after the ttl, CSI Controller polls
+// the provider of volume secrets to rotate credentials in mount pods, see SecretController.
+type Cache struct {
+	ttl   time.Duration
+	mu    sync.Mutex
+	items map[string]cacheEntry
+	now   func() time.Time
+}
+
+// NewCache returns a cache, credentials are fetched every time if ttl is not positive
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, items: make(map[string]cacheEntry), now: time.Now}
+}
+
+// TTL returns how long credentials are cached
+func (c *Cache) TTL() time.Duration {
+	return c.ttl
+}
+
+// Get returns cached credentials of ref, or fetches them if expired.
+// The expired credentials are returned if fetching fails, to tolerate outage of the provider.
+func (c *Cache) Get(ctx context.Context, ref *Ref) (map[string]string, error) {
+	key := ref.String()
+	c.mu.Lock()
+	entry, ok := c.items[key]
+	c.mu.Unlock()
+	if ok && c.ttl > 0 && c.now().Sub(entry.fetched) < c.ttl {
+		return copyMap(entry.values), nil
+	}
+
+	values, err := fetch(ctx, ref)
+	if err != nil {
+		if ok {
+			log.Error(err, "fetch credentials error, use the cached ones", "ref", key)
+			return copyMap(entry.values), nil
+		}
+		return nil, err
+	}
+	c.mu.Lock()
+	c.items[key] = cacheEntry{values: values, fetched: c.now()}
+	c.mu.Unlock()
+	return copyMap(values), nil
+}
+
+func copyMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
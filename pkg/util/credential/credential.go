@@ -0,0 +1,173 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package credential fetches JuiceFS credentials from external providers, e.g. files mounted by
+// the secrets store CSI driver or a credential HTTP endpoint, so that raw keys are not stored in
+// secrets generated by CSI. Mount containers fetch credentials from the same provider when they start.
+package credential
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+var log = klog.NewKlogr().WithName("credential")
+
+const (
+	// ProviderKey is the key in the volume secret to set the credential provider
+	ProviderKey = "credential-provider"
+	// PathKey is the key in the volume secret to set the directory of the file provider
+	PathKey = "credential-path"
+	// EndpointKey is the key in the volume secret to set the endpoint of the http provider
+	EndpointKey = "credential-endpoint"
+)
+
+// Keys are the credentials which can be provided, they are named the same as in the volume secret
+var Keys = []string{"metaurl", "secret-key", "secret-key2", "token", "passphrase"}
+
+// EnvName returns the env name of key in mount containers, e.g. secretkey for secret-key
+func EnvName(key string) string {
+	return strings.ReplaceAll(key, "-", "")
+}
+
+// Ref refers to credentials in a provider
+type Ref struct {
+	Provider string `json:"provider"`
+	Path     string `json:"path,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+	// Keys are the credentials returned by the provider, sorted
+	Keys []string `json:"keys,omitempty"`
+}
+
+// Provides returns true if key is returned by the provider
+func (r *Ref) Provides(key string) bool {
+	if r == nil {
+		return false
+	}
+	for _, k := range r.Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Ref) String() string {
+	return fmt.Sprintf("%s:%s%s", r.Provider, r.Path, r.Endpoint)
+}
+
+// Provider fetches credentials
+type Provider interface {
+	// Validate checks if ref is valid for the provider
+	Validate(ref *Ref) error
+	// Fetch returns credentials of ref in Keys, keys not found are absent in the result
+	Fetch(ctx context.Context, ref *Ref) (map[string]string, error)
+	// Command returns a shell script which exports credentials of ref as envs in mount containers,
+	// it exits with non-zero code if any one of ref.Keys can not be fetched.
+	Command(ref *Ref) string
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{
+		"file": fileProvider{},
+		"http": newHTTPProvider(),
+	}
+)
+
+// Register registers a provider with name, the existing one with the same name is replaced
+func Register(name string, p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = p
+}
+
+func getProvider(name string) (Provider, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown credential provider %q", name)
+	}
+	return p, nil
+}
+
+// ParseRef parses the credential provider set in the volume secret, returns nil if not set
+func ParseRef(secrets map[string]string) (*Ref, error) {
+	name := strings.TrimSpace(secrets[ProviderKey])
+	if name == "" {
+		return nil, nil
+	}
+	ref := &Ref{
+		Provider: name,
+		Path:     strings.TrimSpace(secrets[PathKey]),
+		Endpoint: strings.TrimRight(strings.TrimSpace(secrets[EndpointKey]), "/"),
+	}
+	p, err := getProvider(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Validate(ref); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// Resolve fetches credentials of ref through the default cache, and sets ref.Keys to the keys returned
+func Resolve(ctx context.Context, ref *Ref) (map[string]string, error) {
+	values, err := DefaultCache.Get(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	ref.Keys = make([]string, 0, len(values))
+	for k := range values {
+		ref.Keys = append(ref.Keys, k)
+	}
+	sort.Strings(ref.Keys)
+	return values, nil
+}
+
+// Command returns the shell script which exports credentials of ref in mount containers,
+// returns empty if ref is nil or no credentials are provided.
+func Command(ref *Ref) string {
+	if ref == nil || len(ref.Keys) == 0 {
+		return ""
+	}
+	p, err := getProvider(ref.Provider)
+	if err != nil {
+		log.Error(err, "generate credential command error")
+		return ""
+	}
+	return p.Command(ref)
+}
+
+func fetch(ctx context.Context, ref *Ref) (map[string]string, error) {
+	p, err := getProvider(ref.Provider)
+	if err != nil {
+		return nil, err
+	}
+	return p.Fetch(ctx, ref)
+}
+
+// quote quotes s for POSIX shell
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credential
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets map[string]string
+		want    *Ref
+		wantErr bool
+	}{
+		{name: "not set", secrets: map[string]string{"name": "test"}},
+		{name: "file", secrets: map[string]string{ProviderKey: "file", PathKey: "/mnt/secrets-store"}, want: &Ref{Provider: "file", Path: "/mnt/secrets-store"}},
+		{name: "relative path", secrets: map[string]string{ProviderKey: "file", PathKey: "secrets"}, wantErr: true},
+		{name: "http", secrets: map[string]string{ProviderKey: "http", EndpointKey: "http://127.0.0.1:8200/v1/jfs/"}, want: &Ref{Provider: "http", Endpoint: "http://127.0.0.1:8200/v1/jfs"}},
+		{name: "invalid endpoint", secrets: map[string]string{ProviderKey: "http", EndpointKey: "127.0.0.1:8200"}, wantErr: true},
+		{name: "unknown provider", secrets: map[string]string{ProviderKey: "vault"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRef(tt.secrets)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRef() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	for k, v := range map[string]string{"metaurl": "redis://:pass@redis:6379/1\n", "secret-key": "sk", "unknown": "x"} {
+		if err := os.WriteFile(filepath.Join(dir, k), []byte(v), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ref := &Ref{Provider: "file", Path: dir}
+	values, err := NewCache(0).Get(context.TODO(), ref)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	want := map[string]string{"metaurl": "redis://:pass@redis:6379/1", "secret-key": "sk"}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("Get() = %v, want %v", values, want)
+	}
+
+	ref.Keys = []string{"metaurl", "secret-key"}
+	cmd := Command(ref)
+	wantCmd := `metaurl="$(cat '` + dir + `/metaurl')" || exit 1; export metaurl` + "\n" +
+		`secretkey="$(cat '` + dir + `/secret-key')" || exit 1; export secretkey`
+	if cmd != wantCmd {
+		t.Errorf("Command() = %s, want %s", cmd, wantCmd)
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	failed := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/creds/token":
+			_, _ = w.Write([]byte("t1"))
+		case "/creds/passphrase":
+			if failed {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			http.NotFound(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ref, err := ParseRef(map[string]string{ProviderKey: "http", EndpointKey: server.URL + "/creds/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCache(0).Get(context.TODO(), ref); err == nil || !strings.Contains(err.Error(), "passphrase") {
+		t.Errorf("Get() error = %v, want error of passphrase", err)
+	}
+	failed = false
+	values, err := NewCache(0).Get(context.TODO(), ref)
+	if err != nil || !reflect.DeepEqual(values, map[string]string{"token": "t1"}) {
+		t.Errorf("Get() = %v, %v", values, err)
+	}
+
+	ref.Keys = []string{"token"}
+	if cmd := Command(ref); !strings.Contains(cmd, "curl -fsS '"+server.URL+"/creds/token'") || !strings.HasSuffix(cmd, "export token") {
+		t.Errorf("Command() = %s", cmd)
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	write := func(v string) {
+		if err := os.WriteFile(filepath.Join(dir, "token"), []byte(v), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("t1")
+
+	now := time.Now()
+	cache := NewCache(time.Minute)
+	cache.now = func() time.Time { return now }
+	ref := &Ref{Provider: "file", Path: dir}
+	get := func() string {
+		values, err := cache.Get(context.TODO(), ref)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		return values["token"]
+	}
+
+	if v := get(); v != "t1" {
+		t.Fatalf("token = %s, want t1", v)
+	}
+	write("t2")
+	if v := get(); v != "t1" {
+		t.Errorf("token = %s, want cached t1", v)
+	}
+	now = now.Add(time.Minute)
+	if v := get(); v != "t2" {
+		t.Errorf("token = %s, want t2 after expired", v)
+	}
+
+	// expired credentials are used if the provider is unavailable
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	if v := get(); v != "t2" {
+		t.Errorf("token = %s, want stale t2", v)
+	}
+}
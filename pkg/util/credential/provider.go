@@ -0,0 +1,123 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credential
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fileProvider reads credentials from files named by keys in a directory,
+// e.g. the one mounted by the secrets store CSI driver.
+type fileProvider struct{}
+
+func (fileProvider) Validate(ref *Ref) error {
+	if !filepath.IsAbs(ref.Path) {
+		return fmt.Errorf("%s of file credential provider should be an absolute path, got %q", PathKey, ref.Path)
+	}
+	return nil
+}
+
+func (fileProvider) Fetch(ctx context.Context, ref *Ref) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, key := range Keys {
+		data, err := os.ReadFile(filepath.Join(ref.Path, key))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read credential %s error: %v", key, err)
+		}
+		// the same as $(cat file) in mount containers
+		values[key] = strings.TrimRight(string(data), "\n")
+	}
+	return values, nil
+}
+
+func (fileProvider) Command(ref *Ref) string {
+	lines := make([]string, 0, len(ref.Keys))
+	for _, key := range ref.Keys {
+		env := EnvName(key)
+		lines = append(lines, fmt.Sprintf(`%s="$(cat %s)" || exit 1; export %s`,
+			env, quote(filepath.Join(ref.Path, key)), env))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// httpProvider gets each credential from <endpoint>/<key>, whose response body is the raw value.
+// 404 means the credential is not provided.
+type httpProvider struct {
+	client *http.Client
+}
+
+func newHTTPProvider() httpProvider {
+	return httpProvider{client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (httpProvider) Validate(ref *Ref) error {
+	u, err := url.Parse(ref.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s of http credential provider should be a http(s) url, got %q", EndpointKey, ref.Endpoint)
+	}
+	return nil
+}
+
+func (p httpProvider) Fetch(ctx context.Context, ref *Ref) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, key := range Keys {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref.Endpoint+"/"+key, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("get credential %s error: %v", key, err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("get credential %s error: %s", key, resp.Status)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read credential %s error: %v", key, err)
+		}
+		values[key] = strings.TrimRight(string(data), "\n")
+	}
+	return values, nil
+}
+
+func (httpProvider) Command(ref *Ref) string {
+	lines := make([]string, 0, len(ref.Keys))
+	for _, key := range ref.Keys {
+		env := EnvName(key)
+		u := quote(ref.Endpoint + "/" + key)
+		// mount images have either curl or wget
+		lines = append(lines, fmt.Sprintf(`%s="$(curl -fsS %s 2>/dev/null || wget -qO- %s)" || exit 1; export %s`,
+			env, u, u, env))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package encryption encrypts secret data with envelope encryption: values are encrypted with a random
+// data key by AES-GCM, and the data key is wrapped by a key encryption key of the keyring and stored
+// beside the values. Rotating the key encryption key only needs the data key to be wrapped again.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// DataKeyName is the key in the data to store the wrapped data key
+	DataKeyName = "juicefs-data-key"
+	// Prefix is the prefix of encrypted values
+	Prefix = "enc:v1:"
+
+	keySize = 32
+)
+
+// Keyring holds key encryption keys, the first one is primary and wraps new data keys
+type Keyring struct {
+	ids  []string
+	keys map[string]cipher.AEAD
+}
+
+// NewKeyring returns a keyring of 32 bytes keys
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no key encryption key")
+	}
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+	for _, key := range keys {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key encryption key should be %d bytes, got %d", keySize, len(key))
+		}
+		sum := sha256.Sum256(key)
+		id := hex.EncodeToString(sum[:])[:8]
+		if _, ok := k.keys[id]; ok {
+			continue
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.ids = append(k.ids, id)
+		k.keys[id] = aead
+	}
+	return k, nil
+}
+
+// LoadKeyring loads keys from files, each file contains a base64 encoded 32 bytes key
+func LoadKeyring(files ...string) (*Keyring, error) {
+	keys := make([][]byte, 0, len(files))
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("read key file %s: %v", f, err)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+		if err != nil {
+			return nil, fmt.Errorf("decode key file %s: %v", f, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring(keys...)
+}
+
+// Primary returns the id of the primary key
+func (k *Keyring) Primary() string {
+	return k.ids[0]
+}
+
+// Encrypt returns a copy of data whose values of keys are encrypted with a new data key,
+// other values are kept in plaintext
+func (k *Keyring) Encrypt(data map[string]string, keys ...string) (map[string]string, error) {
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	wrapped, err := seal(k.keys[k.Primary()], dek, DataKeyName)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(data)+1)
+	for name, v := range data {
+		result[name] = v
+	}
+	for _, name := range keys {
+		v, ok := data[name]
+		if !ok {
+			continue
+		}
+		sealed, err := seal(aead, []byte(v), name)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = Prefix + sealed
+	}
+	result[DataKeyName] = k.Primary() + ":" + wrapped
+	return result, nil
+}
+
+// Decrypt returns a copy of data with encrypted values decrypted, data not encrypted is returned as is
+func (k *Keyring) Decrypt(data map[string]string) (map[string]string, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	dek, err := k.unwrap(data[DataKeyName])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(data))
+	for name, v := range data {
+		if name == DataKeyName {
+			continue
+		}
+		if !strings.HasPrefix(v, Prefix) {
+			result[name] = v
+			continue
+		}
+		plain, err := open(aead, strings.TrimPrefix(v, Prefix), name)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt %s: %v", name, err)
+		}
+		result[name] = string(plain)
+	}
+	return result, nil
+}
+
+// Rewrap wraps the data key with the primary key if it is wrapped by another key,
+// it returns the new value of DataKeyName, and false if the data key does not need to be wrapped again
+func (k *Keyring) Rewrap(data map[string]string) (string, bool, error) {
+	if !IsEncrypted(data) || KeyID(data) == k.Primary() {
+		return "", false, nil
+	}
+	dek, err := k.unwrap(data[DataKeyName])
+	if err != nil {
+		return "", false, err
+	}
+	wrapped, err := seal(k.keys[k.Primary()], dek, DataKeyName)
+	if err != nil {
+		return "", false, err
+	}
+	return k.Primary() + ":" + wrapped, true, nil
+}
+
+// IsEncrypted returns true if data is encrypted by Encrypt
+func IsEncrypted(data map[string]string) bool {
+	_, ok := data[DataKeyName]
+	return ok
+}
+
+// KeyID returns the id of the key wrapping the data key, empty if data is not encrypted
+func KeyID(data map[string]string) string {
+	id, _, _ := strings.Cut(data[DataKeyName], ":")
+	return id
+}
+
+func (k *Keyring) unwrap(value string) ([]byte, error) {
+	id, wrapped, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid data key")
+	}
+	kek, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("key encryption key %s not found", id)
+	}
+	dek, err := open(kek, wrapped, DataKeyName)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key with key %s: %v", id, err)
+	}
+	return dek, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with the name as additional data, so that values can not be swapped between keys
+func seal(aead cipher.AEAD, plaintext []byte, name string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, []byte(name))), nil
+}
+
+func open(aead cipher.AEAD, value string, name string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, keySize)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	k, err := NewKeyring(testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]string{"metaurl": "redis://:pass@redis:6379/1", "token": "t", "check_mount.sh": "echo"}
+	encrypted, err := k.Encrypt(data, "metaurl", "token", "not-exist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(encrypted) || KeyID(encrypted) != k.Primary() {
+		t.Fatalf("data key not set: %v", encrypted)
+	}
+	if _, ok := encrypted["not-exist"]; ok {
+		t.Errorf("key not in data should not be added")
+	}
+	if !strings.HasPrefix(encrypted["metaurl"], Prefix) || strings.Contains(encrypted["metaurl"], "pass") {
+		t.Errorf("metaurl not encrypted: %s", encrypted["metaurl"])
+	}
+	if encrypted["check_mount.sh"] != "echo" {
+		t.Errorf("check_mount.sh should be kept in plaintext")
+	}
+	got, err := k.Decrypt(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, data) {
+		t.Errorf("Decrypt() = %v, want %v", got, data)
+	}
+
+	// values can not be swapped between keys
+	encrypted["token"], encrypted["metaurl"] = encrypted["metaurl"], encrypted["token"]
+	if _, err := k.Decrypt(encrypted); err == nil {
+		t.Errorf("Decrypt() of swapped values should fail")
+	}
+
+	// not encrypted
+	got, err = k.Decrypt(data)
+	if err != nil || !reflect.DeepEqual(got, data) {
+		t.Errorf("Decrypt() of plaintext = %v, %v", got, err)
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	old, _ := NewKeyring(testKey(1))
+	encrypted, err := old.Encrypt(map[string]string{"token": "t"}, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the new key is primary, the old one is kept to decrypt
+	rotated, _ := NewKeyring(testKey(2), testKey(1))
+	dataKey, changed, err := rotated.Rewrap(encrypted)
+	if err != nil || !changed {
+		t.Fatalf("Rewrap() = %v, %v", changed, err)
+	}
+	encrypted[DataKeyName] = dataKey
+	if KeyID(encrypted) != rotated.Primary() {
+		t.Errorf("data key should be wrapped by the primary key")
+	}
+	if _, changed, _ := rotated.Rewrap(encrypted); changed {
+		t.Errorf("Rewrap() should not change data wrapped by the primary key")
+	}
+
+	// the old key is removed
+	current, _ := NewKeyring(testKey(2))
+	got, err := current.Decrypt(encrypted)
+	if err != nil || got["token"] != "t" {
+		t.Errorf("Decrypt() = %v, %v", got, err)
+	}
+	if _, err := old.Decrypt(encrypted); err == nil {
+		t.Errorf("Decrypt() with the removed key should fail")
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(testKey(1))+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	shortFile := filepath.Join(dir, "short")
+	if err := os.WriteFile(shortFile, []byte(base64.StdEncoding.EncodeToString([]byte("short"))), 0600); err != nil {
+		t.Fatal(err)
+	}
+	k, err := LoadKeyring(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := NewKeyring(testKey(1))
+	if k.Primary() != expected.Primary() {
+		t.Errorf("Primary() = %s, want %s", k.Primary(), expected.Primary())
+	}
+	if _, err := LoadKeyring(shortFile); err == nil {
+		t.Errorf("LoadKeyring() of short key should fail")
+	}
+	if _, err := LoadKeyring(filepath.Join(dir, "not-exist")); err == nil {
+		t.Errorf("LoadKeyring() of missing file should fail")
+	}
+	if _, err := LoadKeyring(); err == nil {
+		t.Errorf("LoadKeyring() without files should fail")
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package resource
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+// GetResourceBudgetUsage returns the total cpu/memory limits of mount pods in all nodes labeled with
+// the namespace of their pvc, which are counted in the resource budget of the namespace.
+// Mount pods being deleted, limits not set and mount pods in replaced are not counted.
+func GetResourceBudgetUsage(ctx context.Context, client *k8sclient.K8sClient, namespace string, replaced ...string) (corev1.ResourceList, error) {
+	labelSelector := &metav1.LabelSelector{MatchLabels: map[string]string{
+		common.PodTypeKey:                   common.PodTypeValue,
+		common.MountPodPVCNamespaceLabelKey: namespace,
+	}}
+	pods, err := client.ListPod(ctx, config.Namespace, labelSelector, nil)
+	if err != nil {
+		return nil, err
+	}
+	counted := make([]corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if !slices.Contains(replaced, pod.Name) {
+			counted = append(counted, pod)
+		}
+	}
+	return sumMountPodLimits(counted), nil
+}
+
+// ApplyResourceBudget checks limits of the new mount pod against the total budget of the namespace of its pvc,
+// and lowers them in Clamp policy. Mount pods in replaced are not counted, as the new one replaces them.
+// Mount pods created at the same time in different nodes may exceed the total budget together.
+func ApplyResourceBudget(ctx context.Context, client *k8sclient.K8sClient, pod *corev1.Pod, namespace *corev1.Namespace, replaced ...string) error {
+	budget := config.GlobalConfig.GetResourceBudget(namespace)
+	if budget == nil || len(budget.Total) == 0 || len(pod.Spec.Containers) == 0 {
+		return nil
+	}
+	used, err := GetResourceBudgetUsage(ctx, client, namespace.Name, replaced...)
+	if err != nil {
+		return fmt.Errorf("get resource budget usage of namespace %s: %v", namespace.Name, err)
+	}
+	resources, err := budget.Apply(pod.Spec.Containers[0].Resources, used)
+	if err != nil {
+		return fmt.Errorf("mount pod resources exceed the budget of namespace %s: %v", namespace.Name, err)
+	}
+	pod.Spec.Containers[0].Resources = resources
+	return nil
+}
+
+func sumMountPodLimits(pods []corev1.Pod) corev1.ResourceList {
+	used := corev1.ResourceList{}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || len(pod.Spec.Containers) == 0 {
+			continue
+		}
+		for name, q := range pod.Spec.Containers[0].Resources.Limits {
+			if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+				continue
+			}
+			total := used[name]
+			total.Add(q)
+			used[name] = total
+		}
+	}
+	return used
+}
@@ -0,0 +1,72 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package resource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	k8s "github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+func TestGetResourceBudgetUsage(t *testing.T) {
+	defer func(ns string) { config.Namespace = ns }(config.Namespace)
+	config.Namespace = "kube-system"
+	now := metav1.Now()
+	mountPod := func(name, pvcNamespace, cpu, memory string, deleting bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: config.Namespace,
+				Labels: map[string]string{
+					common.PodTypeKey:                   common.PodTypeValue,
+					common.MountPodPVCNamespaceLabelKey: pvcNamespace,
+				},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:      "jfs-mount",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}},
+			}}},
+		}
+		if memory != "" {
+			pod.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory] = resource.MustParse(memory)
+		}
+		if deleting {
+			pod.DeletionTimestamp = &now
+		}
+		return pod
+	}
+	client := &k8s.K8sClient{Interface: fake.NewSimpleClientset(
+		mountPod("mount-1", "tenant-a", "1", "1Gi", false),
+		mountPod("mount-2", "tenant-a", "500m", "", false),
+		mountPod("mount-3", "tenant-a", "4", "4Gi", true),
+		mountPod("mount-4", "tenant-b", "4", "4Gi", false),
+	)}
+	used, err := GetResourceBudgetUsage(context.TODO(), client, "tenant-a")
+	assert.NoError(t, err)
+	cpu, memory := used[corev1.ResourceCPU], used[corev1.ResourceMemory]
+	assert.Equal(t, 0, cpu.Cmp(resource.MustParse("1500m")), "cpu: %s", cpu.String())
+	assert.Equal(t, 0, memory.Cmp(resource.MustParse("1Gi")), "memory: %s", memory.String())
+}
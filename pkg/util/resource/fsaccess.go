@@ -0,0 +1,62 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package resource
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+// ReasonFSAccessDenied is the reason of events recorded when a volume is rejected by fsAccessPolicies
+const ReasonFSAccessDenied = "FSAccessDenied"
+
+// CheckFSAccess checks if the namespace can use the file system at fsPath by fsAccessPolicies in config.
+// fsName is the name of the file system, it's read from the secret of volume credentials if empty.
+func CheckFSAccess(ctx context.Context, client *k8sclient.K8sClient, namespace string, secretRef *corev1.SecretReference, fsName, fsPath string) error {
+	if len(config.GlobalConfig.FSAccessPolicies) == 0 {
+		return nil
+	}
+	ns, err := client.GetNamespaceByCache(ctx, namespace)
+	if err != nil {
+		ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+	}
+	access := config.FSAccess{Namespace: ns, FSName: fsName, Path: fsPath}
+	if secretRef != nil {
+		access.SecretNamespace, access.SecretName = secretRef.Namespace, secretRef.Name
+		if fsName == "" {
+			if secret, err := client.GetSecret(ctx, secretRef.Name, secretRef.Namespace); err == nil {
+				access.FSName = string(secret.Data["name"])
+			} else {
+				resourceLog.Error(err, "get secret of volume credentials error", "name", secretRef.Name, "namespace", secretRef.Namespace)
+			}
+		}
+	}
+	return config.GlobalConfig.CheckFSAccess(access)
+}
+
+// RecordFSAccessDenied records a warning event on the object, e.g. pvc or app pod, for auditing violations of fsAccessPolicies
+func RecordFSAccessDenied(ctx context.Context, client *k8sclient.K8sClient, ref corev1.ObjectReference, component string, err error) {
+	resourceLog.Info("file system access is denied", "kind", ref.Kind, "name", ref.Name, "namespace", ref.Namespace, "error", err.Error())
+	if e := client.CreateObjectEvent(ctx, ref, component, corev1.EventTypeWarning, ReasonFSAccessDenied, err.Error()); e != nil {
+		resourceLog.Error(e, "create event error", "kind", ref.Kind, "name", ref.Name, "namespace", ref.Namespace)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+)
+
+const (
+	metricsPortName = "metrics"
+
+	PrometheusScrapeKey = "prometheus.io/scrape"
+	PrometheusPortKey   = "prometheus.io/port"
+	PrometheusPathKey   = "prometheus.io/path"
+)
+
+// randomMetricsOptionRe matches the metrics option set by builder for hostNetwork mount pod
+var randomMetricsOptionRe = regexp.MustCompile(`metrics=0\.0\.0\.0:0\b`)
+
+// metricsOptionRe matches the metrics option with its port in mount command, e.g. metrics=0.0.0.0:9567
+var metricsOptionRe = regexp.MustCompile(`metrics=[^,\s']*:([0-9]{1,5})\b`)
+
+// GetCommandMetricsPort returns the metrics port in the mount command of mount pod, 0 if it is not set or random
+func GetCommandMetricsPort(pod *corev1.Pod) int32 {
+	if len(pod.Spec.Containers) == 0 || len(pod.Spec.Containers[0].Command) < 3 {
+		return 0
+	}
+	match := metricsOptionRe.FindStringSubmatch(pod.Spec.Containers[0].Command[2])
+	if match == nil {
+		return 0
+	}
+	port, err := strconv.ParseInt(match[1], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(port)
+}
+
+// UseRandomMetricsPort checks if hostNetwork mount pod listens metrics on a random port
+func UseRandomMetricsPort(pod *corev1.Pod) bool {
+	if !pod.Spec.HostNetwork || len(pod.Spec.Containers) == 0 || len(pod.Spec.Containers[0].Command) < 3 {
+		return false
+	}
+	return randomMetricsOptionRe.MatchString(pod.Spec.Containers[0].Command[2])
+}
+
+// SetMetricsPort makes hostNetwork mount pod listen metrics on port instead of a random one,
+// and declares the port in the mount container. It keeps the port of mount pod regenerated from an old one.
+func SetMetricsPort(pod *corev1.Pod, port int32) {
+	if !pod.Spec.HostNetwork || len(pod.Spec.Containers) == 0 || len(pod.Spec.Containers[0].Command) < 3 {
+		return
+	}
+	container := &pod.Spec.Containers[0]
+	option := fmt.Sprintf("metrics=0.0.0.0:%d", port)
+	if randomMetricsOptionRe.MatchString(container.Command[2]) {
+		container.Command[2] = randomMetricsOptionRe.ReplaceAllString(container.Command[2], option)
+	} else if !regexp.MustCompile(regexp.QuoteMeta(option) + `\b`).MatchString(container.Command[2]) {
+		// metrics option is set by user
+		return
+	}
+	ports := []corev1.ContainerPort{}
+	for _, p := range container.Ports {
+		if p.Name != metricsPortName {
+			ports = append(ports, p)
+		}
+	}
+	container.Ports = append(ports, corev1.ContainerPort{Name: metricsPortName, ContainerPort: port})
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[common.MetricsPortKey] = strconv.Itoa(int(port))
+}
+
+// GetMetricsPort returns the metrics port declared in mount container, 0 if not found
+func GetMetricsPort(pod *corev1.Pod) int32 {
+	if len(pod.Spec.Containers) == 0 {
+		return 0
+	}
+	for _, p := range pod.Spec.Containers[0].Ports {
+		if p.Name == metricsPortName {
+			return p.ContainerPort
+		}
+	}
+	return 0
+}
+
+// SetMetricsScrapeAnnotations adds prometheus scrape annotations of the metrics port to mount pod
+func SetMetricsScrapeAnnotations(pod *corev1.Pod) {
+	port := GetMetricsPort(pod)
+	if port == 0 {
+		return
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[PrometheusScrapeKey] = common.True
+	pod.Annotations[PrometheusPortKey] = strconv.Itoa(int(port))
+	pod.Annotations[PrometheusPathKey] = "/metrics"
+}
@@ -0,0 +1,100 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+)
+
+func genMetricsPod(hostNetwork bool, options string) *corev1.Pod {
+	return &corev1.Pod{
+		Spec: corev1.PodSpec{
+			HostNetwork: hostNetwork,
+			Containers: []corev1.Container{{
+				Command: []string{"sh", "-c", "exec /usr/local/bin/juicefs mount ${metaurl} /jfs/pvc-xxx -o " + options},
+			}},
+		},
+	}
+}
+
+func TestSetMetricsPort(t *testing.T) {
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		wantOpts string
+		wantPort int32
+		wantAnno bool
+	}{
+		{
+			name:     "random port",
+			pod:      genMetricsPod(true, "cache-size=100,metrics=0.0.0.0:0"),
+			wantOpts: "cache-size=100,metrics=0.0.0.0:9600",
+			wantPort: 9600,
+			wantAnno: true,
+		},
+		{
+			name:     "regenerated with allocated port",
+			pod:      genMetricsPod(true, "metrics=0.0.0.0:9600,cache-size=100"),
+			wantOpts: "metrics=0.0.0.0:9600,cache-size=100",
+			wantPort: 9600,
+			wantAnno: true,
+		},
+		{
+			name:     "port set by user",
+			pod:      genMetricsPod(true, "metrics=0.0.0.0:9700"),
+			wantOpts: "metrics=0.0.0.0:9700",
+		},
+		{
+			name:     "not host network",
+			pod:      genMetricsPod(false, "metrics=0.0.0.0:0"),
+			wantOpts: "metrics=0.0.0.0:0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetMetricsPort(tt.pod, 9600)
+			assert.Equal(t, "exec /usr/local/bin/juicefs mount ${metaurl} /jfs/pvc-xxx -o "+tt.wantOpts, tt.pod.Spec.Containers[0].Command[2])
+			assert.Equal(t, tt.wantPort, GetMetricsPort(tt.pod))
+			_, ok := tt.pod.Annotations[common.MetricsPortKey]
+			assert.Equal(t, tt.wantAnno, ok)
+		})
+	}
+}
+
+func TestGetCommandMetricsPort(t *testing.T) {
+	assert.Equal(t, int32(9600), GetCommandMetricsPort(genMetricsPod(true, "cache-size=100,metrics=0.0.0.0:9600")))
+	assert.Equal(t, int32(9700), GetCommandMetricsPort(genMetricsPod(true, "metrics=[::]:9700,cache-size=100")))
+	assert.Equal(t, int32(0), GetCommandMetricsPort(genMetricsPod(true, "metrics=0.0.0.0:0")))
+	assert.Equal(t, int32(0), GetCommandMetricsPort(genMetricsPod(true, "cache-size=100")))
+}
+
+func TestSetMetricsScrapeAnnotations(t *testing.T) {
+	pod := genMetricsPod(true, "metrics=0.0.0.0:0")
+	SetMetricsScrapeAnnotations(pod)
+	assert.Empty(t, pod.Annotations, "no annotations without metrics port")
+
+	SetMetricsPort(pod, 9601)
+	SetMetricsScrapeAnnotations(pod)
+	assert.Equal(t, "true", pod.Annotations[PrometheusScrapeKey])
+	assert.Equal(t, "9601", pod.Annotations[PrometheusPortKey])
+	assert.Equal(t, "/metrics", pod.Annotations[PrometheusPathKey])
+}
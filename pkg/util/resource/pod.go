@@ -107,6 +107,20 @@ func IsPodResourceError(pod *corev1.Pod) bool {
 	return false
 }
 
+// IsPodOOMKilled checks if any container of pod is OOMKilled and not running again
+func IsPodOOMKilled(pod *corev1.Pod) bool {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Terminated != nil && cs.State.Terminated.Reason == "OOMKilled" {
+			return true
+		}
+		if cs.State.Waiting != nil && cs.LastTerminationState.Terminated != nil &&
+			cs.LastTerminationState.Terminated.Reason == "OOMKilled" {
+			return true
+		}
+	}
+	return false
+}
+
 func DeleteResourceOfPod(pod *corev1.Pod) {
 	for i := range pod.Spec.Containers {
 		pod.Spec.Containers[i].Resources.Requests = nil
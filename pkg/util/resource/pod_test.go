@@ -534,6 +534,53 @@ func TestIsPodResourceError(t *testing.T) {
 	}
 }
 
+func TestIsPodOOMKilled(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []corev1.ContainerStatus
+		want     bool
+	}{
+		{
+			name: "test-terminated",
+			statuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+			}},
+			want: true,
+		},
+		{
+			name: "test-crash-loop",
+			statuses: []corev1.ContainerStatus{{
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+			}},
+			want: true,
+		},
+		{
+			name: "test-running-again",
+			statuses: []corev1.ContainerStatus{{
+				State:                corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+			}},
+			want: false,
+		},
+		{
+			name: "test-error",
+			statuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+			}},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: tt.statuses}}
+			if got := IsPodOOMKilled(pod); got != tt.want {
+				t.Errorf("IsPodOOMKilled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetAllRefKeys(t *testing.T) {
 	type args struct {
 		pod corev1.Pod
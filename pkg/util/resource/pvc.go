@@ -21,9 +21,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"regexp"
+	"strconv"
 	"strings"
 
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
@@ -31,68 +32,95 @@ import (
 
 	"github.com/juicedata/juicefs-csi-driver/pkg/common"
 	k8s "github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/template"
 )
 
-var (
-	pattern = regexp.MustCompile(`\${\.(PVC|pvc|node)\.((labels|annotations)\.(.*?)|.*?)}`)
-)
+type ObjectMeta struct {
+	pvc     v1.PersistentVolumeClaim
+	resolve template.Resolver
+}
 
-type objectMetadata struct {
-	data        map[string]string
-	labels      map[string]string
-	annotations map[string]string
+// NewObjectMeta returns the ObjectMeta resolving variables of pvc (.PVC or .pvc), node (.node) and storageClass (.SC or .sc)
+func NewObjectMeta(pvc v1.PersistentVolumeClaim, node *v1.Node, sc *storagev1.StorageClass) *ObjectMeta {
+	pvcObj, scObj := template.PVC(&pvc), template.StorageClass(sc)
+	return &ObjectMeta{
+		pvc: pvc,
+		resolve: template.ObjectResolver(map[string]*template.Object{
+			"PVC":  pvcObj,
+			"pvc":  pvcObj,
+			"node": template.Node(node),
+			"SC":   scObj,
+			"sc":   scObj,
+		}),
+	}
 }
 
-type ObjectMeta struct {
-	pvc, node *objectMetadata
+// Parse expands templates in str, and returns error if there are unknown variables or functions
+func (meta *ObjectMeta) Parse(str string) (string, error) {
+	return template.Expand(str, meta.resolve)
 }
 
-func NewObjectMeta(pvc v1.PersistentVolumeClaim, node *v1.Node) *ObjectMeta {
-	meta := &ObjectMeta{
-		pvc: &objectMetadata{
-			data: map[string]string{
-				"name":      pvc.Name,
-				"namespace": pvc.Namespace,
-			},
-			labels:      pvc.Labels,
-			annotations: pvc.Annotations,
-		},
-		node: &objectMetadata{},
-	}
-	if node != nil {
-		meta.node.data = map[string]string{
-			"name":    node.Name,
-			"podCIDR": node.Spec.PodCIDR,
-		}
-		meta.node.labels = node.Labels
-		meta.node.annotations = node.Annotations
-	}
-	return meta
+// Validate checks templates in str without expanding them, e.g. unknown variables or functions
+func (meta *ObjectMeta) Validate(str string) error {
+	return template.Validate(str, meta.resolve)
 }
 
+// StringParser expands templates in str, str is returned as it is if it is invalid
 func (meta *ObjectMeta) StringParser(str string) string {
-	result := pattern.FindAllStringSubmatch(str, -1)
-	for _, r := range result {
-		switch r[1] {
-		case "PVC", "pvc":
-			str = meta.pvc.stringParser(str, r)
-		case "node":
-			str = meta.node.stringParser(str, r)
-		default:
-		}
+	result, err := meta.Parse(str)
+	if err != nil {
+		return str
 	}
-	return str
+	return result
 }
 
-func (meta *objectMetadata) stringParser(str string, matches []string) string {
-	switch matches[3] {
-	case "labels":
-		return strings.ReplaceAll(str, matches[0], meta.labels[matches[4]])
-	case "annotations":
-		return strings.ReplaceAll(str, matches[0], meta.annotations[matches[4]])
-	default:
-		return strings.ReplaceAll(str, matches[0], meta.data[matches[2]])
+// DynamicVolume is the volume resolved from StorageClass for pvc in dynamic provisioning
+type DynamicVolume struct {
+	// resolved parameters of StorageClass
+	Params       map[string]string
+	SubPath      string
+	MountOptions []string
+	// volume context of pv
+	VolCtx map[string]string
+}
+
+// ResolveDynamicVolume resolves parameters and mount options of StorageClass for the pv named pvName provisioned for pvc
+func ResolveDynamicVolume(pvName string, pvc v1.PersistentVolumeClaim, node *v1.Node, sc *storagev1.StorageClass) (DynamicVolume, error) {
+	meta := NewObjectMeta(pvc, node, sc)
+	vol := DynamicVolume{
+		Params:       make(map[string]string),
+		SubPath:      pvName,
+		MountOptions: make([]string, 0),
+		VolCtx:       make(map[string]string),
+	}
+	for k, v := range sc.Parameters {
+		if strings.HasPrefix(k, "csi.storage.k8s.io/") {
+			vol.Params[k] = meta.ResolveSecret(v, pvName)
+		} else {
+			parsed, err := meta.Parse(v)
+			if err != nil {
+				return vol, fmt.Errorf("parse parameter %s of storageClass %s error: %v", k, sc.Name, err)
+			}
+			vol.Params[k] = parsed
+		}
+	}
+	if vol.Params["pathPattern"] != "" {
+		vol.SubPath = vol.Params["pathPattern"]
+	}
+	for _, mo := range sc.MountOptions {
+		parsedStr, err := meta.Parse(mo)
+		if err != nil {
+			return vol, fmt.Errorf("parse mount option %s of storageClass %s error: %v", mo, sc.Name, err)
+		}
+		vol.MountOptions = append(vol.MountOptions, strings.Split(strings.TrimSpace(parsedStr), ",")...)
+	}
+
+	vol.VolCtx["subPath"] = vol.SubPath
+	vol.VolCtx["capacity"] = strconv.FormatInt(pvc.Spec.Resources.Requests.Storage().Value(), 10)
+	for k, v := range vol.Params {
+		vol.VolCtx[k] = v
 	}
+	return vol, nil
 }
 
 func CheckForSubPath(ctx context.Context, client *k8s.K8sClient, volume *v1.PersistentVolume, pathPattern string) (shouldDeleted bool, err error) {
@@ -133,13 +161,13 @@ func (meta *ObjectMeta) ResolveSecret(str string, pvName string) string {
 	resolved := os.Expand(str, func(k string) string {
 		switch k {
 		case "pvc.name":
-			return meta.pvc.data["name"]
+			return meta.pvc.Name
 		case "pvc.namespace":
-			return meta.pvc.data["namespace"]
+			return meta.pvc.Namespace
 		case "pv.name":
 			return pvName
 		}
-		for ak, av := range meta.pvc.annotations {
+		for ak, av := range meta.pvc.Annotations {
 			if k == "pvc.annotations['"+ak+"']" {
 				return av
 			}
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 
@@ -212,18 +213,21 @@ func TestObjectMetadata_StringParser(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			meta := &ObjectMeta{
-				pvc: &objectMetadata{
-					data:        tt.pvc.data,
-					labels:      tt.pvc.labels,
-					annotations: tt.pvc.annotations,
-				},
-				node: &objectMetadata{
-					data:        tt.node.data,
-					labels:      tt.node.labels,
-					annotations: tt.node.annotations,
+			pvc := v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+				Name:        tt.pvc.data["name"],
+				Namespace:   tt.pvc.data["namespace"],
+				Labels:      tt.pvc.labels,
+				Annotations: tt.pvc.annotations,
+			}}
+			node := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        tt.node.data["name"],
+					Labels:      tt.node.labels,
+					Annotations: tt.node.annotations,
 				},
+				Spec: v1.NodeSpec{PodCIDR: tt.node.data["podCIDR"]},
 			}
+			meta := NewObjectMeta(pvc, node, nil)
 			if got := meta.StringParser(tt.args.str); got != tt.want {
 				t.Errorf("StringParser() = %v, want %v", got, tt.want)
 			}
@@ -475,16 +479,45 @@ func TestResolveSecret(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			meta := &ObjectMeta{
-				pvc: &objectMetadata{
-					data:        tt.fields.data,
-					labels:      tt.fields.labels,
-					annotations: tt.fields.annotations,
-				},
-			}
+			meta := NewObjectMeta(v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+				Name:        tt.fields.data["name"],
+				Namespace:   tt.fields.data["namespace"],
+				Labels:      tt.fields.labels,
+				Annotations: tt.fields.annotations,
+			}}, nil, nil)
 			if got := meta.ResolveSecret(tt.args.str, tt.args.pvname); got != tt.want {
 				t.Errorf("ResolveSecret() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestResolveDynamicVolume(t *testing.T) {
+	pvc := v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+		Name:      "data",
+		Namespace: "team-a",
+		Labels:    map[string]string{"app": "Web"},
+	}}
+	sc := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "juicefs-sc"},
+		Parameters: map[string]string{
+			"pathPattern": `${.SC.name}/${.PVC.namespace}/${.PVC.labels.app | lower}-${.PVC.labels.env | default "dev"}`,
+		},
+		MountOptions: []string{"subdir=${.node.name | default \"any\"}"},
+	}
+	vol, err := ResolveDynamicVolume("pvc-1234", pvc, nil, sc)
+	if err != nil {
+		t.Fatalf("ResolveDynamicVolume() error = %v", err)
+	}
+	if vol.SubPath != "juicefs-sc/team-a/web-dev" || vol.VolCtx["subPath"] != vol.SubPath {
+		t.Errorf("subPath = %s", vol.SubPath)
+	}
+	if len(vol.MountOptions) != 1 || vol.MountOptions[0] != "subdir=any" {
+		t.Errorf("mountOptions = %v", vol.MountOptions)
+	}
+
+	sc.Parameters["pathPattern"] = "${.PVC.nmae}"
+	if _, err := ResolveDynamicVolume("pvc-1234", pvc, nil, sc); err == nil {
+		t.Errorf("ResolveDynamicVolume() expect error for unknown variable")
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -29,6 +30,7 @@ import (
 	jfsConfig "github.com/juicedata/juicefs-csi-driver/pkg/config"
 	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
 	"github.com/juicedata/juicefs-csi-driver/pkg/util"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/encryption"
 )
 
 func CreateOrUpdateSecret(ctx context.Context, client *k8sclient.K8sClient, secret *corev1.Secret) error {
@@ -59,6 +61,9 @@ func CreateOrUpdateSecret(ctx context.Context, client *k8sclient.K8sClient, secr
 		if len(secret.StringData) != len(oldSecret.Data) {
 			shouldUpdate = true
 		}
+		if shouldUpdate && encryptedSecretUnchanged(oldSecret, secret) {
+			shouldUpdate = false
+		}
 		// merge owner reference
 		if len(secret.OwnerReferences) != 0 {
 			newOwner := secret.OwnerReferences[0]
@@ -109,6 +114,27 @@ func CreateOrUpdateSecret(ctx context.Context, client *k8sclient.K8sClient, secr
 	return nil
 }
 
+// encryptedSecretUnchanged returns true if both secrets are encrypted from the same data,
+// their values differ as data keys and nonces are random
+func encryptedSecretUnchanged(oldSecret, secret *corev1.Secret) bool {
+	if oldSecret.Data[encryption.DataKeyName] == nil || !encryption.IsEncrypted(secret.StringData) {
+		return false
+	}
+	keyring, err := jfsConfig.SecretKeyring()
+	if err != nil || keyring == nil {
+		return false
+	}
+	oldData, err := jfsConfig.DecryptSecretData(oldSecret)
+	if err != nil {
+		return false
+	}
+	newData, err := keyring.Decrypt(secret.StringData)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(oldData, newData)
+}
+
 func GetSecretNameByUniqueId(uniqueId string) string {
 	return fmt.Sprintf("juicefs-%s-secret", uniqueId)
 }
@@ -0,0 +1,80 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package resource
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	k8s "github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+func setupSecretEncryption(t *testing.T) {
+	keyFile := path.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE="), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config.GlobalConfig.SecretEncryption = &config.SecretEncryption{KeyFiles: []string{keyFile}}
+}
+
+func encryptedSecret(t *testing.T, name string, data map[string]string) *corev1.Secret {
+	keyring, err := config.SecretKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := keyring.Encrypt(data, "jfsSettings", "metaurl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: config.Namespace},
+		StringData: encrypted,
+	}
+}
+
+func TestCreateOrUpdateSecret_Encrypted(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	setupSecretEncryption(t)
+	data := map[string]string{"jfsSettings": `{"name":"test"}`, "metaurl": "redis://127.0.0.1:6379/0"}
+	old := encryptedSecret(t, "juicefs-test-secret", data)
+	old.Data = map[string][]byte{}
+	for k, v := range old.StringData {
+		old.Data[k] = []byte(v)
+	}
+	old.StringData = nil
+	fakeClient := fake.NewSimpleClientset(old)
+	client := &k8s.K8sClient{Interface: fakeClient}
+
+	// encrypted again from the same data, values differ but should not be updated
+	assert.NoError(t, CreateOrUpdateSecret(context.TODO(), client, encryptedSecret(t, "juicefs-test-secret", data)))
+	for _, action := range fakeClient.Actions() {
+		assert.NotEqual(t, "patch", action.GetVerb())
+	}
+
+	changed := map[string]string{"jfsSettings": `{"name":"test"}`, "metaurl": "redis://127.0.0.1:6379/1"}
+	assert.NoError(t, CreateOrUpdateSecret(context.TODO(), client, encryptedSecret(t, "juicefs-test-secret", changed)))
+	actions := fakeClient.Actions()
+	assert.Equal(t, "patch", actions[len(actions)-1].GetVerb())
+}
@@ -19,19 +19,26 @@ package resource
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
 	"github.com/juicedata/juicefs-csi-driver/pkg/config"
 	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util"
 )
 
 type PVPair struct {
 	PV  *corev1.PersistentVolume
 	PVC *corev1.PersistentVolumeClaim
+	// Ephemeral is true if PV and PVC are resolved from volumeClaimTemplate of generic ephemeral volume,
+	// they are not created yet when the pod is admitted
+	Ephemeral bool
 }
 
 // GetVolumes get juicefs pv & pvc from pod
@@ -97,10 +104,166 @@ func getVol(ctx context.Context, client *k8sclient.K8sClient, pod *corev1.Pod, n
 				pvPairGot = append(pvPairGot, PVPair{PV: pv, PVC: pvc})
 			}
 		}
+		if volume.Ephemeral != nil && volume.Ephemeral.VolumeClaimTemplate != nil {
+			var pair *PVPair
+			pair, err = resolveEphemeralVolume(ctx, client, pod, volume)
+			if err != nil {
+				return
+			}
+			if pair != nil {
+				pvPairGot = append(pvPairGot, *pair)
+			}
+		}
 	}
 	return
 }
 
+// resolveEphemeralVolume resolves PVC of generic ephemeral volume from its volumeClaimTemplate, and PV from StorageClass
+// as provisioner does, since they are created after the pod. nil is returned if it is not a JuiceFS volume.
+func resolveEphemeralVolume(ctx context.Context, client *k8sclient.K8sClient, pod *corev1.Pod, volume corev1.Volume) (*PVPair, error) {
+	template := volume.Ephemeral.VolumeClaimTemplate
+	if template.Spec.StorageClassName == nil || *template.Spec.StorageClassName == "" {
+		return nil, nil
+	}
+	sc, err := client.GetStorageClass(ctx, *template.Spec.StorageClassName)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if sc.Provisioner != config.DriverName {
+		return nil, nil
+	}
+	// the PVC created by webhook has no consumer, since the volume is replaced by the mount point of sidecar,
+	// so it would be pending forever
+	if sc.VolumeBindingMode != nil && *sc.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer {
+		return nil, fmt.Errorf("generic ephemeral volume %s can not use storageClass %s with volumeBindingMode WaitForFirstConsumer in sidecar mode", volume.Name, sc.Name)
+	}
+	// subPath and secret depending on the name of pv can not be known before it is provisioned
+	if sc.Parameters["pathPattern"] == "" {
+		return nil, fmt.Errorf("generic ephemeral volume %s requires pathPattern in storageClass %s in sidecar mode", volume.Name, sc.Name)
+	}
+	for _, key := range []string{common.PublishSecretName, common.PublishSecretNamespace} {
+		if strings.Contains(sc.Parameters[key], "${pv.name}") {
+			return nil, fmt.Errorf("generic ephemeral volume %s can not use ${pv.name} in %s of storageClass %s in sidecar mode", volume.Name, key, sc.Name)
+		}
+	}
+	if pod.Name == "" {
+		// PVC is named <pod name>-<volume name>, generate the name of pod as apiserver does to know it
+		if pod.Name, err = generatePodName(ctx, client, pod); err != nil {
+			return nil, err
+		}
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pod.Name + "-" + volume.Name,
+			Namespace:   pod.Namespace,
+			Labels:      template.Labels,
+			Annotations: template.Annotations,
+		},
+		Spec: template.Spec,
+	}
+	return ephemeralPVPair(pvc, sc)
+}
+
+// ephemeralPVPair resolves PV of the PVC of generic ephemeral volume from StorageClass as provisioner does
+func ephemeralPVPair(pvc *corev1.PersistentVolumeClaim, sc *storagev1.StorageClass) (*PVPair, error) {
+	vol, err := ResolveDynamicVolume(pvc.Name, *pvc, nil, sc)
+	if err != nil {
+		return nil, err
+	}
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: pvc.Name},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:           config.DriverName,
+					VolumeHandle:     pvc.Name,
+					FSType:           "juicefs",
+					VolumeAttributes: vol.VolCtx,
+					NodePublishSecretRef: &corev1.SecretReference{
+						Name:      vol.Params[common.PublishSecretName],
+						Namespace: vol.Params[common.PublishSecretNamespace],
+					},
+				},
+			},
+			AccessModes:      pvc.Spec.AccessModes,
+			StorageClassName: sc.Name,
+			MountOptions:     vol.MountOptions,
+		},
+	}
+	return &PVPair{PV: pv, PVC: pvc, Ephemeral: true}, nil
+}
+
+// GetSidecarVolumes gets PV pairs of PVCs which sidecars are injected for. PVs of generic ephemeral volumes
+// are resolved from StorageClass as webhook does, since they are not provisioned when sidecars are injected.
+func GetSidecarVolumes(ctx context.Context, client *k8sclient.K8sClient, namespace string, pvcNames []string) ([]PVPair, error) {
+	pairs := make([]PVPair, 0, len(pvcNames))
+	for _, name := range pvcNames {
+		pvc, err := client.GetPersistentVolumeClaim(ctx, name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := pvc.Annotations[common.EphemeralPodAnnotationKey]; ok && pvc.Spec.StorageClassName != nil {
+			sc, err := client.GetStorageClass(ctx, *pvc.Spec.StorageClassName)
+			if err != nil {
+				return nil, err
+			}
+			pair, err := ephemeralPVPair(pvc, sc)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, *pair)
+			continue
+		}
+		if pvc.Spec.VolumeName == "" {
+			return nil, fmt.Errorf("pvc %s is not bound", pvc.Name)
+		}
+		pv, err := client.GetPersistentVolume(ctx, pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, PVPair{PV: pv, PVC: pvc})
+	}
+	return pairs, nil
+}
+
+// generatePodName generates the name of pod from its generateName, apiserver does not retry on conflicts
+// once the name is set, so names used by existing pods or PVCs of its ephemeral volumes are skipped
+func generatePodName(ctx context.Context, client *k8sclient.K8sClient, pod *corev1.Pod) (string, error) {
+	base := pod.GenerateName
+	if len(base) > 58 {
+		base = base[:58]
+	}
+	for i := 0; i < 5; i++ {
+		name := base + util.RandStringRunes(5)
+		used, err := isPodNameUsed(ctx, client, pod, name)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("can not generate an unused name of pod with generateName %s", pod.GenerateName)
+}
+
+func isPodNameUsed(ctx context.Context, client *k8sclient.K8sClient, pod *corev1.Pod, name string) (bool, error) {
+	if _, err := client.GetPod(ctx, name, pod.Namespace); err == nil || !k8serrors.IsNotFound(err) {
+		return err == nil, err
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Ephemeral == nil {
+			continue
+		}
+		if _, err := client.GetPersistentVolumeClaim(ctx, name+"-"+volume.Name, pod.Namespace); err == nil || !k8serrors.IsNotFound(err) {
+			return err == nil, err
+		}
+	}
+	return false, nil
+}
+
 type VolumeLocks struct {
 	locks sync.Map
 	mux   sync.Mutex
@@ -22,9 +22,12 @@ import (
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
 
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
 	k8s "github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
 )
 
@@ -271,3 +274,79 @@ func TestGetVolumes(t *testing.T) {
 		})
 	}
 }
+
+func TestGetVolumesOfEphemeral(t *testing.T) {
+	sc := func(name, provisioner string, params map[string]string) *storagev1.StorageClass {
+		return &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: name}, Provisioner: provisioner, Parameters: params}
+	}
+	k8sClient := &k8s.K8sClient{Interface: fake.NewSimpleClientset(
+		sc("jfs", "csi.juicefs.com", map[string]string{
+			common.PublishSecretName:      "juicefs-secret",
+			common.PublishSecretNamespace: "${pvc.namespace}",
+			"pathPattern":                 "${.pvc.namespace}-${.pvc.name}",
+		}),
+		sc("jfs-no-pattern", "csi.juicefs.com", map[string]string{
+			common.PublishSecretName:      "juicefs-secret",
+			common.PublishSecretNamespace: "default",
+		}),
+		sc("other", "other.csi.io", nil),
+		&storagev1.StorageClass{
+			ObjectMeta:        metav1.ObjectMeta{Name: "jfs-wait"},
+			Provisioner:       "csi.juicefs.com",
+			Parameters:        map[string]string{"pathPattern": "${.pvc.name}"},
+			VolumeBindingMode: ptr.To(storagev1.VolumeBindingWaitForFirstConsumer),
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app-used", Namespace: "default"}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "app-pvc-data", Namespace: "default"}},
+	)}
+	pod := func(scName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: "app-", Namespace: "default"},
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{Ephemeral: &corev1.EphemeralVolumeSource{
+					VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+						Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: &scName},
+					},
+				}},
+			}}},
+		}
+	}
+
+	p := pod("jfs")
+	used, pairs, err := GetVolumes(context.TODO(), k8sClient, p, "")
+	if err != nil || !used || len(pairs) != 1 {
+		t.Fatalf("GetVolumes() used = %v, pairs = %v, error = %v", used, pairs, err)
+	}
+	if p.Name == "" {
+		t.Fatalf("GetVolumes() does not generate name of pod")
+	}
+	pair := pairs[0]
+	if !pair.Ephemeral || pair.PVC.Name != p.Name+"-data" {
+		t.Errorf("GetVolumes() got pvc %s, ephemeral %v", pair.PVC.Name, pair.Ephemeral)
+	}
+	csi := pair.PV.Spec.CSI
+	if csi.VolumeAttributes["subPath"] != "default-"+p.Name+"-data" {
+		t.Errorf("GetVolumes() got subPath %s", csi.VolumeAttributes["subPath"])
+	}
+	if csi.NodePublishSecretRef.Name != "juicefs-secret" || csi.NodePublishSecretRef.Namespace != "default" {
+		t.Errorf("GetVolumes() got secret %v", csi.NodePublishSecretRef)
+	}
+
+	if _, _, err := GetVolumes(context.TODO(), k8sClient, pod("jfs-no-pattern"), ""); err == nil {
+		t.Errorf("GetVolumes() expects error without pathPattern")
+	}
+	if _, _, err := GetVolumes(context.TODO(), k8sClient, pod("jfs-wait"), ""); err == nil {
+		t.Errorf("GetVolumes() expects error with WaitForFirstConsumer")
+	}
+	for name, want := range map[string]bool{"app-used": true, "app-pvc": true, "app-free": false} {
+		if used, err := isPodNameUsed(context.TODO(), k8sClient, pod("jfs"), name); err != nil || used != want {
+			t.Errorf("isPodNameUsed(%s) = %v, error = %v, want %v", name, used, err, want)
+		}
+	}
+	for _, scName := range []string{"other", "not-exist"} {
+		if used, _, err := GetVolumes(context.TODO(), k8sClient, pod(scName), ""); err != nil || used {
+			t.Errorf("GetVolumes() of storageClass %s used = %v, error = %v", scName, used, err)
+		}
+	}
+}
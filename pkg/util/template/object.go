@@ -0,0 +1,153 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+// Object exposes fields and maps (e.g. labels) of a kubernetes object to templates, as ${.PVC.name}
+// and ${.PVC.labels.app}. Fields must exist in Fields, while a missing key in maps is empty.
+type Object struct {
+	Fields map[string]string
+	Maps   map[string]map[string]string
+}
+
+// PVC returns the template object of pvc, which has fields name and namespace, and maps labels and annotations
+func PVC(pvc *corev1.PersistentVolumeClaim) *Object {
+	if pvc == nil {
+		pvc = &corev1.PersistentVolumeClaim{}
+	}
+	return &Object{
+		Fields: map[string]string{"name": pvc.Name, "namespace": pvc.Namespace},
+		Maps:   map[string]map[string]string{"labels": pvc.Labels, "annotations": pvc.Annotations},
+	}
+}
+
+// Node returns the template object of node, which has fields name and podCIDR, and maps labels and annotations
+func Node(node *corev1.Node) *Object {
+	if node == nil {
+		node = &corev1.Node{}
+	}
+	return &Object{
+		Fields: map[string]string{"name": node.Name, "podCIDR": node.Spec.PodCIDR},
+		Maps:   map[string]map[string]string{"labels": node.Labels, "annotations": node.Annotations},
+	}
+}
+
+// StorageClass returns the template object of sc, which has field name, and maps labels, annotations and parameters
+func StorageClass(sc *storagev1.StorageClass) *Object {
+	if sc == nil {
+		sc = &storagev1.StorageClass{}
+	}
+	return &Object{
+		Fields: map[string]string{"name": sc.Name},
+		Maps:   map[string]map[string]string{"labels": sc.Labels, "annotations": sc.Annotations, "parameters": sc.Parameters},
+	}
+}
+
+// ObjectResolver resolves variables .<name>.<field> and .<name>.<map>.<key> of objects
+func ObjectResolver(objects map[string]*Object) Resolver {
+	return func(name string) (string, bool) {
+		if !strings.HasPrefix(name, ".") {
+			return "", false
+		}
+		parts := strings.SplitN(name[1:], ".", 3)
+		obj := objects[parts[0]]
+		if obj == nil || len(parts) < 2 {
+			return "", false
+		}
+		if len(parts) == 3 {
+			if m, ok := obj.Maps[parts[1]]; ok {
+				return m[parts[2]], true
+			}
+		}
+		v, ok := obj.Fields[strings.Join(parts[1:], ".")]
+		return v, ok
+	}
+}
+
+// ExpandJSON expands templates in all keys and string values of the JSON document
+func ExpandJSON(data []byte, resolve Resolver) ([]byte, error) {
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	doc, err = walkJSON(doc, func(s string) (string, error) {
+		return Expand(s, resolve)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// ValidateJSON validates templates in all keys and string values of the JSON document
+func ValidateJSON(data []byte, resolve Resolver) error {
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return err
+	}
+	_, err = walkJSON(doc, func(s string) (string, error) {
+		return s, Validate(s, resolve)
+	})
+	return err
+}
+
+// decodeJSON decodes data with numbers kept as they are
+func decodeJSON(data []byte) (interface{}, error) {
+	var doc interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	err := d.Decode(&doc)
+	return doc, err
+}
+
+func walkJSON(v interface{}, fn func(string) (string, error)) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		return fn(val)
+	case []interface{}:
+		for i := range val {
+			item, err := walkJSON(val[i], fn)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = item
+		}
+		return val, nil
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			key, err := fn(k)
+			if err != nil {
+				return nil, err
+			}
+			if result[key], err = walkJSON(item, fn); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	default:
+		return v, nil
+	}
+}
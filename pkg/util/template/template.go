@@ -0,0 +1,288 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package template expands templates like `${.PVC.labels.team | default "shared" | lower}` in
+// pathPattern, mount options and mountPodPatch.
+//
+// An expression is a variable followed by functions separated by "|", the value of the previous
+// one is passed to a function as its last argument. Arguments are double-quoted strings or integers.
+// Variables starting with "." must be known, otherwise an error is returned. Other expressions whose
+// variable is unknown, e.g. ${HOME} or ${VAR:-x} in a shell command, are left as they are.
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Resolver returns the value of a variable, and false if the variable is unknown
+type Resolver func(name string) (string, bool)
+
+// MapResolver resolves variables in the map
+func MapResolver(vars map[string]string) Resolver {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+// Chain returns a resolver which tries resolvers in order
+func Chain(resolvers ...Resolver) Resolver {
+	return func(name string) (string, bool) {
+		for _, r := range resolvers {
+			if r == nil {
+				continue
+			}
+			if v, ok := r(name); ok {
+				return v, true
+			}
+		}
+		return "", false
+	}
+}
+
+type function struct {
+	args int
+	call func(args []string, value string) (string, error)
+}
+
+var functions = map[string]function{
+	"default": {1, func(args []string, value string) (string, error) {
+		if value == "" {
+			return args[0], nil
+		}
+		return value, nil
+	}},
+	"required": {0, func(args []string, value string) (string, error) {
+		if value == "" {
+			return "", fmt.Errorf("value is required")
+		}
+		return value, nil
+	}},
+	"lower": {0, func(args []string, value string) (string, error) {
+		return strings.ToLower(value), nil
+	}},
+	"upper": {0, func(args []string, value string) (string, error) {
+		return strings.ToUpper(value), nil
+	}},
+	"trimPrefix": {1, func(args []string, value string) (string, error) {
+		return strings.TrimPrefix(value, args[0]), nil
+	}},
+	"trimSuffix": {1, func(args []string, value string) (string, error) {
+		return strings.TrimSuffix(value, args[0]), nil
+	}},
+	"trunc": {1, func(args []string, value string) (string, error) {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("invalid length %s", args[0])
+		}
+		if len(value) > n {
+			return value[:n], nil
+		}
+		return value, nil
+	}},
+	"hash": {0, func(args []string, value string) (string, error) {
+		h := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(h[:]), nil
+	}},
+	"shortHash": {0, func(args []string, value string) (string, error) {
+		h := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(h[:])[:8], nil
+	}},
+	"regexReplace": {2, func(args []string, value string) (string, error) {
+		re, err := regexp.Compile(args[0])
+		if err != nil {
+			return "", err
+		}
+		return re.ReplaceAllString(value, args[1]), nil
+	}},
+}
+
+type call struct {
+	name string
+	args []string
+}
+
+type expression struct {
+	start, end int // position of ${...} in template
+	variable   string
+	calls      []call
+}
+
+// Expand replaces expressions in str with their values
+func Expand(str string, resolve Resolver) (string, error) {
+	return expand(str, resolve, true)
+}
+
+// Validate checks syntax, functions and variables of expressions in str, without evaluating them
+func Validate(str string, resolve Resolver) error {
+	_, err := expand(str, resolve, false)
+	return err
+}
+
+func expand(str string, resolve Resolver, eval bool) (string, error) {
+	var sb strings.Builder
+	pos := 0
+	for {
+		i := strings.Index(str[pos:], "${")
+		if i < 0 {
+			sb.WriteString(str[pos:])
+			return sb.String(), nil
+		}
+		start := pos + i
+		if name := leadingWord(str[start+2:]); !strings.HasPrefix(name, ".") {
+			if _, ok := resolve(name); !ok {
+				// not a template, e.g. shell variable
+				sb.WriteString(str[pos : start+2])
+				pos = start + 2
+				continue
+			}
+		}
+		expr, err := parse(str, start)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(str[pos:start])
+		pos = expr.end
+		value, ok := resolve(expr.variable)
+		if !ok {
+			return "", fmt.Errorf("unknown variable %s in %q", expr.variable, str[expr.start:expr.end])
+		}
+		for _, c := range expr.calls {
+			fn, ok := functions[c.name]
+			if !ok {
+				return "", fmt.Errorf("unknown function %s in %q", c.name, str[expr.start:expr.end])
+			}
+			if len(c.args) != fn.args {
+				return "", fmt.Errorf("function %s requires %d arguments in %q", c.name, fn.args, str[expr.start:expr.end])
+			}
+			if !eval {
+				// check arguments only
+				if _, err := fn.call(c.args, "x"); err != nil {
+					return "", fmt.Errorf("%s in %q", err, str[expr.start:expr.end])
+				}
+				continue
+			}
+			if value, err = fn.call(c.args, value); err != nil {
+				return "", fmt.Errorf("%s: %v", str[expr.start:expr.end], err)
+			}
+		}
+		sb.WriteString(value)
+	}
+}
+
+// parse parses the expression starting from "${" at start
+func parse(str string, start int) (expression, error) {
+	expr := expression{start: start}
+	var segments [][]string
+	var tokens []string
+	var token strings.Builder
+	inToken := false
+	flush := func() {
+		if inToken {
+			tokens = append(tokens, token.String())
+			token.Reset()
+			inToken = false
+		}
+	}
+	for i := start + 2; i < len(str); i++ {
+		ch := str[i]
+		switch {
+		case ch == '"':
+			if inToken {
+				return expr, fmt.Errorf("unexpected quote in %q", str[start:])
+			}
+			end, err := quotedEnd(str, i)
+			if err != nil {
+				return expr, err
+			}
+			s, err := strconv.Unquote(str[i:end])
+			if err != nil {
+				return expr, fmt.Errorf("invalid string %s: %v", str[i:end], err)
+			}
+			// keep quoted strings distinguishable from function names
+			tokens = append(tokens, "\""+s)
+			i = end - 1
+		case ch == '|':
+			flush()
+			segments = append(segments, tokens)
+			tokens = nil
+		case ch == '}':
+			flush()
+			segments = append(segments, tokens)
+			expr.end = i + 1
+			return expr, expr.build(segments, str[start:expr.end])
+		case unicode.IsSpace(rune(ch)):
+			flush()
+		default:
+			token.WriteByte(ch)
+			inToken = true
+		}
+	}
+	return expr, fmt.Errorf("unclosed expression %q", str[start:])
+}
+
+// leadingWord returns the variable name at the beginning of an expression
+func leadingWord(str string) string {
+	str = strings.TrimLeftFunc(str, unicode.IsSpace)
+	if i := strings.IndexFunc(str, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '|' || r == '}' || r == '"'
+	}); i >= 0 {
+		return str[:i]
+	}
+	return str
+}
+
+func quotedEnd(str string, start int) (int, error) {
+	for i := start + 1; i < len(str); i++ {
+		switch str[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unclosed string in %q", str[start:])
+}
+
+func (e *expression) build(segments [][]string, raw string) error {
+	if len(segments[0]) != 1 || strings.HasPrefix(segments[0][0], "\"") {
+		return fmt.Errorf("expression %q should start with a variable", raw)
+	}
+	e.variable = segments[0][0]
+	for _, seg := range segments[1:] {
+		if len(seg) == 0 || strings.HasPrefix(seg[0], "\"") {
+			return fmt.Errorf("function name is required after | in %q", raw)
+		}
+		c := call{name: seg[0]}
+		for _, arg := range seg[1:] {
+			if strings.HasPrefix(arg, "\"") {
+				c.args = append(c.args, arg[1:])
+			} else if _, err := strconv.Atoi(arg); err == nil {
+				c.args = append(c.args, arg)
+			} else {
+				return fmt.Errorf("invalid argument %s of %s in %q, strings should be quoted", arg, c.name, raw)
+			}
+		}
+		e.calls = append(e.calls, c)
+	}
+	return nil
+}
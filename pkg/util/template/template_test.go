@@ -0,0 +1,106 @@
+/*
+Copyright 2026 Juicedata Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testResolver() Resolver {
+	pvc := PVC(&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+		Name:      "Data-01",
+		Namespace: "kube-team-a",
+		Labels:    map[string]string{"team": "Infra"},
+	}})
+	return Chain(
+		MapResolver(map[string]string{"VOLUME_ID": "pvc-1234"}),
+		ObjectResolver(map[string]*Object{
+			"PVC":  pvc,
+			"node": Node(nil),
+			"SC": StorageClass(&storagev1.StorageClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "juicefs-sc"},
+				Parameters: map[string]string{"tier": "gold"},
+			}),
+		}),
+	)
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name    string
+		str     string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", str: "no template", want: "no template"},
+		{name: "legacy variables", str: "${.PVC.namespace}-${.PVC.name}/${VOLUME_ID}", want: "kube-team-a-Data-01/pvc-1234"},
+		{name: "label", str: "${.PVC.labels.team | lower}", want: "infra"},
+		{name: "missing label with default", str: `${.PVC.labels.owner | default "shared"}`, want: "shared"},
+		{name: "missing label is empty", str: "a${.PVC.annotations.foo}b", want: "ab"},
+		{name: "required", str: "${.PVC.labels.owner | required}", wantErr: true},
+		{name: "empty node", str: `${.node.name | default "any"}`, want: "any"},
+		{name: "storage class", str: "${.SC.name}-${.SC.parameters.tier | upper}", want: "juicefs-sc-GOLD"},
+		{name: "regex replace", str: `${.PVC.namespace | regexReplace "^kube-(.*)$" "$1"}`, want: "team-a"},
+		{name: "brace in argument", str: `${.PVC.name | regexReplace "[0-9]{2}" "x"}`, want: "Data-x"},
+		{name: "short hash", str: "${.PVC.name | shortHash}", want: "7a635d5a"},
+		{name: "trunc", str: "${.PVC.namespace | trunc 4 | trimSuffix \"-\"}", want: "kube"},
+		{name: "shell variables are kept", str: `echo ${HOME} ${VAR:-"x"} ${ VOLUME_ID }`, want: `echo ${HOME} ${VAR:-"x"} pvc-1234`},
+		{name: "unknown variable", str: "${.PVC.nmae}", wantErr: true},
+		{name: "unknown object", str: "${.pod.name}", wantErr: true},
+		{name: "unknown function", str: "${.PVC.name | title}", wantErr: true},
+		{name: "wrong arguments", str: "${.PVC.name | default}", wantErr: true},
+		{name: "unquoted argument", str: "${.PVC.name | default shared}", wantErr: true},
+		{name: "unclosed", str: "${.PVC.name", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.str, testResolver())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("${.PVC.labels.owner | required}", testResolver()); err != nil {
+		t.Errorf("Validate() error = %v, values should not be evaluated", err)
+	}
+	if err := Validate(`${.PVC.name | regexReplace "(" ""}`, testResolver()); err == nil {
+		t.Errorf("Validate() expect error for invalid regex")
+	}
+	if err := ValidateJSON([]byte(`{"labels":{"a":"${.PVC.nmae}"}}`), testResolver()); err == nil {
+		t.Errorf("ValidateJSON() expect error for unknown variable")
+	}
+}
+
+func TestExpandJSON(t *testing.T) {
+	got, err := ExpandJSON([]byte(`{"labels":{"${.SC.name}":"${.PVC.labels.team | default \"x\"}"},"uid":1000000000000001}`), testResolver())
+	if err != nil {
+		t.Fatalf("ExpandJSON() error = %v", err)
+	}
+	if string(got) != `{"labels":{"juicefs-sc":"Infra"},"uid":1000000000000001}` {
+		t.Errorf("ExpandJSON() = %s", got)
+	}
+}
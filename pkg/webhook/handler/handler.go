@@ -23,6 +23,7 @@ import (
 	"net/http"
 
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -92,7 +93,8 @@ func (s *SidecarHandler) Handle(ctx context.Context, request admission.Request)
 	}
 
 	jfs := juicefs.NewJfsProvider(nil, s.Client)
-	sidecarMutate := mutate.NewSidecarMutate(s.Client, jfs, s.serverless, pair)
+	dryRun := request.DryRun != nil && *request.DryRun
+	sidecarMutate := mutate.NewSidecarMutate(s.Client, jfs, s.serverless, dryRun, pair)
 	handlerLog.Info("start injecting juicefs client as sidecar in pod", "name", pod.Name, "namespace", pod.Namespace)
 	out, err := sidecarMutate.Mutate(ctx, pod)
 	if err != nil {
@@ -174,6 +176,12 @@ func (s *PVHandler) Handle(ctx context.Context, request admission.Request) admis
 		return admission.Allowed("")
 	}
 
+	pvValidator := validator.NewPVValidator()
+	if err := pvValidator.Validate(ctx, *pv); err != nil {
+		handlerLog.Info("pv validation failed", "name", pv.Name, "error", err)
+		return admission.Denied(err.Error())
+	}
+
 	volumeHandle := pv.Spec.CSI.VolumeHandle
 	existPvs, err := s.Client.ListPersistentVolumesByVolumeHandle(ctx, volumeHandle)
 	if err != nil {
@@ -183,7 +191,108 @@ func (s *PVHandler) Handle(ctx context.Context, request admission.Request) admis
 	if len(existPvs) > 0 {
 		return admission.Denied(fmt.Sprintf("pv %s with volume handle %s already exists", pv.Name, volumeHandle))
 	}
-	return admission.Allowed("")
+	return admission.Allowed("").WithWarnings(pvValidator.Warnings...)
+}
+
+type StorageClassHandler struct {
+	Client *k8sclient.K8sClient
+	// A decoder will be automatically injected
+	decoder admission.Decoder
+}
+
+func NewStorageClassHandler(client *k8sclient.K8sClient, scheme *runtime.Scheme) *StorageClassHandler {
+	return &StorageClassHandler{
+		Client:  client,
+		decoder: admission.NewDecoder(scheme),
+	}
+}
+
+func (s *StorageClassHandler) Handle(ctx context.Context, request admission.Request) admission.Response {
+	sc := &storagev1.StorageClass{}
+	err := s.decoder.Decode(request, sc)
+	if err != nil {
+		handlerLog.Error(err, "unable to decoder storageClass from req")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	scValidator := validator.NewStorageClassValidator()
+	if err := scValidator.Validate(ctx, *sc); err != nil {
+		handlerLog.Info("storageClass validation failed", "name", sc.Name, "error", err)
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("").WithWarnings(scValidator.Warnings...)
+}
+
+type PVCHandler struct {
+	Client *k8sclient.K8sClient
+	// A decoder will be automatically injected
+	decoder admission.Decoder
+}
+
+func NewPVCHandler(client *k8sclient.K8sClient, scheme *runtime.Scheme) *PVCHandler {
+	return &PVCHandler{
+		Client:  client,
+		decoder: admission.NewDecoder(scheme),
+	}
+}
+
+func (s *PVCHandler) Handle(ctx context.Context, request admission.Request) admission.Response {
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := s.decoder.Decode(request, pvc)
+	if err != nil {
+		handlerLog.Error(err, "unable to decoder pvc from req")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if pvc.Namespace == "" {
+		pvc.Namespace = request.Namespace
+	}
+
+	pvcValidator := validator.NewPVCValidator(s.Client)
+	if len(request.OldObject.Raw) != 0 {
+		oldPVC := &corev1.PersistentVolumeClaim{}
+		if err := s.decoder.DecodeRaw(request.OldObject, oldPVC); err != nil {
+			handlerLog.Error(err, "unable to decoder old pvc from req")
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		pvcValidator.OldPVC = oldPVC
+	}
+	if err := pvcValidator.Validate(ctx, *pvc); err != nil {
+		handlerLog.Info("pvc validation failed", "name", pvc.Name, "namespace", pvc.Namespace, "error", err)
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("").WithWarnings(pvcValidator.Warnings...)
+}
+
+type ConfigMapHandler struct {
+	Client *k8sclient.K8sClient
+	// A decoder will be automatically injected
+	decoder admission.Decoder
+}
+
+func NewConfigMapHandler(client *k8sclient.K8sClient, scheme *runtime.Scheme) *ConfigMapHandler {
+	return &ConfigMapHandler{
+		Client:  client,
+		decoder: admission.NewDecoder(scheme),
+	}
+}
+
+func (s *ConfigMapHandler) Handle(ctx context.Context, request admission.Request) admission.Response {
+	cm := &corev1.ConfigMap{}
+	err := s.decoder.Decode(request, cm)
+	if err != nil {
+		handlerLog.Error(err, "unable to decoder configMap from req")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if cm.Namespace == "" {
+		cm.Namespace = request.Namespace
+	}
+
+	cmValidator := validator.NewConfigMapValidator(s.Client)
+	if err := cmValidator.Validate(ctx, *cm); err != nil {
+		handlerLog.Info("configMap validation failed", "name", cm.Name, "namespace", cm.Namespace, "error", err)
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("").WithWarnings(cmValidator.Warnings...)
 }
 
 var (
@@ -18,8 +18,11 @@ package mutate
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -63,6 +66,8 @@ type SidecarMutate struct {
 	juicefs               juicefs.Interface
 	Serverless            bool
 	supportsNativeSidecar bool
+	// DryRun is true if the request is a dry run, which must not create any object
+	DryRun bool
 
 	Pair       []resource.PVPair
 	jfsSetting *config.JfsSetting
@@ -70,7 +75,7 @@ type SidecarMutate struct {
 
 var _ Mutate = &SidecarMutate{}
 
-func NewSidecarMutate(client *k8sclient.K8sClient, jfs juicefs.Interface, serverless bool, pair []resource.PVPair) Mutate {
+func NewSidecarMutate(client *k8sclient.K8sClient, jfs juicefs.Interface, serverless, dryRun bool, pair []resource.PVPair) Mutate {
 	var serverVersion *version.Version
 	v, err := client.Discovery().ServerVersion()
 	if err != nil {
@@ -90,6 +95,7 @@ func NewSidecarMutate(client *k8sclient.K8sClient, jfs juicefs.Interface, server
 		Client:                client,
 		juicefs:               jfs,
 		Serverless:            serverless,
+		DryRun:                dryRun,
 		Pair:                  pair,
 		supportsNativeSidecar: checkSupportNativeSidecar(serverVersion),
 	}
@@ -97,8 +103,119 @@ func NewSidecarMutate(client *k8sclient.K8sClient, jfs juicefs.Interface, server
 
 func (s *SidecarMutate) Mutate(ctx context.Context, pod *corev1.Pod) (out *corev1.Pod, err error) {
 	out = pod.DeepCopy()
-	for i, pair := range s.Pair {
-		out, err = s.mutate(ctx, out, pair, i)
+	if err = s.checkFSAccess(ctx, out); err != nil {
+		return
+	}
+	for i := range s.Pair {
+		if s.Pair[i].Ephemeral {
+			if err = s.createEphemeralPVC(ctx, out, &s.Pair[i]); err != nil {
+				return
+			}
+		}
+	}
+	settings, err := s.genSettings(ctx, out)
+	if err != nil {
+		return
+	}
+	// settings are changed by injection, hash them before that
+	annotations := map[string]string{
+		common.SidecarHashAnnotationKey: SidecarHash(settings),
+		common.SidecarPVCsAnnotationKey: sidecarPVCs(s.Pair),
+	}
+	if !s.Serverless && shareSidecar(pod) {
+		out, err = s.mutateShared(ctx, out, settings)
+	} else {
+		for i, pair := range s.Pair {
+			if out, err = s.inject(ctx, out, pair, settings[i], i); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return
+	}
+	s.injectAnnotation(out, annotations)
+	return
+}
+
+// checkFSAccess checks if the namespace of pod can use file systems of all PVCs by fsAccessPolicies,
+// and records an event on the PVC which is denied
+func (s *SidecarMutate) checkFSAccess(ctx context.Context, pod *corev1.Pod) error {
+	if len(config.GlobalConfig.FSAccessPolicies) == 0 {
+		return nil
+	}
+	for _, pair := range s.Pair {
+		secrets, volCtx, options, err := s.GetSettings(*pair.PV)
+		if err != nil {
+			return err
+		}
+		if err = resource.CheckFSAccess(ctx, s.Client, pod.Namespace, pair.PV.Spec.CSI.NodePublishSecretRef, secrets["name"], config.PathInFS(options, volCtx["subPath"])); err != nil {
+			ref := corev1.ObjectReference{Kind: "PersistentVolumeClaim", APIVersion: "v1", Namespace: pair.PVC.Namespace, Name: pair.PVC.Name, UID: pair.PVC.UID}
+			resource.RecordFSAccessDenied(ctx, s.Client, ref, "juicefs-csi-controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// createEphemeralPVC creates PVC of generic ephemeral volume, which is not created by kubernetes since the volume
+// is replaced by the mount point of sidecar. The pod becomes its owner by controller once the pod is created.
+func (s *SidecarMutate) createEphemeralPVC(ctx context.Context, pod *corev1.Pod, pair *resource.PVPair) error {
+	pvc := pair.PVC.DeepCopy()
+	if pvc.Annotations == nil {
+		pvc.Annotations = map[string]string{}
+	}
+	pvc.Annotations[common.EphemeralPodAnnotationKey] = pod.Name
+	if s.DryRun {
+		sidecarLog.V(1).Info("skip creating pvc of generic ephemeral volume in dry run", "name", pvc.Name, "namespace", pvc.Namespace)
+		pair.PVC = pvc
+		return nil
+	}
+	sidecarLog.Info("create pvc of generic ephemeral volume", "name", pvc.Name, "namespace", pvc.Namespace)
+	created, err := s.Client.CreatePersistentVolumeClaim(ctx, pvc)
+	if k8serrors.IsAlreadyExists(err) {
+		// the webhook may be called again for the same pod, reuse the PVC created for it
+		created, err = s.Client.GetPersistentVolumeClaim(ctx, pvc.Name, pvc.Namespace)
+		if err == nil && (created.DeletionTimestamp != nil || len(created.OwnerReferences) != 0 ||
+			created.Annotations[common.EphemeralPodAnnotationKey] != pod.Name) {
+			return fmt.Errorf("pvc %s of generic ephemeral volume already exists and is not created for pod %s", pvc.Name, pod.Name)
+		}
+	}
+	if err != nil {
+		sidecarLog.Error(err, "create pvc of generic ephemeral volume error", "name", pvc.Name)
+		return err
+	}
+	pair.PVC = created
+	return nil
+}
+
+// shareSidecar checks if PVCs of the same filesystem in the pod share one sidecar
+func shareSidecar(pod *corev1.Pod) bool {
+	if v, ok := pod.Annotations[common.SharedSidecarKey]; ok {
+		return v == common.True
+	}
+	return config.GlobalConfig.EnableSharedSidecar
+}
+
+// mutateShared groups PVCs by filesystem and mount settings, and injects one sidecar for each group
+func (s *SidecarMutate) mutateShared(ctx context.Context, pod *corev1.Pod, settings []*config.JfsSetting) (out *corev1.Pod, err error) {
+	out = pod
+	groups := make(map[string][]int)
+	var keys []string
+	for i := range s.Pair {
+		key := sharedSidecarKey(settings[i])
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+	for index, key := range keys {
+		members := groups[key]
+		if len(members) == 1 {
+			out, err = s.inject(ctx, out, s.Pair[members[0]], settings[members[0]], index)
+		} else {
+			out, err = s.injectShared(ctx, out, members, settings, index)
+		}
 		if err != nil {
 			return
 		}
@@ -106,25 +223,66 @@ func (s *SidecarMutate) Mutate(ctx context.Context, pod *corev1.Pod) (out *corev
 	return
 }
 
-func (s *SidecarMutate) mutate(ctx context.Context, pod *corev1.Pod, pair resource.PVPair, index int) (out *corev1.Pod, err error) {
+// genSettings generates jfs settings of all PVCs used by the pod
+func (s *SidecarMutate) genSettings(ctx context.Context, pod *corev1.Pod) ([]*config.JfsSetting, error) {
+	settings := make([]*config.JfsSetting, len(s.Pair))
+	for i, pair := range s.Pair {
+		setting, err := s.genSetting(ctx, pod, pair)
+		if err != nil {
+			return nil, err
+		}
+		settings[i] = setting
+	}
+	return settings, nil
+}
+
+// SidecarHash returns the hash of settings of sidecars injected into a pod, which changes with the config,
+// secrets and mount image of any PVC
+func SidecarHash(settings []*config.JfsSetting) string {
+	hashes := make([]string, len(settings))
+	for i, setting := range settings {
+		hashes[i] = config.GenHashOfSetting(sidecarLog, *setting)
+	}
+	if len(hashes) == 1 {
+		return hashes[0]
+	}
+	sum := sha256.Sum256([]byte(strings.Join(hashes, ",")))
+	return hex.EncodeToString(sum[:])[:63]
+}
+
+// GenSidecarHash returns the hash of sidecars which would be injected into the pod now, for PVCs of pairs
+func GenSidecarHash(ctx context.Context, client *k8sclient.K8sClient, pod *corev1.Pod, pairs []resource.PVPair) (string, error) {
+	s := &SidecarMutate{Client: client, Pair: pairs}
+	settings, err := s.genSettings(ctx, pod)
+	if err != nil {
+		return "", err
+	}
+	return SidecarHash(settings), nil
+}
+
+func sidecarPVCs(pairs []resource.PVPair) string {
+	names := make([]string, len(pairs))
+	for i, pair := range pairs {
+		names[i] = pair.PVC.Name
+	}
+	return strings.Join(names, ",")
+}
+
+// genSetting generates jfs setting of the PVC used by the pod
+func (s *SidecarMutate) genSetting(ctx context.Context, pod *corev1.Pod, pair resource.PVPair) (*config.JfsSetting, error) {
 	// get secret, volumeContext and mountOptions from PV
 	secrets, volCtx, options, err := s.GetSettings(*pair.PV)
 	if err != nil {
 		sidecarLog.Error(err, "get settings from pv of pod err", "pv name", pair.PV.Name, "podName", pod.Name, "podNamespace", pod.Namespace)
-		return
+		return nil, err
 	}
 
 	if volCtx == nil {
 		volCtx = make(map[string]string)
 	}
-	// overwrite volume context
-	for k, v := range pair.PVC.Annotations {
-		if !strings.HasPrefix(k, "juicefs") {
-			continue
-		}
-		volCtx[k] = v
+	if isReadOnlyVolume(pod, pair.PVC.Name) {
+		options = append(options, "ro")
 	}
-	out = pod.DeepCopy()
 	// gen jfs settings
 	var node *corev1.Node
 	if pod.Spec.NodeName != "" && s.Client != nil {
@@ -140,10 +298,67 @@ func (s *SidecarMutate) mutate(ctx context.Context, pod *corev1.Pod, pair resour
 			},
 		}
 	}
-	jfsSetting, err := config.ParseSettingWithNode(ctx, secrets, volCtx, options, pair.PV.Spec.CSI.VolumeHandle, pair.PV.Spec.CSI.VolumeHandle, secrets["name"], pair.PV, pair.PVC, node)
+	var namespace *corev1.Namespace
+	if s.Client != nil {
+		namespace, err = s.Client.GetNamespaceByCache(ctx, pod.Namespace)
+		if err != nil {
+			namespace = nil
+		}
+	}
+	// overwrite volume context with pvc annotations, which may be admitted when the validating webhook
+	// was unavailable, so annotation policies are checked again
+	policyNamespace := namespace
+	if policyNamespace == nil {
+		policyNamespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pair.PVC.Namespace}}
+	}
+	for k, v := range pair.PVC.Annotations {
+		if !strings.HasPrefix(k, "juicefs") {
+			continue
+		}
+		if !config.GlobalConfig.IsPVCAnnotationAllowed(k, policyNamespace) {
+			sidecarLog.Info("ignore pvc annotation not allowed by annotation policies", "annotation", k, "pvc", pair.PVC.Name, "namespace", pair.PVC.Namespace)
+			continue
+		}
+		volCtx[k] = v
+	}
+	// app pod is needed to match appPodSelector of mountPodPatch when the setting is parsed
+	jfsSetting, err := config.ParseSettingWithAppPod(ctx, secrets, volCtx, options, pair.PV.Spec.CSI.VolumeHandle, pair.PV.Spec.CSI.VolumeHandle, secrets["name"], pair.PV, pair.PVC, node, namespace, pod)
 	if err != nil {
-		return
+		return nil, err
+	}
+	// annotations of app pod override those of PVC
+	if jfsSetting.Warmup, err = config.ParseWarmupSetting(volCtx, pod.Annotations); err != nil {
+		return nil, err
 	}
+	return jfsSetting, nil
+}
+
+// isReadOnlyVolume checks if the PVC is used by the pod as a read-only volume
+func isReadOnlyVolume(pod *corev1.Pod, pvcName string) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == pvcName {
+			return volume.PersistentVolumeClaim.ReadOnly
+		}
+	}
+	return false
+}
+
+// quotaCapacity returns capacity of PVC in GiB to set quota, 0 if quota is disabled or the volume is read-only
+func quotaCapacity(pvc *corev1.PersistentVolumeClaim, setting *config.JfsSetting) (int64, error) {
+	quotaEnabled := config.GlobalConfig.EnableSetQuota == nil || *config.GlobalConfig.EnableSetQuota
+	if !quotaEnabled || setting.ReadOnly {
+		return 0, nil
+	}
+	capacity := pvc.Spec.Resources.Requests.Storage().Value()
+	cap := capacity / 1024 / 1024 / 1024
+	if cap <= 0 {
+		return 0, fmt.Errorf("capacity %d is too small, at least 1GiB for quota", capacity)
+	}
+	return cap, nil
+}
+
+func (s *SidecarMutate) inject(ctx context.Context, pod *corev1.Pod, pair resource.PVPair, jfsSetting *config.JfsSetting, index int) (out *corev1.Pod, err error) {
+	out = pod.DeepCopy()
 	mountPath := util.RandStringRunes(6)
 	if s.Serverless {
 		mountPath = pair.PVC.Name
@@ -157,14 +372,9 @@ func (s *SidecarMutate) mutate(ctx context.Context, pod *corev1.Pod, pair resour
 	jfsSetting.SecretName = pair.PVC.Name + "-jfs-secret"
 	jfsSetting.AppPod = pod
 	s.jfsSetting = jfsSetting
-	quotaEnabled := config.GlobalConfig.EnableSetQuota == nil || *config.GlobalConfig.EnableSetQuota
-	capacity := pair.PVC.Spec.Resources.Requests.Storage().Value()
-	cap := capacity / 1024 / 1024 / 1024
-	if quotaEnabled && cap <= 0 {
-		return nil, fmt.Errorf("capacity %d is too small, at least 1GiB for quota", capacity)
-	}
-	if !quotaEnabled {
-		cap = 0
+	cap, err := quotaCapacity(pair.PVC, jfsSetting)
+	if err != nil {
+		return nil, err
 	}
 
 	var r builder.SidecarInterface
@@ -205,6 +415,115 @@ func (s *SidecarMutate) mutate(ctx context.Context, pod *corev1.Pod, pair resour
 	return
 }
 
+// injectShared injects one sidecar for PVCs of the same filesystem and mount settings, which mounts their
+// common parent directory, and binds path of each PVC to its own mount path
+func (s *SidecarMutate) injectShared(ctx context.Context, pod *corev1.Pod, members []int, settings []*config.JfsSetting, index int) (out *corev1.Pod, err error) {
+	out = pod.DeepCopy()
+	paths := make([]string, len(members))
+	for i, m := range members {
+		paths[i] = pathInFS(settings[m])
+	}
+	parent := commonParent(paths)
+
+	first := s.Pair[members[0]]
+	jfsSetting := settings[members[0]]
+	jfsSetting.Options = withoutSubdir(jfsSetting.Options)
+	if parent != "/" {
+		jfsSetting.Options = append(jfsSetting.Options, "subdir="+parent)
+	}
+	jfsSetting.SubPath = ""
+	jfsSetting.MountPath = filepath.Join(config.PodMountBase, util.RandStringRunes(6))
+	jfsSetting.Attr.Namespace = pod.Namespace
+	jfsSetting.SecretName = first.PVC.Name + "-jfs-secret"
+	jfsSetting.AppPod = pod
+	s.jfsSetting = jfsSetting
+
+	mountPaths := make([]string, len(members))
+	mounts := make([]builder.SharedMount, len(members))
+	for i, m := range members {
+		cap, err := quotaCapacity(s.Pair[m].PVC, settings[m])
+		if err != nil {
+			return nil, err
+		}
+		bindPath, _ := filepath.Rel(parent, paths[i])
+		mountPaths[i] = util.RandStringRunes(6)
+		mounts[i] = builder.SharedMount{
+			MountPath: filepath.Join(config.PodMountBase, mountPaths[i]),
+			BindPath:  bindPath,
+			SubPath:   settings[m].SubPath,
+			QuotaPath: paths[i],
+			Capacity:  cap,
+			Warmup:    settings[m].Warmup,
+		}
+	}
+	// warmup is done for each PVC under its own mount path
+	jfsSetting.Warmup = nil
+	r := builder.NewSharedContainerBuilder(jfsSetting, mounts)
+	mountPod := r.NewMountSidecar()
+	podStr, _ := json.Marshal(mountPod)
+	sidecarLog.V(1).Info("generate shared mount pod", "mount pod", string(podStr), "pvcs", len(members))
+
+	// secret is owned by the first PVC, the same as the sidecar of it only
+	secret := r.NewSecret()
+	builder.SetPVCAsOwner(&secret, first.PVC)
+	if err = s.createOrUpdateSecret(ctx, &secret); err != nil {
+		return
+	}
+
+	s.Deduplicate(pod, mountPod, index)
+	for i, m := range members {
+		// volumes of sidecar are injected only once
+		var volumes []corev1.Volume
+		if i == 0 {
+			volumes = mountPod.Spec.Volumes
+		}
+		s.injectVolume(out, r, volumes, mountPaths[i], s.Pair[m])
+	}
+	s.injectLabel(out)
+	s.injectAnnotation(out, mountPod.Annotations)
+	s.injectContainer(out, mountPod.Spec.Containers[0])
+	return
+}
+
+// sharedSidecarKey returns the key of PVCs which can share one sidecar, regardless of their paths in the filesystem
+func sharedSidecarKey(setting *config.JfsSetting) string {
+	s := *setting
+	s.Options = withoutSubdir(setting.Options)
+	// fields generated from the volume, the secret of the first PVC is used by the shared sidecar
+	s.UniqueId = ""
+	s.SecretName = ""
+	return config.GenHashOfSetting(sidecarLog, s)
+}
+
+// pathInFS returns the absolute path of the PVC in the filesystem, with subdir option and subPath
+func pathInFS(setting *config.JfsSetting) string {
+	return config.PathInFS(setting.Options, setting.SubPath)
+}
+
+func withoutSubdir(options []string) []string {
+	result := make([]string, 0, len(options))
+	for _, option := range options {
+		if !strings.HasPrefix(option, "subdir=") {
+			result = append(result, option)
+		}
+	}
+	return result
+}
+
+// commonParent returns the longest common directory of absolute paths
+func commonParent(paths []string) string {
+	parent := strings.Split(paths[0], "/")
+	for _, p := range paths[1:] {
+		parts := strings.Split(p, "/")
+		n := 0
+		for n < len(parent) && n < len(parts) && parent[n] == parts[n] {
+			n++
+		}
+		parent = parent[:n]
+	}
+	return path.Join("/", strings.Join(parent, "/"))
+}
+
 func (s *SidecarMutate) Deduplicate(pod, mountPod *corev1.Pod, index int) {
 	// deduplicate container name
 	var containers []corev1.Container
@@ -302,7 +621,14 @@ func (s *SidecarMutate) injectVolume(pod *corev1.Pod, build builder.SidecarInter
 		mountedVolume = append(mountedVolume, v)
 	}
 	for i, volume := range pod.Spec.Volumes {
-		if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == pair.PVC.Name {
+		claimName := ""
+		if volume.PersistentVolumeClaim != nil {
+			claimName = volume.PersistentVolumeClaim.ClaimName
+		} else if volume.Ephemeral != nil && pair.Ephemeral {
+			// PVC of generic ephemeral volume is named <pod name>-<volume name>
+			claimName = pod.Name + "-" + volume.Name
+		}
+		if claimName != "" && claimName == pair.PVC.Name {
 			// overwrite volume
 			build.OverwriteVolumes(&volume, mountPath)
 			pod.Spec.Volumes[i] = volume
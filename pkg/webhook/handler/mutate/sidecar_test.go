@@ -17,17 +17,24 @@
 package mutate
 
 import (
+	"context"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	volconf "github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
 
 	corev1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
 	"github.com/juicedata/juicefs-csi-driver/pkg/config"
 	"github.com/juicedata/juicefs-csi-driver/pkg/juicefs/mount/builder"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util"
 )
 
 func TestSidecarMutate_injectVolume(t *testing.T) {
@@ -317,3 +324,264 @@ func TestSidecarMutate_Deduplicate(t *testing.T) {
 		})
 	}
 }
+
+func Test_commonParent(t *testing.T) {
+	tests := []struct {
+		paths []string
+		want  string
+	}{
+		{paths: []string{"/a/b", "/a/c"}, want: "/a"},
+		{paths: []string{"/a/b", "/a/b/c"}, want: "/a/b"},
+		{paths: []string{"/ab", "/a"}, want: "/"},
+		{paths: []string{"/", "/a"}, want: "/"},
+		{paths: []string{"/a/b", "/a/b"}, want: "/a/b"},
+	}
+	for _, tt := range tests {
+		if got := commonParent(tt.paths); got != tt.want {
+			t.Errorf("commonParent(%v) = %v, want %v", tt.paths, got, tt.want)
+		}
+	}
+}
+
+func Test_sharedSidecarKey(t *testing.T) {
+	secrets := map[string]string{"name": "test", "metaurl": "redis://127.0.0.1/1", "secret-key": "secret"}
+	setting := func(volumeId, subPath string, options ...string) *config.JfsSetting {
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: volumeId},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{VolumeHandle: volumeId},
+				},
+			},
+		}
+		pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc-" + volumeId, Namespace: "default"}}
+		volCtx := map[string]string{"subPath": subPath}
+		s, err := config.ParseSettingWithNode(context.TODO(), secrets, volCtx, options, volumeId, volumeId, "test", pv, pvc, nil, nil)
+		if err != nil {
+			t.Fatalf("ParseSettingWithNode() error = %v", err)
+		}
+		return s
+	}
+	a := setting("pv-a", "pvc-a", "cache-size=100", "subdir=/data")
+	b := setting("pv-b", "pvc-b", "cache-size=100")
+	c := setting("pv-c", "pvc-c", "cache-size=200")
+	if a.SecretName == b.SecretName {
+		t.Fatalf("secret names of volumes should differ, got %s", a.SecretName)
+	}
+	if sharedSidecarKey(a) != sharedSidecarKey(b) {
+		t.Errorf("sharedSidecarKey() should be the same for PVCs only differing in paths")
+	}
+	if sharedSidecarKey(a) == sharedSidecarKey(c) {
+		t.Errorf("sharedSidecarKey() should differ for PVCs with different mount options")
+	}
+	roA, roB := setting("pv-a", "pvc-a", "cache-size=100", "ro"), setting("pv-b", "pvc-b", "cache-size=100", "ro")
+	if !roA.ReadOnly || !roB.ReadOnly {
+		t.Fatalf("settings with ro option should be read-only")
+	}
+	if sharedSidecarKey(roA) == sharedSidecarKey(roB) {
+		t.Errorf("sharedSidecarKey() should differ for read-only PVCs, whose clients are dedicated")
+	}
+	if got := pathInFS(a); got != "/data/pvc-a" {
+		t.Errorf("pathInFS() = %v, want /data/pvc-a", got)
+	}
+	if got := pathInFS(b); got != "/pvc-b" {
+		t.Errorf("pathInFS() = %v, want /pvc-b", got)
+	}
+}
+
+func TestSidecarMutate_checkFSAccess(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	ctx := context.TODO()
+	client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "juicefs-secret", Namespace: "kube-system"},
+		Data:       map[string][]byte{"name": []byte("shared")},
+	})}
+	pair := volconf.PVPair{
+		PV: &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-data"},
+			Spec: corev1.PersistentVolumeSpec{
+				MountOptions: []string{"subdir=/tenant-b"},
+				PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:               config.DriverName,
+					VolumeHandle:         "pv-data",
+					NodePublishSecretRef: &corev1.SecretReference{Name: "juicefs-secret", Namespace: "kube-system"},
+				}},
+			},
+		},
+		PVC: &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "tenant-a"}},
+	}
+	s := &SidecarMutate{Client: client, Pair: []volconf.PVPair{pair}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "tenant-a"}}
+	if err := s.checkFSAccess(ctx, pod); err != nil {
+		t.Errorf("checkFSAccess() error = %v without policies", err)
+	}
+
+	config.GlobalConfig.FSAccessPolicies = []config.FSAccessPolicy{{
+		Namespaces:     []string{"tenant-a"},
+		Filesystems:    []config.FSRef{{Secret: "kube-system/juicefs-secret"}},
+		SubdirPrefixes: []string{"/tenant-a"},
+	}}
+	if err := s.checkFSAccess(ctx, pod); err == nil {
+		t.Errorf("checkFSAccess() allows path of other tenant")
+	}
+	events, _ := client.CoreV1().Events("tenant-a").List(ctx, metav1.ListOptions{})
+	if len(events.Items) != 1 || events.Items[0].Reason != volconf.ReasonFSAccessDenied || events.Items[0].InvolvedObject.Name != "data" {
+		t.Errorf("events = %v, want an event of reason %s on the pvc", events.Items, volconf.ReasonFSAccessDenied)
+	}
+
+	pair.PV.Spec.MountOptions = []string{"subdir=/tenant-a"}
+	if err := s.checkFSAccess(ctx, pod); err != nil {
+		t.Errorf("checkFSAccess() error = %v", err)
+	}
+}
+
+func TestSidecarMutate_createEphemeralPVC(t *testing.T) {
+	ctx := context.TODO()
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}}
+	for _, dryRun := range []bool{true, false} {
+		client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset()}
+		s := &SidecarMutate{Client: client, DryRun: dryRun}
+		pair := &volconf.PVPair{PVC: &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "app-data", Namespace: "default"}}, Ephemeral: true}
+		if err := s.createEphemeralPVC(ctx, pod, pair); err != nil {
+			t.Fatalf("createEphemeralPVC() error = %v", err)
+		}
+		if pair.PVC.Annotations[common.EphemeralPodAnnotationKey] != "app" {
+			t.Errorf("createEphemeralPVC() got annotations %v", pair.PVC.Annotations)
+		}
+		pvcs, _ := client.CoreV1().PersistentVolumeClaims("default").List(ctx, metav1.ListOptions{})
+		if want := map[bool]int{true: 0, false: 1}[dryRun]; len(pvcs.Items) != want {
+			t.Errorf("createEphemeralPVC() in dry run %v created %d pvcs, want %d", dryRun, len(pvcs.Items), want)
+		}
+	}
+}
+
+func TestSidecarMutate_Mutate_appPodSelector(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	config.GlobalConfig.MountPodPatch = []config.MountPodPatch{{
+		AppPodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "spark"}},
+		MountOptions:   []string{"cache-size=102400"},
+	}}
+	ctx := context.TODO()
+	newMutate := func() *SidecarMutate {
+		client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "juicefs-secret", Namespace: "kube-system"},
+			Data: map[string][]byte{
+				"name":    []byte("test"),
+				"metaurl": []byte("redis://127.0.0.1:6379/0"),
+			},
+		})}
+		return &SidecarMutate{Client: client, Pair: []volconf.PVPair{{
+			PV: &corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pv-data"},
+				Spec: corev1.PersistentVolumeSpec{
+					PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+						Driver:               config.DriverName,
+						VolumeHandle:         "pv-data",
+						NodePublishSecretRef: &corev1.SecretReference{Name: "juicefs-secret", Namespace: "kube-system"},
+					}},
+				},
+			},
+			PVC: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+				Spec: corev1.PersistentVolumeClaimSpec{Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: k8sresource.MustParse("10Gi")},
+				}},
+			},
+		}}}
+	}
+	newPod := func(labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", Labels: labels},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: "busybox"}},
+				Volumes: []corev1.Volume{{
+					Name:         "data",
+					VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}},
+				}},
+			},
+		}
+	}
+	sidecarCommand := func(pod *corev1.Pod) string {
+		for _, c := range append(util.CopySlice(pod.Spec.InitContainers), pod.Spec.Containers...) {
+			if c.Name != "app" {
+				return strings.Join(c.Command, " ")
+			}
+		}
+		return ""
+	}
+
+	spark, err := newMutate().Mutate(ctx, newPod(map[string]string{"app": "spark"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd := sidecarCommand(spark); !strings.Contains(cmd, "cache-size=102400") {
+		t.Errorf("mountPodPatch with matched appPodSelector is not applied, command: %s", cmd)
+	}
+	web, err := newMutate().Mutate(ctx, newPod(map[string]string{"app": "web"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd := sidecarCommand(web); cmd == "" || strings.Contains(cmd, "cache-size=102400") {
+		t.Errorf("mountPodPatch with unmatched appPodSelector is applied, command: %s", cmd)
+	}
+	// settings are hashed before injection, the patch should be applied when they are parsed
+	if spark.Annotations[common.SidecarHashAnnotationKey] == web.Annotations[common.SidecarHashAnnotationKey] {
+		t.Errorf("hash of sidecars should differ when mountPodPatch is only applied to one of the pods")
+	}
+}
+
+func TestSidecarMutate_genSetting_annotationPolicies(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	config.GlobalConfig.PVCAnnotationPolicies = []config.PVCAnnotationPolicy{{
+		Namespaces:  []string{"ml"},
+		AllowedKeys: []string{"juicefs/mount-image"},
+	}}
+	client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "juicefs-secret", Namespace: "kube-system"},
+		Data: map[string][]byte{
+			"name":    []byte("test"),
+			"metaurl": []byte("redis://127.0.0.1:6379/0"),
+		},
+	})}
+	pair := func(namespace string) volconf.PVPair {
+		return volconf.PVPair{
+			PV: &corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pv-data"},
+				Spec: corev1.PersistentVolumeSpec{
+					PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+						Driver:               config.DriverName,
+						VolumeHandle:         "pv-data",
+						NodePublishSecretRef: &corev1.SecretReference{Name: "juicefs-secret", Namespace: "kube-system"},
+					}},
+				},
+			},
+			PVC: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "data",
+					Namespace:   namespace,
+					Annotations: map[string]string{common.MountPodImageKey: "juicedata/mount:custom"},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		namespace string
+		wantImage bool
+	}{
+		{namespace: "ml", wantImage: true},
+		{namespace: "default", wantImage: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			s := &SidecarMutate{Client: client}
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: tt.namespace}}
+			setting, err := s.genSetting(context.TODO(), pod, pair(tt.namespace))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := setting.Attr.Image == "juicedata/mount:custom"; got != tt.wantImage {
+				t.Errorf("image of mount pod = %s, annotation applied: %v, want %v", setting.Attr.Image, got, tt.wantImage)
+			}
+		})
+	}
+}
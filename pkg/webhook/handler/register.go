@@ -31,6 +31,9 @@ const (
 	ServerlessPath = "/juicefs/serverless/inject-v1-pod"
 	SecretPath     = "/juicefs/validate-secret"
 	PVPath         = "/juicefs/validate-pv"
+	PVCPath        = "/juicefs/validate-pvc"
+	SCPath         = "/juicefs/validate-storageclass"
+	ConfigMapPath  = "/juicefs/validate-configmap"
 	EvictPodPath   = "/juicefs/validate-evict-pod"
 )
 
@@ -50,6 +53,9 @@ func Register(mgr manager.Manager, client *k8sclient.K8sClient) {
 	if config.ValidatingWebhook {
 		server.Register(SecretPath, &webhook.Admission{Handler: NewSecretHandler(client, scheme)})
 		server.Register(PVPath, &webhook.Admission{Handler: NewPVHandler(client, scheme)})
+		server.Register(PVCPath, &webhook.Admission{Handler: NewPVCHandler(client, scheme)})
+		server.Register(SCPath, &webhook.Admission{Handler: NewStorageClassHandler(client, scheme)})
+		server.Register(ConfigMapPath, &webhook.Admission{Handler: NewConfigMapHandler(client, scheme)})
 		server.Register(EvictPodPath, &webhook.Admission{Handler: NewEvictPodHandler(client, scheme)})
 	}
 }
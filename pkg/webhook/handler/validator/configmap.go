@@ -0,0 +1,155 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package validator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+// listMatchTargetsTimeout is less than timeoutSeconds of the webhook, which is 5s
+const listMatchTargetsTimeout = 3 * time.Second
+
+// ConfigMapValidator checks config.yaml in the CSI config map before it takes effect,
+// other config maps are ignored
+type ConfigMapValidator struct {
+	client *k8sclient.K8sClient
+	// warnings of the last validation, e.g. unknown fields or patches matching nothing
+	Warnings []string
+}
+
+var _ Validator[corev1.ConfigMap] = &ConfigMapValidator{}
+
+func NewConfigMapValidator(client *k8sclient.K8sClient) *ConfigMapValidator {
+	return &ConfigMapValidator{client: client}
+}
+
+func (v *ConfigMapValidator) Validate(ctx context.Context, cm corev1.ConfigMap) error {
+	v.Warnings = nil
+	if cm.Name != config.GetGlobalConfigName() || cm.Namespace != config.GetGlobalConfigNamespace() {
+		return nil
+	}
+	data := cm.Data["config.yaml"]
+	if err := config.ValidateConfigData(data); err != nil {
+		return fmt.Errorf("invalid config.yaml: %v", err)
+	}
+	cfg := &config.Config{}
+	if err := yaml.UnmarshalStrict([]byte(data), cfg); err != nil {
+		v.Warnings = append(v.Warnings, fmt.Sprintf("config.yaml: %v", err))
+		if err := cfg.Unmarshal([]byte(data)); err != nil {
+			return fmt.Errorf("invalid config.yaml: %v", err)
+		}
+	}
+
+	hasSelector := false
+	for _, patch := range cfg.MountPodPatch {
+		if patch.PVCSelector != nil || patch.NodeSelector != nil || patch.NamespaceSelector != nil {
+			hasSelector = true
+			break
+		}
+	}
+	if !hasSelector {
+		return nil
+	}
+	// selectors are only checked for warnings, give up before the webhook times out
+	listCtx, cancel := context.WithTimeout(ctx, listMatchTargetsTimeout)
+	defer cancel()
+	pvcs, nodes, namespaces, err := v.listMatchTargets(listCtx, cfg)
+	if err != nil {
+		v.Warnings = append(v.Warnings, fmt.Sprintf("can not check selectors of mountPodPatch: %v", err))
+		return nil
+	}
+	v.Warnings = append(v.Warnings, cfg.UnmatchedMountPodPatches(pvcs, nodes, namespaces)...)
+	return nil
+}
+
+// listMatchTargets lists what selectors of mountPodPatch in cfg match: PVCs bound to JuiceFS PVs, nodes matching any nodeSelector,
+// and namespaces of these PVCs. Only objects of the kinds selected are listed, and lists are served from the watch cache of apiserver.
+func (v *ConfigMapValidator) listMatchTargets(ctx context.Context, cfg *config.Config) ([]corev1.PersistentVolumeClaim, []corev1.Node, []corev1.Namespace, error) {
+	var withPVC, withNamespace bool
+	var nodes []corev1.Node
+	for _, patch := range cfg.MountPodPatch {
+		withPVC = withPVC || patch.PVCSelector != nil
+		withNamespace = withNamespace || patch.NamespaceSelector != nil
+		if patch.NodeSelector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(patch.NodeSelector)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid nodeSelector: %v", err)
+		}
+		nodeList, err := v.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{ResourceVersion: "0", LabelSelector: selector.String()})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("list nodes error: %v", err)
+		}
+		nodes = append(nodes, nodeList.Items...)
+	}
+	if !withPVC && !withNamespace {
+		return nil, nodes, nil, nil
+	}
+
+	// PVCs of JuiceFS are known from claimRef of JuiceFS PVs
+	pvList, err := v.client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("list pvs error: %v", err)
+	}
+	claims := make(map[types.NamespacedName]bool)
+	claimNamespaces := make(map[string]bool)
+	for _, pv := range pvList.Items {
+		if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == config.DriverName && pv.Spec.ClaimRef != nil {
+			claims[types.NamespacedName{Namespace: pv.Spec.ClaimRef.Namespace, Name: pv.Spec.ClaimRef.Name}] = true
+			claimNamespaces[pv.Spec.ClaimRef.Namespace] = true
+		}
+	}
+	if len(claims) == 0 {
+		return nil, nodes, nil, nil
+	}
+
+	var pvcs []corev1.PersistentVolumeClaim
+	if withPVC {
+		pvcList, err := v.client.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{ResourceVersion: "0"})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("list pvcs error: %v", err)
+		}
+		for _, pvc := range pvcList.Items {
+			if claims[types.NamespacedName{Namespace: pvc.Namespace, Name: pvc.Name}] {
+				pvcs = append(pvcs, pvc)
+			}
+		}
+	}
+	var namespaces []corev1.Namespace
+	if withNamespace {
+		for name := range claimNamespaces {
+			// those failed to get are matched by name
+			namespace, err := v.client.GetNamespaceByCache(ctx, name)
+			if err != nil {
+				namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+			}
+			namespaces = append(namespaces, *namespace)
+		}
+	}
+	return pvcs, nodes, namespaces, nil
+}
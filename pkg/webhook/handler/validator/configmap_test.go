@@ -0,0 +1,125 @@
+package validator
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+func TestConfigMapValidator_Validate(t *testing.T) {
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-jfs"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{Driver: config.DriverName},
+			},
+			ClaimRef: &corev1.ObjectReference{Namespace: "default", Name: "data"},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default", Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-jfs"},
+	}
+	otherPVC := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "tenant", Labels: map[string]string{"app": "db"}},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-other"},
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"zone": "a"}}}
+	clientset := fake.NewSimpleClientset(pv, pvc, otherPVC, node,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant"}},
+	)
+	client := &k8sclient.K8sClient{Interface: clientset}
+	cm := func(name, data string) corev1.ConfigMap {
+		return corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: config.GetGlobalConfigNamespace()},
+			Data:       map[string]string{"config.yaml": data},
+		}
+	}
+	tests := []struct {
+		name         string
+		cm           corev1.ConfigMap
+		wantErr      bool
+		wantWarnings []string
+		wantLists    []string
+	}{
+		{name: "other config map", cm: cm("other", "mountPodPatch: [")},
+		{name: "valid", cm: cm(config.GetGlobalConfigName(), `
+mountPodPatch:
+  - pvcSelector:
+      matchLabels:
+        app: web
+    nodeSelector:
+      matchLabels:
+        zone: a
+`), wantLists: []string{"nodes", "persistentvolumes", "persistentvolumeclaims"}},
+		{name: "node selector only", cm: cm(config.GetGlobalConfigName(), `
+mountPodPatch:
+  - nodeSelector:
+      matchLabels:
+        zone: a
+`), wantLists: []string{"nodes"}},
+		{name: "invalid yaml", cm: cm(config.GetGlobalConfigName(), "mountPodPatch: ["), wantErr: true},
+		{name: "invalid label", cm: cm(config.GetGlobalConfigName(), `
+mountPodPatch:
+  - labels:
+      "invalid key": "v"
+`), wantErr: true},
+		{name: "unknown field", cm: cm(config.GetGlobalConfigName(), `
+mountPodPatchs:
+  - image: juicedata/mount:ce-v1.2.0
+`), wantWarnings: []string{"mountPodPatchs"}},
+		{name: "unmatched", cm: cm(config.GetGlobalConfigName(), `
+mountPodPatch:
+  - pvcSelector:
+      matchLabels:
+        app: db
+  - nodeSelector:
+      matchLabels:
+        zone: b
+  - namespaceSelector:
+      matchLabels:
+        kubernetes.io/metadata.name: tenant
+`), wantWarnings: []string{"mountPodPatch[0]: pvcSelector", "mountPodPatch[1]: nodeSelector", "mountPodPatch[2]: namespaceSelector"},
+			wantLists: []string{"nodes", "persistentvolumes", "persistentvolumeclaims"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset.ClearActions()
+			v := NewConfigMapValidator(client)
+			err := v.Validate(context.TODO(), tt.cm)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(v.Warnings) != len(tt.wantWarnings) {
+				t.Fatalf("Validate() warnings = %s, want %v", strings.Join(v.Warnings, "; "), tt.wantWarnings)
+			}
+			for i, want := range tt.wantWarnings {
+				if !strings.Contains(v.Warnings[i], want) {
+					t.Errorf("Validate() warning[%d] = %s, want containing %s", i, v.Warnings[i], want)
+				}
+			}
+			var lists []string
+			for _, action := range clientset.Actions() {
+				if list, ok := action.(k8stesting.ListActionImpl); ok {
+					lists = append(lists, list.GetResource().Resource)
+					if list.ListOptions.ResourceVersion != "0" {
+						t.Errorf("Validate() lists %s from etcd", list.GetResource().Resource)
+					}
+				}
+			}
+			if !slices.Equal(lists, tt.wantLists) {
+				t.Errorf("Validate() lists %v, want %v", lists, tt.wantLists)
+			}
+		})
+	}
+}
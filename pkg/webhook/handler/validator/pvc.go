@@ -0,0 +1,152 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package validator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+)
+
+// PVCValidator checks juicefs/* annotations of pvc against annotation policies, and mount pod resources
+// set in annotations against the resource budget of its namespace
+type PVCValidator struct {
+	client *k8sclient.K8sClient
+	// the pvc before update, annotations not changed are not checked against annotation policies
+	OldPVC *corev1.PersistentVolumeClaim
+	// warnings of the last validation, e.g. resources to be clamped by the budget
+	Warnings []string
+}
+
+var _ Validator[corev1.PersistentVolumeClaim] = &PVCValidator{}
+
+func NewPVCValidator(client *k8sclient.K8sClient) *PVCValidator {
+	return &PVCValidator{client: client}
+}
+
+func (v *PVCValidator) Validate(ctx context.Context, pvc corev1.PersistentVolumeClaim) error {
+	v.Warnings = nil
+	if err := v.validateAnnotations(ctx, pvc); err != nil {
+		return err
+	}
+	cpuLimit := pvc.Annotations[common.MountPodCpuLimitKey]
+	memoryLimit := pvc.Annotations[common.MountPodMemLimitKey]
+	cpuRequest := pvc.Annotations[common.MountPodCpuRequestKey]
+	memoryRequest := pvc.Annotations[common.MountPodMemRequestKey]
+	if cpuLimit == "" && memoryLimit == "" && cpuRequest == "" && memoryRequest == "" {
+		return nil
+	}
+	namespace, err := v.client.GetNamespaceByCache(ctx, pvc.Namespace)
+	if err != nil {
+		namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pvc.Namespace}}
+	}
+	budget := config.GlobalConfig.GetResourceBudget(namespace)
+	if budget == nil {
+		return nil
+	}
+	resources, err := config.ParsePodResources(cpuLimit, memoryLimit, cpuRequest, memoryRequest, config.GetDefaultResource(), nil)
+	if err != nil {
+		return fmt.Errorf("invalid mount pod resources: %v", err)
+	}
+	clamped, err := budget.Apply(resources, nil)
+	if err != nil {
+		return fmt.Errorf("mount pod resources exceed the budget of namespace %s: %v", pvc.Namespace, err)
+	}
+	v.Warnings = append(v.Warnings, clampWarnings(resources, clamped)...)
+
+	if len(budget.Total) == 0 {
+		return nil
+	}
+	used, err := resource.GetResourceBudgetUsage(ctx, v.client, pvc.Namespace)
+	if err != nil {
+		v.Warnings = append(v.Warnings, fmt.Sprintf("can not get resource budget usage of namespace %s: %v", pvc.Namespace, err))
+		return nil
+	}
+	if _, err := budget.Apply(clamped, used); err != nil {
+		// more mount pods may be created in other nodes, so it is not rejected
+		v.Warnings = append(v.Warnings, fmt.Sprintf("remaining budget of namespace %s is not enough for a new mount pod: %v", pvc.Namespace, err))
+	}
+	return nil
+}
+
+func (v *PVCValidator) validateAnnotations(ctx context.Context, pvc corev1.PersistentVolumeClaim) error {
+	if len(config.GlobalConfig.PVCAnnotationPolicies) == 0 {
+		return nil
+	}
+	var oldAnnotations map[string]string
+	if v.OldPVC != nil {
+		oldAnnotations = v.OldPVC.Annotations
+	}
+	var keys []string
+	for k, val := range pvc.Annotations {
+		if old, ok := oldAnnotations[k]; ok && old == val {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	namespace, err := v.client.GetNamespaceByCache(ctx, pvc.Namespace)
+	if err != nil {
+		namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pvc.Namespace}}
+	}
+	var denied []string
+	for _, k := range keys {
+		if !config.GlobalConfig.IsPVCAnnotationAllowed(k, namespace) {
+			denied = append(denied, k)
+		}
+	}
+	if len(denied) != 0 {
+		sort.Strings(denied)
+		return fmt.Errorf("annotations %s are not allowed on pvc in namespace %s", strings.Join(denied, ", "), pvc.Namespace)
+	}
+	return nil
+}
+
+func clampWarnings(origin, clamped corev1.ResourceRequirements) []string {
+	var warnings []string
+	for _, kind := range []struct {
+		name           string
+		origin, result corev1.ResourceList
+	}{
+		{"limit", origin.Limits, clamped.Limits},
+		{"request", origin.Requests, clamped.Requests},
+	} {
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			q, ok := kind.origin[name]
+			r, clampedOk := kind.result[name]
+			if clampedOk && (!ok || q.Cmp(r) != 0) {
+				from := "unlimited"
+				if ok {
+					from = q.String()
+				}
+				warnings = append(warnings, fmt.Sprintf("mount pod %s %s %s will be clamped to %s by the resource budget", name, kind.name, from, r.String()))
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,88 @@
+package validator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/k8sclient"
+)
+
+func TestPVCValidator_Validate(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	perPod := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("4Gi")}
+	config.GlobalConfig.ResourceBudgets = []config.ResourceBudget{
+		{Namespaces: []string{"tenant-clamp"}, Policy: config.ResourceBudgetPolicyClamp, MaxPerMountPod: perPod},
+		{Namespaces: []string{"tenant-reject"}, MaxPerMountPod: perPod},
+	}
+	client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset()}
+	pvc := func(namespace string, annotations map[string]string) corev1.PersistentVolumeClaim {
+		return corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: namespace, Annotations: annotations}}
+	}
+	tests := []struct {
+		name         string
+		pvc          corev1.PersistentVolumeClaim
+		wantErr      bool
+		wantWarnings int
+	}{
+		{name: "no annotations", pvc: pvc("tenant-reject", nil)},
+		{name: "no budget", pvc: pvc("default", map[string]string{common.MountPodCpuLimitKey: "64"})},
+		{name: "in budget", pvc: pvc("tenant-reject", map[string]string{common.MountPodCpuLimitKey: "1", common.MountPodMemLimitKey: "1Gi"})},
+		{name: "rejected", pvc: pvc("tenant-reject", map[string]string{common.MountPodCpuLimitKey: "64"}), wantErr: true},
+		{name: "invalid", pvc: pvc("tenant-reject", map[string]string{common.MountPodCpuLimitKey: "a lot"}), wantErr: true},
+		// memory limit from default 5Gi is clamped too
+		{name: "clamped", pvc: pvc("tenant-clamp", map[string]string{common.MountPodCpuLimitKey: "64"}), wantWarnings: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewPVCValidator(client)
+			err := v.Validate(context.TODO(), tt.pvc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(v.Warnings) != tt.wantWarnings {
+				t.Errorf("Validate() warnings = %s, want %d warnings", strings.Join(v.Warnings, "; "), tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestPVCValidator_Validate_annotationPolicies(t *testing.T) {
+	defer config.GlobalConfig.Reset()
+	config.GlobalConfig.PVCAnnotationPolicies = []config.PVCAnnotationPolicy{
+		{Namespaces: []string{"tenant"}, AllowedKeys: []string{common.MountPodImageKey}},
+	}
+	client := &k8sclient.K8sClient{Interface: fake.NewSimpleClientset()}
+	pvc := func(annotations map[string]string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "tenant", Annotations: annotations}}
+	}
+	hostPath := map[string]string{common.MountPodHostPath: "/etc"}
+	tests := []struct {
+		name    string
+		pvc     *corev1.PersistentVolumeClaim
+		old     *corev1.PersistentVolumeClaim
+		wantErr bool
+	}{
+		{name: "allowed", pvc: pvc(map[string]string{common.MountPodImageKey: "juicedata/mount:ce-nightly"})},
+		{name: "denied", pvc: pvc(hostPath), wantErr: true},
+		{name: "not changed", pvc: pvc(hostPath), old: pvc(hostPath)},
+		{name: "changed", pvc: pvc(hostPath), old: pvc(map[string]string{common.MountPodHostPath: "/tmp"}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewPVCValidator(client)
+			v.OldPVC = tt.old
+			err := v.Validate(context.TODO(), *tt.pvc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
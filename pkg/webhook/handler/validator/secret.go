@@ -54,7 +54,8 @@ func (s *SecretValidator) Validate(ctx context.Context, secret corev1.Secret) er
 	defer os.RemoveAll(tempConfDir)
 	jfsSetting.ClientConfPath = tempConfDir
 	if jfsSetting.IsCe {
-		metaUrl := secretsMap["metaurl"]
+		// metaurl may be fetched from the external credential provider
+		metaUrl := jfsSetting.MetaUrl
 		if metaUrl == "" {
 			return fmt.Errorf("metaurl is empty")
 		}
@@ -0,0 +1,134 @@
+/*
+ Copyright 2026 Juicedata Inc
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package validator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+	"github.com/juicedata/juicefs-csi-driver/pkg/util/resource"
+)
+
+// StorageClassValidator checks parameters and mount options of StorageClass of JuiceFS
+type StorageClassValidator struct {
+	// warnings of the last validation, e.g. unknown parameters
+	Warnings []string
+}
+
+var _ Validator[storagev1.StorageClass] = &StorageClassValidator{}
+
+func NewStorageClassValidator() *StorageClassValidator {
+	return &StorageClassValidator{}
+}
+
+func (v *StorageClassValidator) Validate(ctx context.Context, sc storagev1.StorageClass) error {
+	v.Warnings = nil
+	if sc.Provisioner != config.DriverName {
+		return nil
+	}
+	for _, pair := range [][2]string{
+		{common.PublishSecretName, common.PublishSecretNamespace},
+		{common.ProvisionerSecretName, common.ProvisionerSecretNamespace},
+		{common.ControllerExpandSecretName, common.ControllerExpandSecretNamespace},
+	} {
+		if (sc.Parameters[pair[0]] == "") != (sc.Parameters[pair[1]] == "") {
+			return fmt.Errorf("parameters %s and %s should be set together", pair[0], pair[1])
+		}
+	}
+	if sc.Parameters[common.PublishSecretName] == "" {
+		return fmt.Errorf("parameter %s is required", common.PublishSecretName)
+	}
+
+	// templates are expanded with pvc and node in provisioning, only check them here
+	meta := resource.NewObjectMeta(corev1.PersistentVolumeClaim{}, nil, &sc)
+	keys := make([]string, 0, len(sc.Parameters))
+	for k := range sc.Parameters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.HasPrefix(k, "csi.storage.k8s.io/") {
+			continue
+		}
+		if err := meta.Validate(sc.Parameters[k]); err != nil {
+			return fmt.Errorf("parameter %s: %v", k, err)
+		}
+	}
+	if pathPattern := sc.Parameters["pathPattern"]; pathPattern != "" && !strings.Contains(pathPattern, "${") {
+		v.Warnings = append(v.Warnings, "pathPattern has no template, all PVs of the StorageClass share the same subPath")
+	}
+	for _, mo := range sc.MountOptions {
+		if err := meta.Validate(mo); err != nil {
+			return fmt.Errorf("mount option %s: %v", mo, err)
+		}
+	}
+
+	warnings, err := config.ValidateVolumeContext(sc.Parameters, sc.MountOptions)
+	v.Warnings = append(v.Warnings, prefixWarnings("parameters", warnings)...)
+	if err != nil {
+		return fmt.Errorf("invalid parameters or mountOptions: %v", err)
+	}
+	return nil
+}
+
+// PVValidator checks volumeAttributes and mount options of static PV of JuiceFS
+type PVValidator struct {
+	// warnings of the last validation, e.g. unknown volumeAttributes
+	Warnings []string
+}
+
+var _ Validator[corev1.PersistentVolume] = &PVValidator{}
+
+func NewPVValidator() *PVValidator {
+	return &PVValidator{}
+}
+
+func (v *PVValidator) Validate(ctx context.Context, pv corev1.PersistentVolume) error {
+	v.Warnings = nil
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != config.DriverName {
+		return nil
+	}
+	// volumeAttributes of dynamic provisioned PV come from the StorageClass, which is checked by StorageClassValidator,
+	// while static PV may set storageClassName too, for PVCs to bind it by StorageClass
+	if pv.Annotations[common.ProvisionedByAnnotationKey] == config.DriverName {
+		return nil
+	}
+	if pv.Spec.CSI.NodePublishSecretRef == nil || pv.Spec.CSI.NodePublishSecretRef.Name == "" {
+		return fmt.Errorf("nodePublishSecretRef is required")
+	}
+	warnings, err := config.ValidateVolumeContext(pv.Spec.CSI.VolumeAttributes, pv.Spec.MountOptions)
+	v.Warnings = append(v.Warnings, prefixWarnings("volumeAttributes", warnings)...)
+	if err != nil {
+		return fmt.Errorf("invalid volumeAttributes or mountOptions: %v", err)
+	}
+	return nil
+}
+
+func prefixWarnings(field string, warnings []string) []string {
+	result := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		result = append(result, fmt.Sprintf("%s: %s", field, w))
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/juicedata/juicefs-csi-driver/pkg/common"
+	"github.com/juicedata/juicefs-csi-driver/pkg/config"
+)
+
+func TestStorageClassValidator_Validate(t *testing.T) {
+	secretParams := func(params map[string]string) map[string]string {
+		p := map[string]string{
+			common.PublishSecretName:          "juicefs-secret",
+			common.PublishSecretNamespace:     "default",
+			common.ProvisionerSecretName:      "juicefs-secret",
+			common.ProvisionerSecretNamespace: "default",
+		}
+		for k, v := range params {
+			p[k] = v
+		}
+		return p
+	}
+	tests := []struct {
+		name         string
+		sc           storagev1.StorageClass
+		wantErr      bool
+		wantWarnings int
+	}{
+		{name: "other provisioner", sc: storagev1.StorageClass{Provisioner: "ebs.csi.aws.com", Parameters: map[string]string{"type": "gp3"}}},
+		{name: "valid", sc: storagev1.StorageClass{
+			Provisioner:  config.DriverName,
+			Parameters:   secretParams(map[string]string{"pathPattern": "${.pvc.namespace}-${.pvc.name}", common.MountPodCpuLimitKey: "2"}),
+			MountOptions: []string{"subdir=${.pvc.annotations.subdir}"},
+		}},
+		{name: "missing secret", sc: storagev1.StorageClass{Provisioner: config.DriverName}, wantErr: true},
+		{name: "secret without namespace", sc: storagev1.StorageClass{
+			Provisioner: config.DriverName,
+			Parameters:  map[string]string{common.PublishSecretName: "juicefs-secret"},
+		}, wantErr: true},
+		{name: "unknown template variable", sc: storagev1.StorageClass{
+			Provisioner: config.DriverName,
+			Parameters:  secretParams(map[string]string{"pathPattern": "${.pvc.nmae}"}),
+		}, wantErr: true},
+		{name: "unknown template function", sc: storagev1.StorageClass{
+			Provisioner:  config.DriverName,
+			Parameters:   secretParams(nil),
+			MountOptions: []string{"subdir=${.pvc.name | title}"},
+		}, wantErr: true},
+		{name: "invalid cache pvc", sc: storagev1.StorageClass{
+			Provisioner: config.DriverName,
+			Parameters:  secretParams(map[string]string{common.CachePVC: "Cache_PVC"}),
+		}, wantErr: true},
+		{name: "non-privileged mount pod", sc: storagev1.StorageClass{
+			Provisioner: config.DriverName,
+			Parameters:  secretParams(map[string]string{common.MountPodPrivilegedKey: "false"}),
+		}},
+		{name: "invalid mount privileged", sc: storagev1.StorageClass{
+			Provisioner: config.DriverName,
+			Parameters:  secretParams(map[string]string{common.MountPodPrivilegedKey: "False"}),
+		}, wantErr: true},
+		{name: "fixed pathPattern and unknown parameter", sc: storagev1.StorageClass{
+			Provisioner: config.DriverName,
+			Parameters:  secretParams(map[string]string{"pathPattern": "shared", "mount-image": "juicedata/mount:ce-v1.2.0"}),
+		}, wantWarnings: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewStorageClassValidator()
+			err := v.Validate(context.TODO(), tt.sc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(v.Warnings) != tt.wantWarnings {
+				t.Errorf("Validate() warnings = %v, want %d warnings", v.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestPVValidator_Validate(t *testing.T) {
+	pv := func(storageClass string, attributes map[string]string, mountOptions []string) corev1.PersistentVolume {
+		annotations := map[string]string{}
+		if storageClass == "juicefs-sc" {
+			annotations[common.ProvisionedByAnnotationKey] = config.DriverName
+		}
+		return corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "juicefs-pv", Annotations: annotations},
+			Spec: corev1.PersistentVolumeSpec{
+				StorageClassName: storageClass,
+				MountOptions:     mountOptions,
+				PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+					Driver:               config.DriverName,
+					VolumeHandle:         "juicefs-pv",
+					VolumeAttributes:     attributes,
+					NodePublishSecretRef: &corev1.SecretReference{Name: "juicefs-secret", Namespace: "default"},
+				}},
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		pv      corev1.PersistentVolume
+		wantErr bool
+	}{
+		{name: "valid", pv: pv("", map[string]string{common.MountPodMemLimitKey: "2Gi"}, []string{"subdir=/data"})},
+		{name: "dynamic provisioned", pv: pv("juicefs-sc", map[string]string{"juicefs/unknown": "x"}, nil)},
+		{name: "static with storageClassName", pv: pv("manual", map[string]string{"juicefs/unknown": "x"}, nil), wantErr: true},
+		{name: "invalid mount option", pv: pv("", nil, []string{"cache-size=1=2"}), wantErr: true},
+		{name: "unknown juicefs key", pv: pv("", map[string]string{"juicefs/mount-cpu-limits": "1"}, nil), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewPVValidator().Validate(context.TODO(), tt.pv)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
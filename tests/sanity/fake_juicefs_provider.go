@@ -167,6 +167,10 @@ func (j *fakeJfsProvider) SetQuota(ctx context.Context, secrets map[string]strin
 	return nil
 }
 
+func (j *fakeJfsProvider) Warmup(ctx context.Context, jfsSetting *config.JfsSetting, warmupPath string) (string, error) {
+	return "", nil
+}
+
 func (j *fakeJfsProvider) GetSubPath(ctx context.Context, volumeID string) (string, error) {
 	return volumeID, nil
 }